@@ -0,0 +1,328 @@
+package jsonapisdk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	headerCacheControl    = "Cache-Control"
+	headerETag            = "ETag"
+	headerLastModified    = "Last-Modified"
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+	weakETagPrefix        = "W/"
+	eTagWildcard          = "*"
+	eTagListSeperator     = ","
+	queryInclude          = "include"
+)
+
+// marshalScopeConditional marshals the scope for the Get and List endpoints.
+// If the endpoint allows conditional requests, the response gets the 'ETag' and 'Last-Modified'
+// headers and the 304 Not Modified status is returned if the request's 'If-None-Match' or
+// 'If-Modified-Since' headers matches the marshaled content.
+// The strong ETag is computed from the marshaled payload, unless the model and all the included
+// models define the LastModifiedField. Then the weak ETag is computed from the resources' and
+// the included resources' last modification time. Both ETags depend on the 'Content-Language'.
+func (h *JSONAPIHandler) marshalScopeConditional(
+	model *ModelHandler,
+	endpoint *Endpoint,
+	scope *jsonapi.Scope,
+	rw http.ResponseWriter,
+	req *http.Request,
+) {
+	h.setCacheControl(rw, endpoint)
+	if endpoint == nil || !endpoint.ConditionalGet {
+		h.MarshalScope(scope, rw, req)
+		return
+	}
+
+	payload, err := h.Controller.MarshalScope(scope)
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while marshaling scope for model: '%v', for path: '%s', and method: '%s', Error: %s", scope.Struct.GetType(), req.URL.Path, req.Method, err)
+		h.errMarshalScope(rw, req)
+		return
+	}
+	setLanguageMeta(scope, payload)
+	h.setDescribedByLink(scope, payload)
+
+	buf := new(bytes.Buffer)
+	if err = jsonapi.MarshalPayload(buf, payload); err != nil {
+		h.errMarshalPayload(payload, err, scope.Struct.GetType(), rw, req)
+		return
+	}
+
+	var (
+		eTag         string
+		lastModified time.Time
+		contentLang  = rw.Header().Get(headerContentLanguage)
+	)
+
+	if model.LastModifiedField != "" {
+		var ok bool
+		lastModified, ok = h.scopeLastModified(model, scope)
+		if ok {
+			hash := sha256.New()
+			writeLastModified(model, scope, hash)
+
+			var included time.Time
+			if included, ok = h.includedLastModified(scope, hash); ok {
+				if included.After(lastModified) {
+					lastModified = included
+				}
+				eTag = weakETag(req, contentLang, hash.Sum(nil))
+			}
+		}
+		if !ok {
+			lastModified = time.Time{}
+		}
+	}
+
+	if eTag == "" {
+		eTag = strongETag(contentLang, buf.Bytes())
+	}
+
+	rw.Header().Set(headerETag, eTag)
+	if !lastModified.IsZero() {
+		rw.Header().Set(headerLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if isNotModified(req, eTag, lastModified) {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	SetContentType(rw)
+	if _, err = rw.Write(buf.Bytes()); err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while writing marshaled payload for model: '%v', Path: '%s'. Error: %v", scope.Struct.GetType(), req.URL.Path, err)
+	}
+}
+
+// checkNotModifiedSince is the cheap freshness check used by the Get endpoint before the whole
+// resource is taken from the repository.
+// If the model's repository implements LastModifiedRepository and the request contains only
+// the 'If-Modified-Since' header, the last modification time is taken from the repository.
+// The request with the 'include' parameter is not checked, as the included resources could be
+// modified after the root resource.
+// If the resource was not modified since the provided time, the 304 status is written and the
+// function returns true.
+func (h *JSONAPIHandler) checkNotModifiedSince(
+	model *ModelHandler,
+	endpoint *Endpoint,
+	scope *jsonapi.Scope,
+	rw http.ResponseWriter,
+	req *http.Request,
+) (notModified bool) {
+	if endpoint == nil || !endpoint.ConditionalGet || model.LastModifiedField == "" {
+		return false
+	}
+
+	// If-None-Match takes precedence over the If-Modified-Since. The ETag needs whole payload.
+	if req.Header.Get(headerIfNoneMatch) != "" {
+		return false
+	}
+
+	if req.URL.Query().Get(queryInclude) != "" {
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get(headerIfModifiedSince))
+	if err != nil {
+		return false
+	}
+
+	repo, ok := asLastModifiedRepository(h.repository(rw, model.ModelType))
+	if !ok {
+		return false
+	}
+
+	lastModified, dbErr := repo.LastModified(scope, model.LastModifiedField)
+	if dbErr != nil {
+		// let the full query handle the error
+		h.logger(rw, SubsystemEndpoint).Debugf("LastModified check failed for model: '%v'. %v", model.ModelType, dbErr)
+		return false
+	}
+
+	if lastModified.Truncate(time.Second).After(since) {
+		return false
+	}
+
+	h.setCacheControl(rw, endpoint)
+	rw.Header().Set(headerLastModified, lastModified.UTC().Format(http.TimeFormat))
+	rw.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func (h *JSONAPIHandler) setCacheControl(rw http.ResponseWriter, endpoint *Endpoint) {
+	if endpoint != nil && endpoint.CacheControl != "" {
+		rw.Header().Set(headerCacheControl, endpoint.CacheControl)
+	}
+}
+
+// scopeLastModified gets the latest value of the model's LastModifiedField from the scope's
+// value.
+func (h *JSONAPIHandler) scopeLastModified(
+	model *ModelHandler,
+	scope *jsonapi.Scope,
+) (lastModified time.Time, ok bool) {
+	field, found := model.ModelType.FieldByName(model.LastModifiedField)
+	if !found {
+		h.logger(nil, SubsystemEndpoint).Errorf("LastModifiedField: '%s' not found within model: '%v'", model.LastModifiedField, model.ModelType)
+		return
+	}
+
+	ok = true
+	forEachScopeValue(scope, func(single reflect.Value) {
+		if t, isTime := timeFieldValue(single.FieldByIndex(field.Index)); isTime && t.After(lastModified) {
+			lastModified = t
+		}
+	})
+	return
+}
+
+// includedLastModified writes the primary and the last modified values of the scope's included
+// resources into the 'hash' and returns their latest modification time. The included scopes are
+// walked recursively. If any of the included models does not define the LastModifiedField the
+// function returns false.
+func (h *JSONAPIHandler) includedLastModified(
+	scope *jsonapi.Scope,
+	hash io.Writer,
+) (lastModified time.Time, ok bool) {
+	defer scope.ResetIncludedField()
+
+	for scope.NextIncludedField() {
+		includedField, err := scope.CurrentIncludedField()
+		if err != nil {
+			h.logger(nil, SubsystemEndpoint).Errorf("Cannot get included field for model: '%v'. %v", scope.Struct.GetType(), err)
+			return lastModified, false
+		}
+
+		included := includedField.Scope
+		model, found := h.ModelHandlers[included.Struct.GetType()]
+		if !found || model.LastModifiedField == "" {
+			return lastModified, false
+		}
+
+		modified, found := h.scopeLastModified(model, included)
+		if !found {
+			return lastModified, false
+		}
+		writeLastModified(model, included, hash)
+
+		nested, found := h.includedLastModified(included, hash)
+		if !found {
+			return lastModified, false
+		}
+
+		for _, t := range []time.Time{modified, nested} {
+			if t.After(lastModified) {
+				lastModified = t
+			}
+		}
+	}
+	return lastModified, true
+}
+
+// writeLastModified writes the collection, the primary and the last modified values of the
+// scope's value into the 'hash'.
+func writeLastModified(model *ModelHandler, scope *jsonapi.Scope, hash io.Writer) {
+	field, _ := model.ModelType.FieldByName(model.LastModifiedField)
+	primIndex := scope.Struct.GetPrimaryField().GetFieldIndex()
+
+	hash.Write([]byte(scope.Struct.GetCollectionType()))
+	forEachScopeValue(scope, func(single reflect.Value) {
+		t, _ := timeFieldValue(single.FieldByIndex(field.Index))
+		fmt.Fprintf(hash, ";%v:%d", single.Field(primIndex).Interface(), t.UnixNano())
+	})
+	hash.Write([]byte("|"))
+}
+
+// forEachScopeValue calls the function for each non nil struct value within the scope's value.
+func forEachScopeValue(scope *jsonapi.Scope, f func(single reflect.Value)) {
+	if scope.Value == nil {
+		return
+	}
+	v := reflect.ValueOf(scope.Value)
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			f(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			single := v.Index(i)
+			if single.Kind() == reflect.Ptr {
+				if single.IsNil() {
+					continue
+				}
+				single = single.Elem()
+			}
+			f(single)
+		}
+	}
+}
+
+func timeFieldValue(field reflect.Value) (time.Time, bool) {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return time.Time{}, false
+		}
+		field = field.Elem()
+	}
+	t, ok := field.Interface().(time.Time)
+	return t, ok
+}
+
+// strongETag creates the ETag for the marshaled payload served in the 'contentLang'.
+func strongETag(contentLang string, payload []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(contentLang + "|"))
+	hash.Write(payload)
+	return "\"" + hex.EncodeToString(hash.Sum(nil)) + "\""
+}
+
+// weakETag creates weak ETag for the provided hash. The request's query and the 'contentLang' are
+// included into the ETag so that the different fieldsets, includes or languages does not match.
+func weakETag(req *http.Request, contentLang string, hash []byte) string {
+	h := sha256.New()
+	h.Write(hash)
+	fmt.Fprintf(h, "|%s|%s", req.URL.RawQuery, contentLang)
+	return weakETagPrefix + "\"" + hex.EncodeToString(h.Sum(nil)) + "\""
+}
+
+// isNotModified checks the conditional request headers. The 'If-None-Match' header takes
+// precedence over the 'If-Modified-Since'.
+func isNotModified(req *http.Request, eTag string, lastModified time.Time) bool {
+	if inm := req.Header.Get(headerIfNoneMatch); inm != "" {
+		for _, candidate := range strings.Split(inm, eTagListSeperator) {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == eTagWildcard || weakETagEqual(candidate, eTag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get(headerIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// weakETagEqual compares the ETags using the weak comparison.
+func weakETagEqual(first, second string) bool {
+	return strings.TrimPrefix(first, weakETagPrefix) == strings.TrimPrefix(second, weakETagPrefix)
+}
@@ -0,0 +1,173 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerGetConditional(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	blogModel := h.ModelHandlers[reflect.TypeOf(Blog{})]
+	blogModel.Get.ConditionalGet = true
+	blogModel.Get.CacheControl = "private, max-age=60"
+
+	mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Twice().Return(nil).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value = &Blog{ID: 1, Lang: arg.LanguageFilters.Values[0].Values[0].(string)}
+		})
+
+	// Case 1:
+	// The response contains ETag and Cache-Control headers
+	rw, req := getHttpPair("GET", "/blogs/1", nil)
+	h.Get(blogModel, blogModel.Get).ServeHTTP(rw, req)
+
+	assert.Equal(t, 200, rw.Result().StatusCode)
+	eTag := rw.Header().Get(headerETag)
+	assert.NotEmpty(t, eTag)
+	assert.Equal(t, "private, max-age=60", rw.Header().Get(headerCacheControl))
+
+	// Case 2:
+	// Matching If-None-Match results with 304 and no body
+	rw, req = getHttpPair("GET", "/blogs/1", nil)
+	req.Header.Set(headerIfNoneMatch, eTag)
+	h.Get(blogModel, blogModel.Get).ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotModified, rw.Result().StatusCode)
+	assert.Equal(t, 0, rw.Body.Len())
+	assert.Equal(t, eTag, rw.Header().Get(headerETag))
+	assert.Empty(t, rw.Header().Get("Content-Type"))
+
+	// Case 3:
+	// The resource served in other language has different ETag
+	rw, req = getHttpPair("GET", "/blogs/1", nil)
+	req.Header.Set(headerAcceptLanguage, "pl")
+	req.Header.Set(headerIfNoneMatch, eTag)
+	h.Get(blogModel, blogModel.Get).ServeHTTP(rw, req)
+
+	assert.Equal(t, 200, rw.Result().StatusCode)
+	assert.NotEqual(t, eTag, rw.Header().Get(headerETag))
+}
+
+func TestHandlerListConditional(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	postModel := h.ModelHandlers[reflect.TypeOf(Post{})]
+	postModel.LastModifiedField = "CreatedAt"
+	postModel.List.ConditionalGet = true
+
+	modified := time.Date(2018, 6, 20, 12, 0, 0, 0, time.UTC)
+	listPosts := func(args mock.Arguments) {
+		arg := args.Get(0).(*jsonapi.Scope)
+		arg.Value = []*Post{
+			{ID: 1, CreatedAt: modified.Add(-time.Hour), Comments: []*Comment{{ID: 1}}},
+			{ID: 2, CreatedAt: modified},
+		}
+	}
+
+	// Case 1:
+	// The weak ETag and Last-Modified are computed from the resources
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).Run(listPosts)
+	rw, req := getHttpPair("GET", "/posts", nil)
+	h.List(postModel, postModel.List).ServeHTTP(rw, req)
+
+	assert.Equal(t, 200, rw.Result().StatusCode)
+	eTag := rw.Header().Get(headerETag)
+	assert.True(t, strings.HasPrefix(eTag, weakETagPrefix))
+	assert.Equal(t, modified.Format(http.TimeFormat), rw.Header().Get(headerLastModified))
+	assert.Equal(t, jsonapi.MediaType, rw.Header().Get("Content-Type"))
+
+	// Case 2:
+	// If-Modified-Since not before the latest modification results with 304
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).Run(listPosts)
+	rw, req = getHttpPair("GET", "/posts", nil)
+	req.Header.Set(headerIfModifiedSince, modified.Format(http.TimeFormat))
+	h.List(postModel, postModel.List).ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusNotModified, rw.Result().StatusCode)
+	assert.Equal(t, 0, rw.Body.Len())
+	assert.Empty(t, rw.Header().Get("Content-Type"))
+
+	// Case 3:
+	// If-Modified-Since before the latest modification
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).Run(listPosts)
+	rw, req = getHttpPair("GET", "/posts", nil)
+	req.Header.Set(headerIfModifiedSince, modified.Add(-time.Minute).Format(http.TimeFormat))
+	h.List(postModel, postModel.List).ServeHTTP(rw, req)
+
+	assert.Equal(t, 200, rw.Result().StatusCode)
+
+	// Case 4:
+	// The included model without the LastModifiedField results with the strong ETag and no
+	// Last-Modified header, so that the If-Modified-Since is not matched
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).Run(listPosts)
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value = []*Comment{{ID: 1, Body: "First"}}
+		})
+	rw, req = getHttpPair("GET", "/posts?include=comments", nil)
+	req.Header.Set(headerIfModifiedSince, modified.Format(http.TimeFormat))
+	h.List(postModel, postModel.List).ServeHTTP(rw, req)
+
+	assert.Equal(t, 200, rw.Result().StatusCode)
+	assert.False(t, strings.HasPrefix(rw.Header().Get(headerETag), weakETagPrefix))
+	assert.Empty(t, rw.Header().Get(headerLastModified))
+}
+
+func TestCheckNotModifiedSinceInclude(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	h.SetDefaultRepo(&MockRepository{})
+
+	postModel := h.ModelHandlers[reflect.TypeOf(Post{})]
+	postModel.LastModifiedField = "CreatedAt"
+	postModel.Get.ConditionalGet = true
+
+	// The included resources could be modified after the root one, the repository is not asked.
+	rw, req := getHttpPair("GET", "/posts/1?include=comments", nil)
+	req.Header.Set(headerIfModifiedSince, time.Now().UTC().Format(http.TimeFormat))
+	assert.False(t, h.checkNotModifiedSince(postModel, postModel.Get, nil, rw, req))
+}
+
+func TestIsNotModified(t *testing.T) {
+	modified := time.Date(2018, 6, 20, 12, 0, 0, 0, time.UTC)
+
+	// Case 1:
+	// If-Modified-Since after the modification time
+	req := httptest.NewRequest("GET", "/blogs", nil)
+	req.Header.Set(headerIfModifiedSince, modified.Add(time.Minute).Format(http.TimeFormat))
+	assert.True(t, isNotModified(req, "", modified))
+
+	// Case 2:
+	// If-Modified-Since before the modification time
+	req.Header.Set(headerIfModifiedSince, modified.Add(-time.Minute).Format(http.TimeFormat))
+	assert.False(t, isNotModified(req, "", modified))
+
+	// Case 3:
+	// If-None-Match takes precedence over If-Modified-Since
+	req.Header.Set(headerIfModifiedSince, modified.Add(time.Minute).Format(http.TimeFormat))
+	req.Header.Set(headerIfNoneMatch, `"other", "tag"`)
+	assert.False(t, isNotModified(req, `"tag"`, modified))
+
+	// Case 4:
+	// Weak comparison of the ETags
+	req.Header.Set(headerIfNoneMatch, `W/"tag"`)
+	assert.True(t, isNotModified(req, `"tag"`, modified))
+
+	// Case 5:
+	// Wildcard
+	req.Header.Set(headerIfNoneMatch, "*")
+	assert.True(t, isNotModified(req, `"tag"`, time.Time{}))
+}
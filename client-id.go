@@ -0,0 +1,203 @@
+package jsonapisdk
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/kucjac/jsonapi"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ClientIDPolicy defines if the client is allowed to provide the resource's id on create.
+type ClientIDPolicy int
+
+const (
+	// ClientIDAllowed allows the client to provide the resource's id.
+	ClientIDAllowed ClientIDPolicy = iota
+
+	// ClientIDForbidden responds with 403 Forbidden if the client provided the resource's id.
+	ClientIDForbidden
+
+	// ClientIDRequired requires the client to provide the resource's id.
+	ClientIDRequired
+)
+
+func (c ClientIDPolicy) String() string {
+	var policy string
+	switch c {
+	case ClientIDAllowed:
+		policy = "allowed"
+	case ClientIDForbidden:
+		policy = "forbidden"
+	case ClientIDRequired:
+		policy = "required"
+	default:
+		policy = "unknown"
+	}
+	return policy
+}
+
+// IDGenerator generates the id for the created resource. The generated id is set within
+// the model's string primary field.
+type IDGenerator func() (string, error)
+
+const (
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	base62Alphabet    = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	ulidLength  = 26
+	ksuidLength = 27
+
+	// ksuidEpoch is the KSUID's custom epoch (2014-05-13T16:53:20Z).
+	ksuidEpoch = 1400000000
+)
+
+// NewUUID generates the random (version 4) UUID.
+func NewUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf), nil
+}
+
+// NewULID generates the ULID - the lexicographically sortable identifier containing
+// the millisecond timestamp and 80 random bits encoded with the Crockford's base32.
+func NewULID() (string, error) {
+	var b [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	return encodeBase(b[:], crockfordAlphabet, ulidLength), nil
+}
+
+// NewKSUID generates the KSUID - the sortable identifier containing the seconds timestamp
+// and 128 random bits encoded with the base62.
+func NewKSUID() (string, error) {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	if _, err := rand.Read(b[4:]); err != nil {
+		return "", err
+	}
+	return encodeBase(b[:], base62Alphabet, ksuidLength), nil
+}
+
+// encodeBase encodes the big endian bytes with the 'alphabet' into the string of fixed 'length'.
+func encodeBase(b []byte, alphabet string, length int) string {
+	var (
+		n    = new(big.Int).SetBytes(b)
+		base = big.NewInt(int64(len(alphabet)))
+		mod  = new(big.Int)
+		out  = make([]byte, length)
+	)
+
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+// checkClientID checks if the client provided id matches the model's ClientIDPolicy.
+// If the id is not valid the error is written to the response and the function returns false.
+func (h *JSONAPIHandler) checkClientID(model *ModelHandler, body []byte, rw http.ResponseWriter) bool {
+	id, err := providedID(body)
+	provided := id != ""
+
+	var errObj *jsonapi.ErrorObject
+	switch {
+	case err != nil:
+		errObj = jsonapi.ErrInvalidJSONFieldValue.Copy()
+		errObj.Detail = "The resource's id must be a string."
+	case provided && model.ClientIDPolicy == ClientIDForbidden:
+		errObj = jsonapi.ErrInsufficientAccPerm.Copy()
+		errObj.Detail = "Client generated ids are not allowed for this collection."
+	case !provided && model.ClientIDPolicy == ClientIDRequired:
+		errObj = jsonapi.ErrMissingRequiredJSONField.Copy()
+		errObj.Detail = "The resource's id must be provided by the client for this collection."
+	default:
+		return true
+	}
+
+	SetErrorSource(errObj, &ErrorSource{Pointer: pointerID})
+	h.MarshalErrors(rw, errObj)
+	return false
+}
+
+// generateID sets the id generated by the model's IDGenerator if the scope's value has no
+// primary value.
+func (h *JSONAPIHandler) generateID(model *ModelHandler, scope *jsonapi.Scope, rw http.ResponseWriter) bool {
+	if model.IDGenerator == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(scope.Value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		h.logger(rw, SubsystemEndpoint).Errorf("Invalid scope value for the id generation. Model: '%v'.", model.ModelType)
+		h.MarshalInternalError(rw)
+		return false
+	}
+
+	primary := v.Elem().Field(scope.Struct.GetPrimaryField().GetFieldIndex())
+	if !reflect.DeepEqual(primary.Interface(), reflect.Zero(primary.Type()).Interface()) {
+		return true
+	}
+
+	if primary.Kind() != reflect.String {
+		h.logger(rw, SubsystemEndpoint).Errorf("The IDGenerator requires the string primary field. Model: '%v'.", model.ModelType)
+		h.MarshalInternalError(rw)
+		return false
+	}
+
+	id, err := model.IDGenerator()
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Cannot generate id for the model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(rw)
+		return false
+	}
+	primary.SetString(id)
+	return true
+}
+
+// providedID gets the resource id provided within the request 'body'. The id that is not
+// a JSON string results in the error.
+func providedID(body []byte) (string, error) {
+	doc := struct {
+		Data *struct {
+			ID json.RawMessage `json:"id"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &doc); err != nil || doc.Data == nil || len(doc.Data.ID) == 0 || string(doc.Data.ID) == "null" {
+		return "", nil
+	}
+
+	var id string
+	if err := json.Unmarshal(doc.Data.ID, &id); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(id), nil
+}
@@ -0,0 +1,130 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIDGenerators(t *testing.T) {
+	generators := []struct {
+		name    string
+		gen     IDGenerator
+		pattern *regexp.Regexp
+	}{
+		{"uuid", NewUUID, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{"ulid", NewULID, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)},
+		{"ksuid", NewKSUID, regexp.MustCompile(`^[0-9A-Za-z]{27}$`)},
+	}
+
+	for _, generator := range generators {
+		generated := make(map[string]struct{})
+		for i := 0; i < 100; i++ {
+			id, err := generator.gen()
+			assert.NoError(t, err, generator.name)
+			assert.Regexp(t, generator.pattern, id, generator.name)
+			generated[id] = struct{}{}
+		}
+		assert.Len(t, generated, 100, generator.name)
+	}
+
+	// the ULIDs with the different timestamps are lexicographically sortable
+	assert.True(t, encodeBase([]byte{0, 0, 0, 0, 0, 1}, crockfordAlphabet, 10) < encodeBase([]byte{0, 0, 0, 0, 1, 0}, crockfordAlphabet, 10))
+}
+
+func TestCreateClientIDPolicy(t *testing.T) {
+	h := prepareHandler(defaultLanguages, &Pet{}, &Human{})
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	petModel := h.ModelHandlers[reflect.TypeOf(Pet{})]
+
+	// Case 1:
+	// Client id forbidden
+	petModel.ClientIDPolicy = ClientIDForbidden
+	rw, req := getHttpPair("POST", "/pets", strings.NewReader(`{"data":{"type":"pets","id":"3","attributes":{"name":"Maniek"}}}`))
+	h.Create(petModel, petModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 403, rw.Result().StatusCode)
+
+	// Case 2:
+	// Client id required
+	petModel.ClientIDPolicy = ClientIDRequired
+	rw, req = getHttpPair("POST", "/pets", strings.NewReader(`{"data":{"type":"pets","attributes":{"name":"Maniek"}}}`))
+	h.Create(petModel, petModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	// Case 3:
+	// Duplicated client id
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByTypeAndID(Pet{}, 3))).Once().Return(unidb.ErrUniqueViolation.New())
+	rw, req = getHttpPair("POST", "/pets", strings.NewReader(`{"data":{"type":"pets","id":"3","attributes":{"name":"Maniek"}}}`))
+	h.Create(petModel, petModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 409, rw.Result().StatusCode)
+
+	// Case 4:
+	// Client id that is not a string
+	petModel.ClientIDPolicy = ClientIDAllowed
+	rw, req = getHttpPair("POST", "/pets", strings.NewReader(`{"data":{"type":"pets","id":3,"attributes":{"name":"Maniek"}}}`))
+	h.Create(petModel, petModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+	mockRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+type Tag struct {
+	ID   string `jsonapi:"primary,tags"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type Label struct {
+	ID   string `jsonapi:"primary,labels" create:"required"`
+	Name string `jsonapi:"attr,name"`
+}
+
+func TestCreateGenerateID(t *testing.T) {
+	h := prepareHandler(defaultLanguages, &Tag{})
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	tagModel := h.ModelHandlers[reflect.TypeOf(Tag{})]
+	tagModel.IDGenerator = func() (string, error) { return "generated", nil }
+
+	// Case 1:
+	// The id is generated before the create
+	mockRepo.On("Create", mock.Anything).Once().Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*jsonapi.Scope)
+		assert.Equal(t, "generated", arg.Value.(*Tag).ID)
+	})
+
+	rw, req := getHttpPair("POST", "/tags", strings.NewReader(`{"data":{"type":"tags","attributes":{"name":"go"}}}`))
+	h.Create(tagModel, tagModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode)
+
+	// Case 2:
+	// The client id is not overwritten
+	mockRepo.On("Create", mock.Anything).Once().Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*jsonapi.Scope)
+		assert.Equal(t, "client", arg.Value.(*Tag).ID)
+	})
+
+	rw, req = getHttpPair("POST", "/tags", strings.NewReader(`{"data":{"type":"tags","id":"client","attributes":{"name":"go"}}}`))
+	h.Create(tagModel, tagModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode)
+
+	// Case 3:
+	// The generated id passes the required id validation
+	h = prepareHandler(defaultLanguages, &Label{})
+	mockRepo = &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	labelModel := h.ModelHandlers[reflect.TypeOf(Label{})]
+	labelModel.IDGenerator = func() (string, error) { return "generated", nil }
+	mockRepo.On("Create", mock.Anything).Once().Return(nil)
+
+	rw, req = getHttpPair("POST", "/labels", strings.NewReader(`{"data":{"type":"labels","attributes":{"name":"go"}}}`))
+	h.Create(labelModel, labelModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode)
+}
@@ -0,0 +1,490 @@
+// Package jsonapiclient is the Go client of the APIs built with the jsonapi-sdk. The client
+// shares the jsonapi.Controller's model registry with the API, so that the requests and
+// responses are marshaled directly from and into the models' values.
+//
+// Example:
+//
+//	client := jsonapiclient.New("https://example.com/v1", controller, nil)
+//
+//	blog := &Blog{}
+//	err := client.Get(ctx, blog, "1", jsonapiclient.NewQuery().Include("posts"))
+//
+//	var blogs []*Blog
+//	err = client.ListAll(ctx, &blogs, jsonapiclient.NewQuery().Sort("-id").Page(20, 0))
+package jsonapiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Client is the client of the API built with the jsonapi-sdk.
+type Client struct {
+	// BaseURL is the URL of the API i.e. 'https://example.com/v1'. The collections' paths are
+	// appended to it.
+	BaseURL string
+
+	// Controller is the jsonapi controller containing the API's models.
+	Controller *jsonapi.Controller
+
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+
+	// Header contains the headers set on every request i.e. 'Authorization'.
+	Header http.Header
+}
+
+// New creates the client of the API at the 'baseURL' for the models registered within the
+// controller 'c'. If the 'httpClient' is nil the http.DefaultClient is used.
+func New(baseURL string, c *jsonapi.Controller, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Controller: c,
+		HTTPClient: httpClient,
+		Header:     make(http.Header),
+	}
+}
+
+// Create creates the resource from the 'value' - the pointer to the model.
+// The 'value' is replaced by the resource returned by the API.
+func (c *Client) Create(ctx context.Context, value interface{}) error {
+	mStruct, err := c.modelStruct(value)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.marshal(value, nil)
+	if err != nil {
+		return err
+	}
+
+	status, resp, err := c.do(ctx, "POST", c.collectionURL(mStruct), nil, body, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNoContent || len(resp) == 0 {
+		return nil
+	}
+	return c.unmarshal(resp, value)
+}
+
+// Get gets the resource with the 'id' into the 'value' - the pointer to the model.
+func (c *Client) Get(ctx context.Context, value interface{}, id string, q *Query) error {
+	mStruct, err := c.modelStruct(value)
+	if err != nil {
+		return err
+	}
+
+	_, resp, err := c.do(ctx, "GET", c.resourceURL(mStruct, id), q.values(mStruct.GetCollectionType()), nil, q.header())
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(resp, value)
+}
+
+// List lists the single page of the resources into the 'dst' - the pointer to the slice of the
+// model's pointers i.e. '*[]*Blog'. In order to list all the pages use the ListAll or Iterate.
+func (c *Client) List(ctx context.Context, dst interface{}, q *Query) error {
+	mStruct, err := c.modelStruct(dst)
+	if err != nil {
+		return err
+	}
+
+	_, resp, err := c.do(ctx, "GET", c.collectionURL(mStruct), q.values(mStruct.GetCollectionType()), nil, q.header())
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(resp, dst)
+}
+
+// ListAll lists the resources from all the pages into the 'dst' - the pointer to the slice of
+// the model's pointers i.e. '*[]*Blog'.
+func (c *Client) ListAll(ctx context.Context, dst interface{}, q *Query) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("jsonapiclient: invalid destination: '%T'. The pointer to the slice is required", dst)
+	}
+
+	all := reflect.MakeSlice(v.Elem().Type(), 0, 0)
+	it := c.Iterate(ctx, q)
+	page := reflect.New(v.Elem().Type())
+	for it.Next(page.Interface()) {
+		all = reflect.AppendSlice(all, page.Elem())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	v.Elem().Set(all)
+	return nil
+}
+
+// Patch patches the resource with the 'value' - the pointer to the model with the primary field
+// set. Only the 'fields' are sent, if provided. The 'value' is replaced by the resource returned
+// by the API.
+func (c *Client) Patch(ctx context.Context, value interface{}, fields ...string) error {
+	mStruct, err := c.modelStruct(value)
+	if err != nil {
+		return err
+	}
+
+	id, err := c.primaryID(mStruct, value)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.marshal(value, fields)
+	if err != nil {
+		return err
+	}
+
+	status, resp, err := c.do(ctx, "PATCH", c.resourceURL(mStruct, id), nil, body, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNoContent || len(resp) == 0 {
+		return nil
+	}
+	return c.unmarshal(resp, value)
+}
+
+// Delete deletes the 'model's resource with the 'id'. The 'model' is the model's value or
+// the pointer i.e. '&Blog{}'.
+func (c *Client) Delete(ctx context.Context, model interface{}, id string) error {
+	mStruct, err := c.modelStruct(model)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.do(ctx, "DELETE", c.resourceURL(mStruct, id), nil, nil, nil)
+	return err
+}
+
+// GetRelated gets the resources related by the 'relation' with the 'model's resource with the
+// 'id'. The 'dst' is the pointer to the related model for the to-one relationships or the
+// pointer to the slice of the related model's pointers for the to-many relationships.
+func (c *Client) GetRelated(ctx context.Context, model interface{}, id, relation string, dst interface{}, q *Query) error {
+	mStruct, err := c.modelStruct(model)
+	if err != nil {
+		return err
+	}
+
+	related, err := c.modelStruct(dst)
+	if err != nil {
+		return err
+	}
+
+	_, resp, err := c.do(ctx, "GET", c.resourceURL(mStruct, id)+"/"+relation, q.values(related.GetCollectionType()), nil, q.header())
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(resp, dst)
+}
+
+// Identifier is the JSON:API resource identifier object.
+type Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type relationshipDocument struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// GetRelationship gets the identifiers of the resources related by the 'relation' with the
+// 'model's resource with the 'id'. An empty to-one relationship results in no identifiers.
+func (c *Client) GetRelationship(ctx context.Context, model interface{}, id, relation string) ([]Identifier, error) {
+	mStruct, err := c.modelStruct(model)
+	if err != nil {
+		return nil, err
+	}
+
+	_, resp, err := c.do(ctx, "GET", c.resourceURL(mStruct, id)+"/relationships/"+relation, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := relationshipDocument{}
+	if err = json.Unmarshal(resp, &doc); err != nil {
+		return nil, fmt.Errorf("jsonapiclient: invalid relationship document. %v", err)
+	}
+
+	data := bytes.TrimSpace(doc.Data)
+	switch {
+	case len(data) == 0, bytes.Equal(data, []byte("null")):
+		return nil, nil
+	case data[0] == '[':
+		var identifiers []Identifier
+		if err = json.Unmarshal(data, &identifiers); err != nil {
+			return nil, fmt.Errorf("jsonapiclient: invalid relationship document. %v", err)
+		}
+		return identifiers, nil
+	}
+
+	identifier := Identifier{}
+	if err = json.Unmarshal(data, &identifier); err != nil {
+		return nil, fmt.Errorf("jsonapiclient: invalid relationship document. %v", err)
+	}
+	return []Identifier{identifier}, nil
+}
+
+// PatchRelationship replaces the resources related by the 'relation' with the 'model's resource
+// with the 'id' by the 'identifiers'. The to-one relationship is cleared if no identifiers are
+// provided.
+func (c *Client) PatchRelationship(ctx context.Context, model interface{}, id, relation string, identifiers ...Identifier) error {
+	mStruct, err := c.modelStruct(model)
+	if err != nil {
+		return err
+	}
+
+	field := mStruct.GetRelationshipField(relation)
+	if field == nil {
+		return fmt.Errorf("jsonapiclient: relationship: '%s' not found for the collection: '%s'", relation, mStruct.GetCollectionType())
+	}
+
+	var data interface{}
+	switch {
+	case field.GetFieldKind() == jsonapi.RelationshipMultiple:
+		if identifiers == nil {
+			identifiers = []Identifier{}
+		}
+		data = identifiers
+	case len(identifiers) > 1:
+		return fmt.Errorf("jsonapiclient: too many identifiers for the to-one relationship: '%s'", relation)
+	case len(identifiers) == 1:
+		data = identifiers[0]
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.do(ctx, "PATCH", c.resourceURL(mStruct, id)+"/relationships/"+relation, nil, body, nil)
+	return err
+}
+
+// AddToRelationship adds the resources with the 'identifiers' to the to-many relationship
+// 'relation' of the 'model's resource with the 'id'.
+func (c *Client) AddToRelationship(ctx context.Context, model interface{}, id, relation string, identifiers ...Identifier) error {
+	return c.changeToManyRelationship(ctx, "POST", model, id, relation, identifiers)
+}
+
+// RemoveFromRelationship removes the resources with the 'identifiers' from the to-many
+// relationship 'relation' of the 'model's resource with the 'id'.
+func (c *Client) RemoveFromRelationship(ctx context.Context, model interface{}, id, relation string, identifiers ...Identifier) error {
+	return c.changeToManyRelationship(ctx, "DELETE", model, id, relation, identifiers)
+}
+
+// changeToManyRelationship sends the relationship document with the 'identifiers' using the
+// 'method'. The to-one relationships are rejected.
+func (c *Client) changeToManyRelationship(
+	ctx context.Context,
+	method string,
+	model interface{},
+	id, relation string,
+	identifiers []Identifier,
+) error {
+	mStruct, err := c.modelStruct(model)
+	if err != nil {
+		return err
+	}
+
+	field := mStruct.GetRelationshipField(relation)
+	if field == nil {
+		return fmt.Errorf("jsonapiclient: relationship: '%s' not found for the collection: '%s'", relation, mStruct.GetCollectionType())
+	}
+	if field.GetFieldKind() != jsonapi.RelationshipMultiple {
+		return fmt.Errorf("jsonapiclient: the resources cannot be added to or removed from the to-one relationship: '%s'", relation)
+	}
+
+	if identifiers == nil {
+		identifiers = []Identifier{}
+	}
+	body, err := json.Marshal(map[string]interface{}{"data": identifiers})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.do(ctx, method, c.resourceURL(mStruct, id)+"/relationships/"+relation, nil, body, nil)
+	return err
+}
+
+// do sends the request and reads the response body. The error documents are returned as the
+// *Error.
+func (c *Client) do(
+	ctx context.Context,
+	method, target string,
+	query url.Values,
+	body []byte,
+	header http.Header,
+) (int, []byte, error) {
+	if len(query) > 0 {
+		target += "?" + encodeQuery(query)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", jsonapi.MediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, nil, newError(resp.StatusCode, respBody)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// marshal marshals the 'value' into the resource document. If the 'fields' are provided only
+// these fields are marshaled.
+func (c *Client) marshal(value interface{}, fields []string) ([]byte, error) {
+	scope, err := c.Controller.NewScope(value)
+	if err != nil {
+		return nil, err
+	}
+	scope.Value = value
+
+	if len(fields) > 0 {
+		scope.Fieldset = make(map[string]*jsonapi.StructField, len(fields))
+		for _, name := range fields {
+			field := scope.Struct.GetAttributeField(name)
+			if field == nil {
+				field = scope.Struct.GetRelationshipField(name)
+			}
+			if field == nil {
+				return nil, fmt.Errorf("jsonapiclient: field: '%s' not found for the collection: '%s'", name, scope.Struct.GetCollectionType())
+			}
+			scope.Fieldset[name] = field
+		}
+	}
+
+	payload, err := c.Controller.MarshalScope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err = jsonapi.MarshalPayload(buf, payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshal unmarshals the resource document into the 'dst'. The document with many resources
+// is unmarshaled into the pointer to the slice.
+func (c *Client) unmarshal(body []byte, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("jsonapiclient: invalid destination: '%T'. Non nil pointer is required", dst)
+	}
+
+	var (
+		scope  *jsonapi.Scope
+		errObj *jsonapi.ErrorObject
+		err    error
+	)
+	if v.Elem().Kind() == reflect.Slice {
+		scope, errObj, err = jsonapi.UnmarshalScopeMany(bytes.NewReader(body), c.Controller)
+	} else {
+		scope, errObj, err = jsonapi.UnmarshalScopeOne(bytes.NewReader(body), c.Controller)
+	}
+	if err != nil {
+		return err
+	}
+	if errObj != nil {
+		return fmt.Errorf("jsonapiclient: invalid response document. %s", errObj.Detail)
+	}
+
+	value := reflect.ValueOf(scope.Value)
+	if !value.IsValid() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+		return nil
+	}
+
+	if v.Elem().Kind() != reflect.Slice && value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if !value.Type().AssignableTo(v.Elem().Type()) {
+		return fmt.Errorf("jsonapiclient: cannot unmarshal: '%s' into: '%T'", value.Type(), dst)
+	}
+	v.Elem().Set(value)
+	return nil
+}
+
+// modelStruct gets the model's struct of the 'value'. The 'value' could be the model, the pointer
+// to the model or the pointer to the slice of the model's pointers.
+func (c *Client) modelStruct(value interface{}) (*jsonapi.ModelStruct, error) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		return nil, fmt.Errorf("jsonapiclient: nil model provided")
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	mStruct := c.Controller.Models.Get(t)
+	if mStruct == nil {
+		return nil, fmt.Errorf("jsonapiclient: model: '%s' is not registered within the controller", t)
+	}
+	return mStruct, nil
+}
+
+// primaryID gets the string value of the 'value's primary field.
+func (c *Client) primaryID(mStruct *jsonapi.ModelStruct, value interface{}) (string, error) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("jsonapiclient: invalid value: '%T'", value)
+	}
+
+	primary := v.FieldByName(mStruct.GetPrimaryField().GetFieldName())
+	if !primary.IsValid() || reflect.DeepEqual(primary.Interface(), reflect.Zero(primary.Type()).Interface()) {
+		return "", fmt.Errorf("jsonapiclient: the primary field of the '%s' value is not set", mStruct.GetCollectionType())
+	}
+	return fmt.Sprint(primary.Interface()), nil
+}
+
+func (c *Client) collectionURL(mStruct *jsonapi.ModelStruct) string {
+	return c.BaseURL + "/" + mStruct.GetCollectionType()
+}
+
+func (c *Client) resourceURL(mStruct *jsonapi.ModelStruct, id string) string {
+	return c.collectionURL(mStruct) + "/" + url.PathEscape(id)
+}
@@ -0,0 +1,205 @@
+package jsonapiclient
+
+import (
+	"context"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type Blog struct {
+	ID    int     `jsonapi:"primary,blogs"`
+	Title string  `jsonapi:"attr,title"`
+	Posts []*Post `jsonapi:"relation,posts"`
+}
+
+type Post struct {
+	ID    int    `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+	Blog  *Blog  `jsonapi:"relation,blog"`
+}
+
+func prepareClient(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	c := jsonapi.New()
+	assert.NoError(t, c.PrecomputeModels(&Blog{}, &Post{}))
+
+	server := httptest.NewServer(handler)
+	return New(server.URL, c, server.Client()), server
+}
+
+func TestQueryValues(t *testing.T) {
+	q := NewQuery().
+		Filter("id", OpIn, 1, 2).
+		Filter("posts.title", OpStartsWith, "go").
+		Sort("-title").
+		Include("posts").
+		Fields("blogs", "title", "posts").
+		Page(10, 20)
+
+	assert.Equal(t,
+		"fields[blogs]=title,posts&filter[blogs][id][in]=1,2&filter[blogs][posts][title][startswith]=go&include=posts&page[limit]=10&page[offset]=20&sort=-title",
+		encodeQuery(q.values("blogs")))
+
+	var empty *Query
+	assert.Empty(t, empty.values("blogs"))
+}
+
+func TestClientGet(t *testing.T) {
+	client, server := prepareClient(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/blogs/1", req.URL.Path)
+		assert.Equal(t, jsonapi.MediaType, req.Header.Get("Accept"))
+		assert.Equal(t, "pl", req.Header.Get("Accept-Language"))
+
+		rw.Header().Set("Content-Type", jsonapi.MediaType)
+		rw.Write([]byte(`{"data":{"type":"blogs","id":"1","attributes":{"title":"First"}}}`))
+	})
+	defer server.Close()
+
+	blog := &Blog{}
+	assert.NoError(t, client.Get(context.Background(), blog, "1", NewQuery().Language("pl")))
+	assert.Equal(t, 1, blog.ID)
+	assert.Equal(t, "First", blog.Title)
+}
+
+func TestClientError(t *testing.T) {
+	client, server := prepareClient(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", jsonapi.MediaType)
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte(`{"errors":[{"status":"404","code":"JAPI-404","title":"Not Found","detail":"Blog not found."}]}`))
+	})
+	defer server.Close()
+
+	err := client.Get(context.Background(), &Blog{}, "2", nil)
+	apiErr, ok := err.(*Error)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.True(t, apiErr.HasCode("JAPI-404"))
+		assert.Contains(t, apiErr.Error(), "Blog not found.")
+	}
+}
+
+func TestClientListAll(t *testing.T) {
+	var requests int
+	client, server := prepareClient(t, func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		assert.Equal(t, "2", req.URL.Query().Get("page[limit]"))
+
+		var ids []int
+		switch req.URL.Query().Get("page[offset]") {
+		case "0":
+			ids = []int{1, 2}
+		case "2":
+			ids = []int{3}
+		}
+
+		data := make([]string, len(ids))
+		for i, id := range ids {
+			data[i] = fmt.Sprintf(`{"type":"blogs","id":"%d","attributes":{"title":"Blog %d"}}`, id, id)
+		}
+		rw.Header().Set("Content-Type", jsonapi.MediaType)
+		rw.Write([]byte(`{"data":[` + strings.Join(data, ",") + `]}`))
+	})
+	defer server.Close()
+
+	var blogs []*Blog
+	assert.NoError(t, client.ListAll(context.Background(), &blogs, NewQuery().Page(2, 0)))
+	assert.Equal(t, 2, requests)
+	if assert.Len(t, blogs, 3) {
+		assert.Equal(t, 3, blogs[2].ID)
+	}
+}
+
+func TestClientPatchRelationship(t *testing.T) {
+	client, server := prepareClient(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "PATCH", req.Method)
+		assert.Equal(t, "/blogs/1/relationships/posts", req.URL.Path)
+
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"data":[{"type":"posts","id":"3"}]}`, string(body))
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	err := client.PatchRelationship(context.Background(), &Blog{}, "1", "posts", Identifier{Type: "posts", ID: "3"})
+	assert.NoError(t, err)
+
+	// Unknown relationship
+	err = client.PatchRelationship(context.Background(), &Blog{}, "1", "unknown")
+	assert.Error(t, err)
+}
+
+func TestClientChangeToManyRelationship(t *testing.T) {
+	var methods []string
+	client, server := prepareClient(t, func(rw http.ResponseWriter, req *http.Request) {
+		methods = append(methods, req.Method)
+		assert.Equal(t, "/blogs/1/relationships/posts", req.URL.Path)
+
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"data":[{"type":"posts","id":"3"},{"type":"posts","id":"4"}]}`, string(body))
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	defer server.Close()
+
+	identifiers := []Identifier{{Type: "posts", ID: "3"}, {Type: "posts", ID: "4"}}
+
+	// Case 1:
+	// Add to the to-many relationship
+	assert.NoError(t, client.AddToRelationship(context.Background(), &Blog{}, "1", "posts", identifiers...))
+
+	// Case 2:
+	// Remove from the to-many relationship
+	assert.NoError(t, client.RemoveFromRelationship(context.Background(), &Blog{}, "1", "posts", identifiers...))
+	assert.Equal(t, []string{"POST", "DELETE"}, methods)
+
+	// Case 3:
+	// The to-one and unknown relationships are rejected
+	assert.Error(t, client.AddToRelationship(context.Background(), &Post{}, "3", "blog", Identifier{Type: "blogs", ID: "1"}))
+	assert.Error(t, client.RemoveFromRelationship(context.Background(), &Post{}, "3", "blog", Identifier{Type: "blogs", ID: "1"}))
+	assert.Error(t, client.AddToRelationship(context.Background(), &Blog{}, "1", "unknown"))
+	assert.Len(t, methods, 2)
+}
+
+func TestIteratorNextLink(t *testing.T) {
+	var next string
+	client, server := prepareClient(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", jsonapi.MediaType)
+		if req.URL.Query().Get("page[offset]") == "1" {
+			rw.Write([]byte(`{"data":[{"type":"blogs","id":"2","attributes":{"title":"Second"}}]}`))
+			return
+		}
+		rw.Write([]byte(`{"data":[{"type":"blogs","id":"1","attributes":{"title":"First"}}],"links":{"next":"` + next + `"}}`))
+	})
+	defer server.Close()
+
+	// Case 1:
+	// The relative next link is resolved against the base url
+	next = "/blogs?page[offset]=1"
+	var ids []int
+	it := client.Iterate(context.Background(), nil)
+	for {
+		var blogs []*Blog
+		if !it.Next(&blogs) {
+			break
+		}
+		for _, blog := range blogs {
+			ids = append(ids, blog.ID)
+		}
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []int{1, 2}, ids)
+
+	// Case 2:
+	// The next link to another host is rejected
+	next = "http://example.com/blogs?page[offset]=1"
+	it = client.Iterate(context.Background(), nil)
+	var blogs []*Blog
+	assert.False(t, it.Next(&blogs))
+	assert.Error(t, it.Err())
+}
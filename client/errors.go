@@ -0,0 +1,53 @@
+package jsonapiclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"net/http"
+	"strings"
+)
+
+// Error is the error returned for the API's error responses. It contains the response status
+// code and the error objects of the error document.
+type Error struct {
+	StatusCode int
+	Errors     []*jsonapi.ErrorObject
+}
+
+// newError creates the Error from the error response's 'body'. The body that is not the error
+// document results in the Error without the error objects.
+func newError(status int, body []byte) *Error {
+	doc := struct {
+		Errors []*jsonapi.ErrorObject `json:"errors"`
+	}{}
+	json.Unmarshal(body, &doc)
+	return &Error{StatusCode: status, Errors: doc.Errors}
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("jsonapiclient: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Errors) == 0 {
+		return msg
+	}
+
+	details := make([]string, len(e.Errors))
+	for i, errObj := range e.Errors {
+		details[i] = errObj.Title
+		if errObj.Detail != "" {
+			details[i] += ": " + errObj.Detail
+		}
+	}
+	return msg + ". " + strings.Join(details, "; ")
+}
+
+// HasCode checks if any of the error objects has the 'code'.
+func (e *Error) HasCode(code string) bool {
+	for _, errObj := range e.Errors {
+		if errObj.Code == code {
+			return true
+		}
+	}
+	return false
+}
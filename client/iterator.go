@@ -0,0 +1,156 @@
+package jsonapiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// Iterator iterates over the pages of the listed collection. The next page is taken from the
+// document's 'links.next'. If the API does not provide the link, the paginated query's offset
+// is moved by its limit until the page with less resources is returned. The query without the
+// pagination results in a single page.
+type Iterator struct {
+	c     *Client
+	ctx   context.Context
+	query *Query
+
+	next   string
+	offset int
+	pages  int
+	done   bool
+	err    error
+}
+
+// Iterate creates the Iterator over the pages of the collection listed with the query 'q'.
+//
+//	it := client.Iterate(ctx, jsonapiclient.NewQuery().Page(50, 0))
+//	var blogs []*Blog
+//	for it.Next(&blogs) {
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (c *Client) Iterate(ctx context.Context, q *Query) *Iterator {
+	it := &Iterator{c: c, ctx: ctx, query: q}
+	if q != nil {
+		it.offset = q.offset
+	}
+	return it
+}
+
+// Next lists the next page into the 'dst' - the pointer to the slice of the model's pointers
+// i.e. '*[]*Blog'. It returns false if there are no more pages or an error occurred.
+func (it *Iterator) Next(dst interface{}) bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	mStruct, err := it.c.modelStruct(dst)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	target, query := it.next, url.Values(nil)
+	if target == "" {
+		target = it.c.collectionURL(mStruct)
+		query = it.query.values(mStruct.GetCollectionType())
+		if it.paginated() {
+			query.Set("page[offset]", strconv.Itoa(it.offset))
+		}
+	}
+
+	_, resp, err := it.c.do(it.ctx, "GET", target, query, nil, it.query.header())
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	if err = it.c.unmarshal(resp, dst); err != nil {
+		it.err = err
+		return false
+	}
+
+	n := reflect.ValueOf(dst).Elem().Len()
+	if n == 0 && it.pages > 0 {
+		it.done = true
+		return false
+	}
+	it.pages++
+
+	next, err := it.nextLink(resp)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	switch {
+	case next != "":
+		it.next = next
+	case it.paginated() && n == it.query.limit:
+		it.offset += it.query.limit
+	default:
+		it.done = true
+	}
+	return true
+}
+
+// Err returns the error that stopped the iteration.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (it *Iterator) paginated() bool {
+	return it.query != nil && it.query.paginated && it.query.limit > 0
+}
+
+// nextLink gets the absolute URL of the document's 'links.next'. The link could be the string or
+// the link object with the 'href'. The link with the scheme or the host other than the BaseURL's
+// is rejected so that the request headers are not sent to another server.
+func (it *Iterator) nextLink(body []byte) (string, error) {
+	doc := struct {
+		Links map[string]json.RawMessage `json:"links"`
+	}{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", err
+	}
+
+	raw, ok := doc.Links["next"]
+	if !ok {
+		return "", nil
+	}
+
+	var next string
+	if err := json.Unmarshal(raw, &next); err != nil {
+		link := struct {
+			Href string `json:"href"`
+		}{}
+		if err = json.Unmarshal(raw, &link); err != nil {
+			return "", nil
+		}
+		next = link.Href
+	}
+	if next == "" {
+		return "", nil
+	}
+
+	base, err := url.Parse(it.c.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return "", err
+	}
+
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != base.Scheme || resolved.Host != base.Host {
+		return "", fmt.Errorf("jsonapiclient: the next link: '%s' does not match the base url: '%s'", next, it.c.BaseURL)
+	}
+	return resolved.String(), nil
+}
@@ -0,0 +1,186 @@
+package jsonapiclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The filter operators of the JSON:API query.
+const (
+	OpEqual        = "eq"
+	OpNotEqual     = "ne"
+	OpIn           = "in"
+	OpNotIn        = "notin"
+	OpLessThan     = "lt"
+	OpLessEqual    = "le"
+	OpGreaterThan  = "gt"
+	OpGreaterEqual = "ge"
+	OpContains     = "contains"
+	OpStartsWith   = "startswith"
+	OpEndsWith     = "endswith"
+)
+
+// Query builds the JSON:API query parameters of the request: filters, sorts, includes,
+// fieldsets and pagination. The nil *Query is an empty query.
+type Query struct {
+	filters  []queryFilter
+	sorts    []string
+	includes []string
+	fields   map[string][]string
+
+	paginated     bool
+	limit, offset int
+
+	language string
+}
+
+type queryFilter struct {
+	collection string
+	field      string
+	operator   string
+	values     []string
+}
+
+// NewQuery creates the empty query.
+func NewQuery() *Query {
+	return &Query{fields: make(map[string][]string)}
+}
+
+// Filter adds the filter on the 'field' of the listed collection. The relationship's fields are
+// separated with the dot i.e. 'posts.id'. The 'operator' is one of the Op... constants.
+func (q *Query) Filter(field, operator string, values ...interface{}) *Query {
+	return q.FilterCollection("", field, operator, values...)
+}
+
+// FilterCollection adds the filter on the 'field' of the 'collection'. If the 'collection' is
+// empty the requested collection is used.
+func (q *Query) FilterCollection(collection, field, operator string, values ...interface{}) *Query {
+	f := queryFilter{collection: collection, field: field, operator: operator}
+	for _, value := range values {
+		f.values = append(f.values, formatValue(value))
+	}
+	q.filters = append(q.filters, f)
+	return q
+}
+
+// Sort adds the sort 'fields'. The descending order is prefixed with '-' i.e. '-title'.
+func (q *Query) Sort(fields ...string) *Query {
+	q.sorts = append(q.sorts, fields...)
+	return q
+}
+
+// Include adds the included relationships' 'paths' i.e. 'posts.comments'.
+func (q *Query) Include(paths ...string) *Query {
+	q.includes = append(q.includes, paths...)
+	return q
+}
+
+// Fields sets the fieldset of the 'collection'.
+func (q *Query) Fields(collection string, fields ...string) *Query {
+	if q.fields == nil {
+		q.fields = make(map[string][]string)
+	}
+	q.fields[collection] = append(q.fields[collection], fields...)
+	return q
+}
+
+// Page sets the pagination's 'limit' and 'offset'. The Iterator moves the offset by the limit
+// until the page with less resources is returned.
+func (q *Query) Page(limit, offset int) *Query {
+	q.paginated, q.limit, q.offset = true, limit, offset
+	return q
+}
+
+// Language sets the 'Accept-Language' header of the request.
+func (q *Query) Language(lang string) *Query {
+	q.language = lang
+	return q
+}
+
+// values encodes the query parameters for the request on the 'collection'.
+func (q *Query) values(collection string) url.Values {
+	values := url.Values{}
+	if q == nil {
+		return values
+	}
+
+	for _, f := range q.filters {
+		c := f.collection
+		if c == "" {
+			c = collection
+		}
+		key := "filter[" + c + "][" + strings.Join(strings.Split(f.field, "."), "][") + "]"
+		if f.operator != "" {
+			key += "[" + f.operator + "]"
+		}
+		values.Add(key, strings.Join(f.values, ","))
+	}
+
+	if len(q.sorts) > 0 {
+		values.Set("sort", strings.Join(q.sorts, ","))
+	}
+
+	if len(q.includes) > 0 {
+		values.Set("include", strings.Join(q.includes, ","))
+	}
+
+	for c, fields := range q.fields {
+		values.Set("fields["+c+"]", strings.Join(fields, ","))
+	}
+
+	if q.paginated {
+		values.Set("page[limit]", strconv.Itoa(q.limit))
+		values.Set("page[offset]", strconv.Itoa(q.offset))
+	}
+	return values
+}
+
+// header gets the query's request headers.
+func (q *Query) header() http.Header {
+	header := http.Header{}
+	if q != nil && q.language != "" {
+		header.Set("Accept-Language", q.language)
+	}
+	return header
+}
+
+// encodeQuery encodes the 'values' sorted by the keys. The brackets and commas are not
+// escaped, so that the query remains readable.
+func encodeQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	unescape := strings.NewReplacer("%5B", "[", "%5D", "]", "%2C", ",")
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range values[key] {
+			parts = append(parts, unescape.Replace(url.QueryEscape(key))+"="+unescape.Replace(url.QueryEscape(value)))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// formatValue formats the filter's value.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return "null"
+		}
+		return v.Format(time.RFC3339)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(value)
+}
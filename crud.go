@@ -1,13 +1,11 @@
 package jsonapisdk
 
 import (
-	"fmt"
 	"github.com/kucjac/jsonapi"
 	"github.com/kucjac/uni-db"
-	"gopkg.in/go-playground/validator.v9"
+	"golang.org/x/text/language"
 	"net/http"
 	"reflect"
-	"strings"
 )
 
 // Create returns http.HandlerFunc that creates new 'model' entity within it's repository.
@@ -19,22 +17,45 @@ import (
 //
 // Correctly Response with status '201' Created.
 func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
+	create := h.create(model, endpoint)
+	if endpoint.Sideposting {
+		create = h.sidepost(model, create)
+	}
+	return h.wrapHandler(model, Create, h.idempotent(create))
+}
+
+func (h *JSONAPIHandler) create(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
 		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
 			h.MarshalInternalError(rw)
 			return
 		}
 		SetContentType(rw)
-		scope := h.UnmarshalScope(model.ModelType, rw, req)
+		scope, body := h.unmarshalScope(model.ModelType, rw, req)
 		if scope == nil {
 			return
 		}
 
 		/**
 
+		CREATE: CLIENT ID
+
+		*/
+		st.next("CREATE: CLIENT ID")
+		// the translations share the id of the translated resource
+		if translationOf(req) == nil && !h.checkClientID(model, body, rw) {
+			return
+		}
+
+		/**
+
 		CREATE: LANGUAGE
 
 		*/
+		st.next("CREATE: LANGUAGE")
 		// if the model is i18n-ready control it's language field value
 		if scope.UseI18n() {
 			lang, ok := h.CheckValueLanguage(scope, rw)
@@ -49,6 +70,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 		CREATE: PRESET PAIRS
 
 		*/
+		st.next("CREATE: PRESET PAIRS")
 		for _, pair := range endpoint.PresetPairs {
 			presetScope, presetField := pair.GetPair()
 			if pair.Key != nil {
@@ -57,7 +79,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 				}
 			}
 
-			values, err := h.GetPresetValues(presetScope, rw)
+			values, err := h.getPresetValues(presetScope, req, rw)
 			if err != nil {
 				if hErr := err.(*HandlerError); hErr != nil {
 					if hErr.Code == ErrNoValues {
@@ -69,7 +91,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 						return
 					}
 				} else {
-					h.log.Error(err)
+					h.logger(rw, SubsystemEndpoint).Error(err)
 					h.MarshalInternalError(rw)
 					return
 				}
@@ -77,7 +99,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 			}
 
 			if err := h.PresetScopeValue(scope, presetField, values...); err != nil {
-				h.log.Errorf("Cannot preset value while creating model: '%s'.'%s'", model.ModelType.Name(), err)
+				h.logger(rw, SubsystemEndpoint).Errorf("Cannot preset value while creating model: '%s'.'%s'", model.ModelType.Name(), err)
 				h.MarshalInternalError(rw)
 				return
 			}
@@ -88,16 +110,17 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 		CREATE: PRESET FILTERS
 
 		*/
+		st.next("CREATE: PRESET FILTERS")
 		for _, filter := range endpoint.PresetFilters {
 			value := req.Context().Value(filter.Key)
 			if value != nil {
 				if err := h.SetPresetFilterValues(filter.FilterField, value); err != nil {
-					h.log.Errorf("Cannot preset value for the Create.PresetFilter. Model %v. Field %v. Value: %v", model.ModelType.Name(), filter.StructField.GetFieldName(), value)
+					h.logger(rw, SubsystemEndpoint).Errorf("Cannot preset value for the Create.PresetFilter. Model %v. Field %v. Value: %v", model.ModelType.Name(), filter.StructField.GetFieldName(), value)
 					h.MarshalInternalError(rw)
 					return
 				}
 				if err := h.PresetScopeValue(scope, filter.FilterField, value); err != nil {
-					h.log.Errorf("Cannot preset value for the model: '%s'. FilterField: %v. Error: %v", model.ModelType.Name(), filter.GetFieldName(), err)
+					h.logger(rw, SubsystemEndpoint).Errorf("Cannot preset value for the model: '%s'. FilterField: %v. Error: %v", model.ModelType.Name(), filter.GetFieldName(), err)
 					h.MarshalInternalError(rw)
 					return
 				}
@@ -106,69 +129,22 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 
 		/**
 
-		CREATE: VALIDATE MODEL
+		CREATE: GENERATE ID
 
 		*/
+		st.next("CREATE: GENERATE ID")
+		if !h.generateID(model, scope, rw) {
+			return
+		}
 
-		if err := h.CreateValidator.Struct(scope.Value); err != nil {
-			if _, ok := err.(*validator.InvalidValidationError); ok {
-				errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
-				h.MarshalErrors(rw, errObj)
-				return
-			}
+		/**
 
-			validateErrors, ok := err.(validator.ValidationErrors)
-			if !ok || ok && len(validateErrors) == 0 {
-				h.log.Debugf("Unknown error type while validating. %v", err)
-				h.MarshalErrors(rw, jsonapi.ErrInvalidJSONFieldValue.Copy())
-				return
-			}
+		CREATE: VALIDATE MODEL
 
-			var errs []*jsonapi.ErrorObject
-			for _, verr := range validateErrors {
-				tag := verr.Tag()
+		*/
+		st.next("CREATE: VALIDATE MODEL")
 
-				var errObj *jsonapi.ErrorObject
-				if tag == "required" {
-					if verr.Field() == "" {
-						errObj = jsonapi.ErrInsufficientAccPerm.Copy()
-						h.MarshalErrors(rw, errObj)
-						return
-					}
-					errObj = jsonapi.ErrMissingRequiredJSONField.Copy()
-					errObj.Detail = fmt.Sprintf("The field: %s, is required.", verr.Field())
-					errs = append(errs, errObj)
-					continue
-				} else if tag == "isdefault" {
-					if verr.Field() == "" {
-						errObj = jsonapi.ErrInsufficientAccPerm.Copy()
-						h.MarshalErrors(rw, errObj)
-						return
-					}
-					errObj = jsonapi.ErrInvalidJSONFieldValue.Copy()
-					errObj.Detail = fmt.Sprintf("The field: '%s' must be empty.", verr.Field())
-					errs = append(errs, errObj)
-					continue
-				} else if strings.HasPrefix(tag, "len") {
-					if verr.Field() == "" {
-						errObj = jsonapi.ErrInsufficientAccPerm.Copy()
-						h.MarshalErrors(rw, errObj)
-						return
-					}
-					errObj = jsonapi.ErrInvalidJSONFieldValue.Copy()
-					errObj.Detail = fmt.Sprintf("The value of the field: %s is of invalid length.", verr.Field())
-					errs = append(errs, errObj)
-					continue
-				} else {
-					errObj = jsonapi.ErrInvalidJSONFieldValue.Copy()
-					if verr.Field() != "" {
-						errObj.Detail = fmt.Sprintf("Invalid value for the field: '%s'.", verr.Field())
-					}
-					errs = append(errs, errObj)
-					continue
-				}
-			}
-			h.MarshalErrors(rw, errs...)
+		if !h.ValidateScope(model, Create, scope, rw, req) {
 			return
 		}
 
@@ -177,6 +153,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 		CREATE: PRECHECK PAIRS
 
 		*/
+		st.next("CREATE: PRECHECK PAIRS")
 
 		for _, pair := range endpoint.PrecheckPairs {
 			presetScope, presetField := pair.GetPair()
@@ -186,7 +163,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 				}
 			}
 
-			values, err := h.GetPresetValues(presetScope, rw)
+			values, err := h.getPresetValues(presetScope, req, rw)
 			if err != nil {
 				if hErr := err.(*HandlerError); hErr != nil {
 					if hErr.Code == ErrNoValues {
@@ -198,7 +175,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 						return
 					}
 				} else {
-					h.log.Error(err)
+					h.logger(rw, SubsystemEndpoint).Error(err)
 					h.MarshalInternalError(rw)
 					return
 				}
@@ -206,13 +183,13 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 			}
 
 			if err := h.SetPresetFilterValues(presetField, values...); err != nil {
-				h.log.Error("Cannot preset values to the filter value. %s", err)
+				h.logger(rw, SubsystemEndpoint).Errorf("Cannot preset values to the filter value. %s", err)
 				h.MarshalInternalError(rw)
 				return
 			}
 
 			if err := h.CheckPrecheckValues(scope, presetField); err != nil {
-				h.log.Debugf("Precheck value error: '%s'", err)
+				h.logger(rw, SubsystemEndpoint).Debugf("Precheck value error: '%s'", err)
 				if err == IErrValueNotValid {
 					errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
 					errObj.Detail = "One of the field values are not valid."
@@ -229,16 +206,17 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 		CREATE: PRECHECK FILTERS
 
 		*/
+		st.next("CREATE: PRECHECK FILTERS")
 
 		for _, filter := range endpoint.PrecheckFilters {
 			value := req.Context().Value(filter.Key)
 			if value != nil {
 				if err := h.SetPresetFilterValues(filter.FilterField, value); err != nil {
-					h.log.Errorf("Cannot preset value for the Create.PresetFilter. Model %v. Field %v. Value: %v", model.ModelType.Name(), filter.StructField.GetFieldName(), value)
+					h.logger(rw, SubsystemEndpoint).Errorf("Cannot preset value for the Create.PresetFilter. Model %v. Field %v. Value: %v", model.ModelType.Name(), filter.StructField.GetFieldName(), value)
 				}
 
 				if err := scope.AddFilterField(filter.FilterField); err != nil {
-					h.log.Error(err)
+					h.logger(rw, SubsystemEndpoint).Error(err)
 					h.MarshalInternalError(rw)
 					return
 				}
@@ -250,6 +228,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 		CREATE: RELATIONSHIP FILTERS
 
 		*/
+		st.next("CREATE: RELATIONSHIP FILTERS")
 		err := h.GetRelationshipFilters(scope, req, rw)
 		if err != nil {
 			if hErr := err.(*HandlerError); hErr != nil {
@@ -257,7 +236,7 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemEndpoint).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
@@ -268,28 +247,23 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 		CREATE: HOOK BEFORE
 
 		*/
+		st.next("CREATE: HOOK BEFORE")
 
-		beforeCreater, ok := scope.Value.(HookBeforeCreator)
-		if ok {
-			if err = beforeCreater.JSONAPIBeforeCreate(scope); err != nil {
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					h.MarshalErrors(rw, errObj)
-					return
-				}
-				h.MarshalInternalError(rw)
-				return
-			}
+		if errObj := h.runHooks(model, endpoint, BeforeCreate, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
 		}
 
-		repo := h.GetRepositoryByType(model.ModelType)
+		repo := h.repository(rw, model.ModelType)
 
 		/**
 
 		CREATE: REPOSITORY CREATE
 
 		*/
+		st.next("CREATE: REPOSITORY CREATE")
 		if dbErr := repo.Create(scope); dbErr != nil {
-			h.manageDBError(rw, dbErr)
+			h.manageDBError(rw, scope, dbErr)
 			return
 		}
 
@@ -298,18 +272,10 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 		CREATE: HOOK AFTER
 
 		*/
-		afterCreator, ok := scope.Value.(HookAfterCreator)
-		if ok {
-			if err = afterCreator.JSONAPIAfterCreate(scope); err != nil {
-				// the value should not be created?
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					h.MarshalErrors(rw, errObj)
-					return
-				}
-				h.log.Debugf("Error in HookAfterCreator: %v", err)
-				h.MarshalInternalError(rw)
-				return
-			}
+		st.next("CREATE: HOOK AFTER")
+		if errObj := h.runHooks(model, endpoint, AfterCreate, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
 		}
 
 		rw.WriteHeader(http.StatusCreated)
@@ -320,21 +286,35 @@ func (h *JSONAPIHandler) Create(model *ModelHandler, endpoint *Endpoint) http.Ha
 // Get returns a http.HandlerFunc that gets single entity from the "model's"
 // repository.
 func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return h.wrapHandler(model, Get, func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
 		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
 			h.MarshalInternalError(rw)
 			return
 		}
-		SetContentType(rw)
+
+		/**
+
+		GET: TRASHED FILTER
+
+		*/
+		st.next("GET: TRASHED FILTER")
+		trashed, ok := h.getTrashedFilter(model, rw, req)
+		if !ok {
+			return
+		}
 
 		/**
 
 		GET: BUILD SCOPE
 
 		*/
+		st.next("GET: BUILD SCOPE")
 		scope, errs, err := h.Controller.BuildScopeSingle(req, reflect.New(model.ModelType).Interface(), nil)
 		if err != nil {
-			h.log.Error(err)
+			h.logger(rw, SubsystemEndpoint).Error(err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -349,13 +329,16 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 		GET: LANGUAGE
 
 		*/
-		tag, ok := h.GetLanguage(req, rw)
+		st.next("GET: LANGUAGE")
+		tag, ok := h.getModelLanguage(model, req, rw)
 		if !ok {
 			return
 		}
 
+		var languages []language.Tag
 		if scope.UseI18n() {
 			scope.SetLanguageFilter(tag.String())
+			languages = h.languageChain(model, tag)
 		}
 
 		/**
@@ -363,6 +346,7 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 		GET: PRECHECK PAIR
 
 		*/
+		st.next("GET: PRECHECK PAIR")
 		if !h.AddPrecheckPairFilters(scope, model, endpoint, req, rw, endpoint.PrecheckPairs...) {
 			return
 		}
@@ -372,6 +356,7 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 		GET: PRECHECK FILTERS
 
 		*/
+		st.next("GET: PRECHECK FILTERS")
 
 		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
 			return
@@ -382,6 +367,7 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 		GET: RELATIONSHIP FILTERS
 
 		*/
+		st.next("GET: RELATIONSHIP FILTERS")
 		err = h.GetRelationshipFilters(scope, req, rw)
 		if err != nil {
 			if hErr := err.(*HandlerError); hErr != nil {
@@ -389,13 +375,16 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemEndpoint).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
 		}
 
-		repo := h.GetRepositoryByType(model.ModelType)
+		repo, ok := h.setTrashedScope(scope, trashed, rw)
+		if !ok {
+			return
+		}
 		// Set NewSingleValue for the scope
 		scope.NewValueSingle()
 
@@ -404,19 +393,32 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 		GET: HOOK BEFORE
 
 		*/
-		if errObj := h.HookBeforeReader(scope); errObj != nil {
+		st.next("GET: HOOK BEFORE")
+		if errObj := h.runHooks(model, endpoint, BeforeRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
 
 		/**
 
+		GET: CHECK NOT MODIFIED
+
+		*/
+		st.next("GET: CHECK NOT MODIFIED")
+		if h.checkNotModifiedSince(model, endpoint, scope, rw, req) {
+			return
+		}
+
+		/**
+
 		GET: REPOSITORY GET
 
+		Gets the resource in the first available language of the chain
 		*/
-		dbErr := repo.Get(scope)
+		st.next("GET: REPOSITORY GET")
+		dbErr := h.getTranslation(repo, scope, languages)
 		if dbErr != nil {
-			h.manageDBError(rw, dbErr)
+			h.manageDBError(rw, scope, dbErr)
 			return
 		}
 
@@ -425,7 +427,8 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 		GET: HOOK AFTER
 
 		*/
-		if errObj := h.HookAfterReader(scope); errObj != nil {
+		st.next("GET: HOOK AFTER")
+		if errObj := h.runHooks(model, endpoint, AfterRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
@@ -435,15 +438,17 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 		GET: GET INCLUDED FIELDS
 
 		*/
+		st.next("GET: GET INCLUDED FIELDS")
 		if correct := h.GetIncluded(scope, rw, req, tag); !correct {
 			return
 		}
 
 		// get included
 		h.HeaderContentLanguage(rw, tag)
-		h.MarshalScope(scope, rw, req)
+		setServedContentLanguage(rw, scope)
+		h.marshalScopeConditional(model, endpoint, scope, rw, req)
 		return
-	}
+	})
 }
 
 // GetRelated returns a http.HandlerFunc that returns the related field for the 'root' model
@@ -453,7 +458,10 @@ func (h *JSONAPIHandler) Get(model *ModelHandler, endpoint *Endpoint) http.Handl
 // and then gets the related object from it's repository.
 // If no error occurred an jsonapi related object is being returned
 func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return h.wrapHandler(root, GetRelated, func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
 		if _, ok := h.ModelHandlers[root.ModelType]; !ok {
 			h.MarshalInternalError(rw)
 			return
@@ -465,9 +473,10 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		GET RELATED: BUILD SCOPE
 
 		*/
+		st.next("GET RELATED: BUILD SCOPE")
 		scope, errs, err := h.Controller.BuildScopeRelated(req, reflect.New(root.ModelType).Interface())
 		if err != nil {
-			h.log.Errorf("An internal error occurred while building related scope for model: '%v'. %v", reflect.TypeOf(root), err)
+			h.logger(rw, SubsystemEndpoint).Errorf("An internal error occurred while building related scope for model: '%v'. %v", reflect.TypeOf(root), err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -483,14 +492,17 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		GET RELATED: LANGUAGE
 
 		*/
+		st.next("GET RELATED: LANGUAGE")
 
-		tag, ok := h.GetLanguage(req, rw)
+		tag, ok := h.getModelLanguage(root, req, rw)
 		if !ok {
 			return
 		}
 
+		var languages []language.Tag
 		if scope.UseI18n() {
 			scope.SetLanguageFilter(tag.String())
+			languages = h.languageChain(root, tag)
 		}
 
 		/**
@@ -498,6 +510,7 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		GET RELATED: PRECHECK PAIR
 
 		*/
+		st.next("GET RELATED: PRECHECK PAIR")
 		if !h.AddPrecheckPairFilters(scope, root, endpoint, req, rw, endpoint.PrecheckPairs...) {
 			return
 		}
@@ -507,6 +520,7 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		GET RELATED: PRECHECK FILTERS
 
 		*/
+		st.next("GET RELATED: PRECHECK FILTERS")
 		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
 			return
 		}
@@ -516,6 +530,7 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		GET RELATED: GET RELATIONSHIP FILTERS
 
 		*/
+		st.next("GET RELATED: GET RELATIONSHIP FILTERS")
 
 		err = h.GetRelationshipFilters(scope, req, rw)
 		if err != nil {
@@ -524,24 +539,32 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemEndpoint).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
 		}
 
-		// Get root repository
-		rootRepository := h.GetRepositoryByType(root.ModelType)
-		// Get the root for given id
-		// Select the related field inside
+		/**
+
+		GET RELATED: TRASHED FILTER
+
+		*/
+		st.next("GET RELATED: TRASHED FILTER")
+		// the trashed root is never read
+		rootRepository, ok := h.setTrashedScope(scope, TrashedWithout, rw)
+		if !ok {
+			return
+		}
 
 		/**
 
 		  GET RELATED: HOOK BEFORE READ
 
 		*/
+		st.next("GET RELATED: HOOK BEFORE READ")
 
-		if errObj := h.HookBeforeReader(scope); errObj != nil {
+		if errObj := h.runHooks(root, endpoint, BeforeRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
@@ -551,9 +574,10 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		GET RELATED: REPOSITORY GET ROOT
 
 		*/
-		dbErr := rootRepository.Get(scope)
+		st.next("GET RELATED: REPOSITORY GET ROOT")
+		dbErr := h.getTranslation(rootRepository, scope, languages)
 		if dbErr != nil {
-			h.manageDBError(rw, dbErr)
+			h.manageDBError(rw, scope, dbErr)
 			return
 		}
 
@@ -562,7 +586,8 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		  GET RELATED: ROOT HOOK AFTER READ
 
 		*/
-		if errObj := h.HookAfterReader(scope); errObj != nil {
+		st.next("GET RELATED: ROOT HOOK AFTER READ")
+		if errObj := h.runHooks(root, endpoint, AfterRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
@@ -572,10 +597,11 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		GET RELATED: BUILD RELATED SCOPE
 
 		*/
+		st.next("GET RELATED: BUILD RELATED SCOPE")
 
 		relatedScope, err := scope.GetRelatedScope()
 		if err != nil {
-			h.log.Errorf("Error while getting Related Scope: %v", err)
+			h.logger(rw, SubsystemEndpoint).Errorf("Error while getting Related Scope: %v", err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -583,9 +609,17 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 		// if there is any primary filter
 		if relatedScope.Value != nil && len(relatedScope.PrimaryFilters) != 0 {
 
-			relatedRepository := h.GetRepositoryByType(relatedScope.Struct.GetType())
+			relatedModel := h.ModelHandlers[relatedScope.Struct.GetType()]
+			var relatedLanguages []language.Tag
 			if relatedScope.UseI18n() {
-				relatedScope.SetLanguageFilter(tag.String())
+				relatedLanguages = h.modelLanguageChain(relatedScope.Struct.GetType(), tag)
+				setLanguageChainFilter(relatedScope, relatedLanguages)
+			}
+
+			// the trashed related resources are not taken
+			relatedRepository, ok := h.setTrashedScope(relatedScope, TrashedWithout, rw)
+			if !ok {
+				return
 			}
 
 			/**
@@ -593,23 +627,20 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 			  GET RELATED: HOOK BEFORE READER
 
 			*/
-			if errObj := h.HookBeforeReader(relatedScope); errObj != nil {
+			st.next("GET RELATED: HOOK BEFORE READER")
+			if errObj := h.runHooks(relatedModel, nil, BeforeRead, req, relatedScope); errObj != nil {
 				h.MarshalErrors(rw, errObj)
 				return
 			}
 
 			// SELECT METHOD TO GET
 			if relatedScope.IsMany {
-				h.log.Debug("The related scope isMany.")
-				dbErr = relatedRepository.List(relatedScope)
+				dbErr = listTranslations(relatedRepository, relatedScope, relatedLanguages)
 			} else {
-				h.log.Debug("The related scope isSingle.")
-				h.log.Debugf("The value of related scope: %+v", relatedScope.Value)
-				h.log.Debugf("Fieldset %+v", relatedScope.Fieldset)
-				dbErr = relatedRepository.Get(relatedScope)
+				dbErr = h.getTranslation(relatedRepository, relatedScope, relatedLanguages)
 			}
 			if dbErr != nil {
-				h.manageDBError(rw, dbErr)
+				h.manageDBError(rw, relatedScope, dbErr)
 				return
 			}
 
@@ -618,22 +649,27 @@ func (h *JSONAPIHandler) GetRelated(root *ModelHandler, endpoint *Endpoint) http
 			HOOK AFTER READER
 
 			*/
-			if errObj := h.HookAfterReader(relatedScope); errObj != nil {
+			st.next("HOOK AFTER READER")
+			if errObj := h.runHooks(relatedModel, nil, AfterRead, req, relatedScope); errObj != nil {
 				h.MarshalErrors(rw, errObj)
 				return
 			}
 		}
 		h.HeaderContentLanguage(rw, tag)
+		setServedContentLanguage(rw, relatedScope)
 		h.MarshalScope(relatedScope, rw, req)
 		return
 
-	}
+	})
 }
 
 // GetRelationship returns a http.HandlerFunc that returns in the response the relationship field
 // for the root model
 func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return h.wrapHandler(root, GetRelationship, func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
 		if _, ok := h.ModelHandlers[root.ModelType]; !ok {
 			h.MarshalInternalError(rw)
 			return
@@ -644,9 +680,10 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		GET RELATIONSHIP: BUILD SCOPE
 
 		*/
+		st.next("GET RELATIONSHIP: BUILD SCOPE")
 		scope, errs, err := h.Controller.BuildScopeRelationship(req, reflect.New(root.ModelType).Interface())
 		if err != nil {
-			h.log.Error(err)
+			h.logger(rw, SubsystemEndpoint).Error(err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -661,7 +698,8 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		  GET RELATIONSHIP: LANGUAGE
 
 		*/
-		tag, ok := h.GetLanguage(req, rw)
+		st.next("GET RELATIONSHIP: LANGUAGE")
+		tag, ok := h.getModelLanguage(root, req, rw)
 		if !ok {
 			return
 		}
@@ -676,6 +714,7 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		  GET RELATIONSHIP: PRECHECK PAIR
 
 		*/
+		st.next("GET RELATIONSHIP: PRECHECK PAIR")
 		if !h.AddPrecheckPairFilters(scope, root, endpoint, req, rw, endpoint.PrecheckPairs...) {
 			return
 		}
@@ -685,6 +724,7 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		GET RELATIONSHIP: PRECHECK FILTERS
 
 		*/
+		st.next("GET RELATIONSHIP: PRECHECK FILTERS")
 
 		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
 			return
@@ -695,6 +735,7 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		GET RELATIONSHIP: GET RELATIONSHIP FILTERS
 
 		*/
+		st.next("GET RELATIONSHIP: GET RELATIONSHIP FILTERS")
 
 		err = h.GetRelationshipFilters(scope, req, rw)
 		if err != nil {
@@ -703,7 +744,7 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemEndpoint).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
@@ -714,8 +755,9 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		  GET RELATIONSHIP: ROOT HOOK BEFORE READ
 
 		*/
+		st.next("GET RELATIONSHIP: ROOT HOOK BEFORE READ")
 
-		if errObj := h.HookBeforeReader(scope); errObj != nil {
+		if errObj := h.runHooks(root, endpoint, BeforeRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
@@ -725,11 +767,15 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		  GET RELATIONSHIP: GET ROOT FROM REPOSITORY
 
 		*/
+		st.next("GET RELATIONSHIP: GET ROOT FROM REPOSITORY")
 
-		rootRepository := h.GetRepositoryByType(scope.Struct.GetType())
+		rootRepository, ok := h.setTrashedScope(scope, TrashedWithout, rw)
+		if !ok {
+			return
+		}
 		dbErr := rootRepository.Get(scope)
 		if dbErr != nil {
-			h.manageDBError(rw, dbErr)
+			h.manageDBError(rw, scope, dbErr)
 			return
 		}
 
@@ -738,8 +784,9 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		  GET RELATIONSHIP: ROOT HOOK AFTER READ
 
 		*/
+		st.next("GET RELATIONSHIP: ROOT HOOK AFTER READ")
 
-		if errObj := h.HookAfterReader(scope); errObj != nil {
+		if errObj := h.runHooks(root, endpoint, AfterRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
@@ -749,20 +796,36 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 		  GET RELATIONSHIP: GET RELATIONSHIP SCOPE
 
 		*/
+		st.next("GET RELATIONSHIP: GET RELATIONSHIP SCOPE")
 		relationshipScope, err := scope.GetRelationshipScope()
 		if err != nil {
-			h.log.Errorf("Error while getting RelationshipScope for model: %v. %v", scope.Struct.GetType(), err)
+			h.logger(rw, SubsystemEndpoint).Errorf("Error while getting RelationshipScope for model: %v. %v", scope.Struct.GetType(), err)
 			h.MarshalInternalError(rw)
 			return
 		}
 
+		/**
+
+		  GET RELATIONSHIP: RELATIONSHIP HOOK AFTER READ
+
+		*/
+		st.next("GET RELATIONSHIP: RELATIONSHIP HOOK AFTER READ")
+		relationshipModel := h.ModelHandlers[relationshipScope.Struct.GetType()]
+		if relationshipScope.Value != nil {
+			if errObj := h.runHooks(relationshipModel, nil, AfterRead, req, relationshipScope); errObj != nil {
+				h.MarshalErrors(rw, errObj)
+				return
+			}
+		}
+
 		/**
 
 		  GET RELATIONSHIP: MARSHAL SCOPE
 
 		*/
+		st.next("GET RELATIONSHIP: MARSHAL SCOPE")
 		h.MarshalScope(relationshipScope, rw, req)
-	}
+	})
 }
 
 // List returns a http.HandlerFunc that response with the model's entities taken
@@ -772,21 +835,35 @@ func (h *JSONAPIHandler) GetRelationship(root *ModelHandler, endpoint *Endpoint)
 // 		i.e. '[collection]' and the field scoped for the filter within brackets, i.e. '[id]'
 //		i.e. url: http://myapiurl.com/api/blogs?filter[blogs][id]=4
 func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return h.wrapHandler(model, List, func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
 		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
 			h.MarshalInternalError(rw)
 			return
 		}
-		SetContentType(rw)
+
+		/**
+
+		  LIST: TRASHED FILTER
+
+		*/
+		st.next("LIST: TRASHED FILTER")
+		trashed, ok := h.getTrashedFilter(model, rw, req)
+		if !ok {
+			return
+		}
 
 		/**
 
 		  LIST: BUILD SCOPE
 
 		*/
+		st.next("LIST: BUILD SCOPE")
 		scope, errs, err := h.Controller.BuildScopeList(req, reflect.New(model.ModelType).Interface())
 		if err != nil {
-			h.log.Error(err)
+			h.logger(rw, SubsystemEndpoint).Error(err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -801,12 +878,16 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: LANGUAGE
 
 		*/
-		tag, ok := h.GetLanguage(req, rw)
+		st.next("LIST: LANGUAGE")
+		tag, ok := h.getModelLanguage(model, req, rw)
 		if !ok {
 			return
 		}
+
+		var languages []language.Tag
 		if scope.UseI18n() {
-			scope.SetLanguageFilter(tag.String())
+			languages = h.languageChain(model, tag)
+			setLanguageChainFilter(scope, languages)
 		}
 
 		h.HeaderContentLanguage(rw, tag)
@@ -816,6 +897,7 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: PRECHECK PAIRS
 
 		*/
+		st.next("LIST: PRECHECK PAIRS")
 		if !h.AddPrecheckPairFilters(scope, model, endpoint, req, rw, endpoint.PrecheckPairs...) {
 			return
 		}
@@ -825,6 +907,7 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: PRECHECK FILTERS
 
 		*/
+		st.next("LIST: PRECHECK FILTERS")
 
 		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
 			return
@@ -834,6 +917,7 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: GET RELATIONSHIP FILTERS
 
 		*/
+		st.next("LIST: GET RELATIONSHIP FILTERS")
 		err = h.GetRelationshipFilters(scope, req, rw)
 		if err != nil {
 			if hErr := err.(*HandlerError); hErr != nil {
@@ -846,13 +930,16 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemEndpoint).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
 		}
 
-		repo := h.GetRepositoryByType(model.ModelType)
+		repo, ok := h.setTrashedScope(scope, trashed, rw)
+		if !ok {
+			return
+		}
 
 		/**
 
@@ -860,6 +947,7 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 
 		  Include count into meta data
 		*/
+		st.next("LIST: INCLUDE COUNT")
 		if endpoint.CountList {
 			scope.CountList = true
 		}
@@ -869,6 +957,7 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: DEFAULT PAGINATION
 
 		*/
+		st.next("LIST: DEFAULT PAGINATION")
 		if endpoint.PresetPaginate != nil && scope.Pagination == nil {
 			scope.Pagination = endpoint.PresetPaginate
 		}
@@ -878,6 +967,7 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: DEFAULT SORT
 
 		*/
+		st.next("LIST: DEFAULT SORT")
 		if len(endpoint.PresetSort) != 0 {
 			scope.Sorts = append(endpoint.PresetSort, scope.Sorts...)
 		}
@@ -887,8 +977,9 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: HOOK BEFORE READER
 
 		*/
+		st.next("LIST: HOOK BEFORE READER")
 
-		if errObj := h.HookBeforeReader(scope); errObj != nil {
+		if errObj := h.runHooks(model, endpoint, BeforeRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
@@ -898,18 +989,21 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: LIST FROM REPOSITORY
 
 		*/
-		dbErr := repo.List(scope)
+		st.next("LIST: LIST FROM REPOSITORY")
+		dbErr := listTranslations(repo, scope, languages)
 		if dbErr != nil {
-			h.manageDBError(rw, dbErr)
+			h.manageDBError(rw, scope, dbErr)
 			return
 		}
+		setServedContentLanguage(rw, scope)
 
 		/**
 
 		  LIST: HOOK AFTER READ
 
 		*/
-		if errObj := h.HookAfterReader(scope); errObj != nil {
+		st.next("LIST: HOOK AFTER READ")
+		if errObj := h.runHooks(model, endpoint, AfterRead, req, scope); errObj != nil {
 			h.MarshalErrors(rw, errObj)
 			return
 		}
@@ -919,6 +1013,7 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: GET INCLUDED
 
 		*/
+		st.next("LIST: GET INCLUDED")
 		if correct := h.GetIncluded(scope, rw, req, tag); !correct {
 			return
 		}
@@ -928,9 +1023,10 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 		  LIST: MARSHAL SCOPE
 
 		*/
-		h.MarshalScope(scope, rw, req)
+		st.next("LIST: MARSHAL SCOPE")
+		h.marshalScopeConditional(model, endpoint, scope, rw, req)
 		return
-	}
+	})
 }
 
 // Patch the patch endpoint is used to patch given entity.
@@ -940,7 +1036,10 @@ func (h *JSONAPIHandler) List(model *ModelHandler, endpoint *Endpoint) http.Hand
 //	- Preset values using PresetScope
 //	- Precheck values using PrecheckScope
 func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return h.wrapHandler(model, Patch, h.idempotent(func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
 		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
 			h.MarshalInternalError(rw)
 			return
@@ -953,19 +1052,44 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: UNMARSHAL SCOPE
 
 		*/
-		scope := h.UnmarshalScope(model.ModelType, rw, req)
+		st.next("PATCH: UNMARSHAL SCOPE")
+		scope, body := h.unmarshalScope(model.ModelType, rw, req)
 		if scope == nil {
 			return
 		}
 
+		/**
+
+		  PATCH: PROVIDED FIELDS
+
+		  Only the fields provided in the request body are patched
+		*/
+		st.next("PATCH: PROVIDED FIELDS")
+		fullFieldset := scope.Fieldset
+		provided, err := providedFieldset(scope, body)
+		if err != nil {
+			errObj := jsonapi.ErrInvalidInput.Copy()
+			errObj.Detail = err.Error()
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+		scope.Fieldset = provided
+
+		// the fields added by the presets are not provided by the client
+		clientFields := make(map[string]*jsonapi.StructField, len(provided))
+		for name, field := range provided {
+			clientFields[name] = field
+		}
+
 		/**
 
 		  PATCH: GET ID FILTER
 
 		  Set the ID for given model's scope
 		*/
+		st.next("PATCH: GET ID FILTER")
 
-		err := h.Controller.GetAndSetIDFilter(req, scope)
+		err = h.Controller.GetAndSetIDFilter(req, scope)
 		if err != nil {
 			h.errSetIDFilter(scope, err, rw, req)
 			return
@@ -976,7 +1100,11 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: LANGAUGE
 
 		*/
+		st.next("PATCH: LANGAUGE")
 		if scope.UseI18n() {
+			if !h.setTranslationLanguage(scope, rw, req) {
+				return
+			}
 			tag, ok := h.CheckValueLanguage(scope, rw)
 			if !ok {
 				return
@@ -989,6 +1117,7 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: PRESET PAIRS
 
 		*/
+		st.next("PATCH: PRESET PAIRS")
 		for _, presetPair := range endpoint.PresetPairs {
 			presetScope, presetField := presetPair.GetPair()
 			if presetPair.Key != nil {
@@ -996,7 +1125,7 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 					continue
 				}
 			}
-			values, err := h.GetPresetValues(presetScope, rw)
+			values, err := h.getPresetValues(presetScope, req, rw)
 			if err != nil {
 				if hErr := err.(*HandlerError); hErr != nil {
 					if hErr.Code == ErrNoValues {
@@ -1008,7 +1137,7 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 						return
 					}
 				} else {
-					h.log.Error(err)
+					h.logger(rw, SubsystemEndpoint).Error(err)
 					h.MarshalInternalError(rw)
 					return
 				}
@@ -1016,10 +1145,11 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 			}
 
 			if err := h.PresetScopeValue(scope, presetField, values...); err != nil {
-				h.log.Errorf("Cannot preset value while creating model: '%s'.'%s'", model.ModelType.Name(), err)
+				h.logger(rw, SubsystemEndpoint).Errorf("Cannot preset value while creating model: '%s'.'%s'", model.ModelType.Name(), err)
 				h.MarshalInternalError(rw)
 				return
 			}
+			addFieldsetField(scope.Fieldset, fullFieldset, presetField.StructField)
 		}
 
 		/**
@@ -1027,16 +1157,28 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: PRESET FILTERS
 
 		*/
+		st.next("PATCH: PRESET FILTERS")
 
 		if !h.SetPresetFilters(scope, model, req, rw, endpoint.PresetFilters...) {
 			return
 		}
 
+		/**
+
+		  PATCH: VALIDATE MODEL
+
+		*/
+		st.next("PATCH: VALIDATE MODEL")
+		if !h.ValidateScope(model, Patch, scope, rw, req) {
+			return
+		}
+
 		/**
 
 		  PATCH: PRECHECK PAIRS
 
 		*/
+		st.next("PATCH: PRECHECK PAIRS")
 		if !h.AddPrecheckPairFilters(scope, model, endpoint, req, rw, endpoint.PrecheckPairs...) {
 			return
 		}
@@ -1046,6 +1188,7 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: PRECHECK FILTERS
 
 		*/
+		st.next("PATCH: PRECHECK FILTERS")
 
 		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
 			return
@@ -1058,20 +1201,40 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemEndpoint).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
 		}
 
-		// Get the Repository for given model
-		repo := h.GetRepositoryByType(model.ModelType)
+		/**
+
+		  PATCH: TRASHED FILTER
+
+		*/
+		st.next("PATCH: TRASHED FILTER")
+		// the trashed resources could not be patched
+		repo, ok := h.setTrashedScope(scope, TrashedWithout, rw)
+		if !ok {
+			return
+		}
+
+		/**
+
+		  PATCH: VALIDATE STORED VALUES
+
+		*/
+		st.next("PATCH: VALIDATE STORED VALUES")
+		if !h.validateStoredValues(model, scope, clientFields, repo, rw, req) {
+			return
+		}
 
 		/**
 
 		  PATCH: GET MODIFIED RESULT
 
 		*/
+		st.next("PATCH: GET MODIFIED RESULT")
 		if endpoint.GetModifiedResult {
 			scope.GetModifiedResult = true
 		}
@@ -1081,16 +1244,10 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: HOOK BEFORE PATCH
 
 		*/
-		if beforePatcher, ok := scope.Value.(HookBeforePatcher); ok {
-			if err = beforePatcher.JSONAPIBeforePatch(scope); err != nil {
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					h.MarshalErrors(rw, errObj)
-					return
-				}
-				h.log.Errorf("Error in HookBeforePatch for model: %v. Error: %v", model.ModelType.Name(), err)
-				h.MarshalInternalError(rw)
-				return
-			}
+		st.next("PATCH: HOOK BEFORE PATCH")
+		if errObj := h.runHooks(model, endpoint, BeforePatch, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
 		}
 
 		/**
@@ -1098,6 +1255,7 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: REPOSITORY PATCH
 
 		*/
+		st.next("PATCH: REPOSITORY PATCH")
 		// Use Patch Method on given model's Repository for given scope.
 		if dbErr := repo.Patch(scope); dbErr != nil {
 			if dbErr.Compare(unidb.ErrNoResult) && endpoint.HasPrechecks() {
@@ -1106,7 +1264,7 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 				h.MarshalErrors(rw, errObj)
 				return
 			}
-			h.manageDBError(rw, dbErr)
+			h.manageDBError(rw, scope, dbErr)
 			return
 		}
 
@@ -1115,16 +1273,10 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: HOOK AFTER PATCH
 
 		*/
-		if afterPatcher, ok := scope.Value.(HookAfterPatcher); ok {
-			if err = afterPatcher.JSONAPIAfterPatch(scope); err != nil {
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					h.MarshalErrors(rw, errObj)
-					return
-				}
-				h.log.Errorf("Error in HookAfterPatcher for model: %v. Error: %v", model.ModelType.Name(), err)
-				h.MarshalInternalError(rw)
-				return
-			}
+		st.next("PATCH: HOOK AFTER PATCH")
+		if errObj := h.runHooks(model, endpoint, AfterPatch, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
 		}
 
 		/**
@@ -1132,13 +1284,15 @@ func (h *JSONAPIHandler) Patch(model *ModelHandler, endpoint *Endpoint) http.Han
 		  PATCH: MARSHAL RESULT
 
 		*/
+		st.next("PATCH: MARSHAL RESULT")
 		if scope.GetModifiedResult {
+			scope.Fieldset = fullFieldset
 			h.MarshalScope(scope, rw, req)
 		} else {
 			rw.WriteHeader(http.StatusNoContent)
 		}
 		return
-	}
+	}))
 }
 
 func (h *JSONAPIHandler) PatchRelated(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
@@ -1150,7 +1304,10 @@ func (h *JSONAPIHandler) PatchRelationship(model *ModelHandler, endpoint *Endpoi
 }
 
 func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return h.wrapHandler(model, Delete, h.idempotent(func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
 		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
 			h.MarshalInternalError(rw)
 			return
@@ -1161,10 +1318,11 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: BUILD SCOPE
 
 		*/
+		st.next("DELETE: BUILD SCOPE")
 		// Create a scope for given delete handler
 		scope, err := h.Controller.NewScope(reflect.New(model.ModelType).Interface())
 		if err != nil {
-			h.log.Errorf("Error while creating scope: '%v' for model: '%v'", err, reflect.TypeOf(model))
+			h.logger(rw, SubsystemEndpoint).Errorf("Error while creating scope: '%v' for model: '%v'", err, reflect.TypeOf(model))
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -1174,6 +1332,7 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: GET ID FILTER
 
 		*/
+		st.next("DELETE: GET ID FILTER")
 		// Set the ID for given model's scope
 		errs, err := h.Controller.GetSetCheckIDFilter(req, scope)
 		if err != nil {
@@ -1191,7 +1350,8 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: LANGUAGE
 
 		*/
-		tag, ok := h.GetLanguage(req, rw)
+		st.next("DELETE: LANGUAGE")
+		tag, ok := h.translationLanguage(model, rw, req)
 		if !ok {
 			return
 		}
@@ -1204,6 +1364,7 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: PRECHECK PAIRS
 
 		*/
+		st.next("DELETE: PRECHECK PAIRS")
 
 		if !h.AddPrecheckPairFilters(scope, model, endpoint, req, rw, endpoint.PrecheckPairs...) {
 			return
@@ -1214,6 +1375,7 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: PRECHECK FILTERS
 
 		*/
+		st.next("DELETE: PRECHECK FILTERS")
 
 		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
 			return
@@ -1223,6 +1385,7 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: GET RELATIONSHIP FILTERS
 
 		*/
+		st.next("DELETE: GET RELATIONSHIP FILTERS")
 		err = h.GetRelationshipFilters(scope, req, rw)
 		if err != nil {
 			if hErr := err.(*HandlerError); hErr != nil {
@@ -1230,7 +1393,7 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemEndpoint).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
@@ -1241,17 +1404,11 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: HOOK BEFORE DELETE
 
 		*/
+		st.next("DELETE: HOOK BEFORE DELETE")
 		scope.NewValueSingle()
-		if deleteBefore, ok := scope.Value.(HookBeforeDeleter); ok {
-			if err = deleteBefore.JSONAPIBeforeDelete(scope); err != nil {
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					h.MarshalErrors(rw, errObj)
-					return
-				}
-				h.log.Errorf("Unknown error in Hook Before Delete. Path: %v. Error: %v", req.URL.Path, err)
-				h.MarshalInternalError(rw)
-				return
-			}
+		if errObj := h.runHooks(model, endpoint, BeforeDelete, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
 		}
 
 		/**
@@ -1259,15 +1416,26 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: REPOSITORY DELETE
 
 		*/
-		repo := h.GetRepositoryByType(model.ModelType)
-		if dbErr := repo.Delete(scope); dbErr != nil {
+		st.next("DELETE: REPOSITORY DELETE")
+		var dbErr *unidb.Error
+		repo := h.repository(rw, model.ModelType)
+		if model.SoftDelete != nil {
+			dbErr, ok = h.softDelete(model, scope, repo, rw)
+			if !ok {
+				return
+			}
+		} else {
+			dbErr = repo.Delete(scope)
+		}
+
+		if dbErr != nil {
 			if dbErr.Compare(unidb.ErrNoResult) && endpoint.HasPrechecks() {
 				errObj := jsonapi.ErrInsufficientAccPerm.Copy()
 				errObj.Detail = "Given object is not available for this account or it does not exists."
 				h.MarshalErrors(rw, errObj)
 				return
 			}
-			h.manageDBError(rw, dbErr)
+			h.manageDBError(rw, scope, dbErr)
 			return
 		}
 
@@ -1276,18 +1444,12 @@ func (h *JSONAPIHandler) Delete(model *ModelHandler, endpoint *Endpoint) http.Ha
 		  DELETE: HOOK AFTER DELETE
 
 		*/
-		if afterDeleter, ok := scope.Value.(HookAfterDeleter); ok {
-			if err = afterDeleter.JSONAPIAfterDelete(scope); err != nil {
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					h.MarshalErrors(rw, errObj)
-					return
-				}
-				h.log.Errorf("Error of unknown type during Hook After Delete. Path: %v. Error %v", req.URL.Path, err)
-				h.MarshalInternalError(rw)
-				return
-			}
+		st.next("DELETE: HOOK AFTER DELETE")
+		if errObj := h.runHooks(model, endpoint, AfterDelete, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
 		}
 
 		rw.WriteHeader(http.StatusNoContent)
-	}
+	}))
 }
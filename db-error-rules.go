@@ -0,0 +1,152 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"strings"
+)
+
+// DBConstraint describes the database constraint or the column that caused the database error.
+type DBConstraint struct {
+	// Name is the name of the constraint or the index.
+	Name string
+
+	// Table is the name of the table.
+	Table string
+
+	// Columns are the names of the constraint's columns.
+	Columns []string
+}
+
+// ConstraintResolver is the interface implemented by the repositories that are able to get
+// the constraint information from their database errors.
+type ConstraintResolver interface {
+	// ResolveConstraint gets the constraint that caused the 'dbErr'. If the constraint could not
+	// be resolved it returns nil.
+	ResolveConstraint(dbErr *unidb.Error) *DBConstraint
+}
+
+// DBErrorRule maps the database error caused by the constraint or the column into the specific
+// jsonapi error object.
+type DBErrorRule struct {
+	// Constraint is the name of the constraint or the index that matches the rule.
+	Constraint string
+
+	// Column is the name of the column that matches the rule. Used if the Constraint is empty.
+	Column string
+
+	// Field is the jsonapi name of the model's field used as the error's 'source.pointer'.
+	Field string
+
+	// Code and Detail overwrite the error object's values if not empty.
+	Code   string
+	Detail string
+
+	// Status overwrites the error object's status if not empty i.e. "422".
+	Status string
+}
+
+// matches checks if the rule matches the provided 'constraint'.
+func (r *DBErrorRule) matches(constraint *DBConstraint) bool {
+	if r.Constraint != "" {
+		return strings.EqualFold(r.Constraint, constraint.Name)
+	}
+
+	if r.Column != "" {
+		for _, column := range constraint.Columns {
+			if strings.EqualFold(r.Column, column) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errorObject gets the copy of the 'errObj' with the rule's values.
+func (r *DBErrorRule) errorObject(errObj *jsonapi.ErrorObject, mStruct *jsonapi.ModelStruct) *jsonapi.ErrorObject {
+	ruleErr := errObj.Copy()
+	if r.Code != "" {
+		ruleErr.Code = r.Code
+	}
+
+	if r.Detail != "" {
+		ruleErr.Detail = r.Detail
+	}
+
+	if r.Status != "" {
+		ruleErr.Status = r.Status
+	}
+
+	if r.Field != "" {
+		pointer := pointerAttributes + r.Field
+		if mStruct != nil && mStruct.GetRelationshipField(r.Field) != nil {
+			pointer = pointerRelationships + r.Field
+		} else if r.Field == "id" {
+			pointer = pointerID
+		}
+		SetErrorSource(ruleErr, &ErrorSource{Pointer: pointer})
+	}
+	return ruleErr
+}
+
+// AddRule adds the database error rule to the error manager. The rules are applied for all
+// the models, after the models' rules.
+func (r *ErrorManager) AddRule(rules ...*DBErrorRule) {
+	r.Lock()
+	r.rules = append(r.rules, rules...)
+	r.Unlock()
+}
+
+// rule gets the manager's rule matching the 'constraint'.
+func (r *ErrorManager) rule(constraint *DBConstraint) *DBErrorRule {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, rule := range r.rules {
+		if rule.matches(constraint) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// AddDBErrorRule adds the database error rule for the model's endpoints.
+func (m *ModelHandler) AddDBErrorRule(rules ...*DBErrorRule) {
+	m.dbErrorRules = append(m.dbErrorRules, rules...)
+}
+
+// constraintErrorObject gets the error object for the rule matching the constraint that caused
+// the 'dbErr'. The constraint is resolved by the scope's model repository. If no rule matches,
+// the function returns nil.
+func (h *JSONAPIHandler) constraintErrorObject(
+	scope *jsonapi.Scope,
+	dbErr *unidb.Error,
+	errObj *jsonapi.ErrorObject,
+) *jsonapi.ErrorObject {
+	if scope == nil || scope.Struct == nil {
+		return nil
+	}
+
+	resolver, ok := unwrapRepository(h.GetRepositoryByType(scope.Struct.GetType())).(ConstraintResolver)
+	if !ok {
+		return nil
+	}
+
+	constraint := resolver.ResolveConstraint(dbErr)
+	if constraint == nil {
+		return nil
+	}
+
+	if model, ok := h.ModelHandlers[scope.Struct.GetType()]; ok {
+		for _, rule := range model.dbErrorRules {
+			if rule.matches(constraint) {
+				return rule.errorObject(errObj, scope.Struct)
+			}
+		}
+	}
+
+	if rule := h.DBErrMgr.rule(constraint); rule != nil {
+		return rule.errorObject(errObj, scope.Struct)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type constraintRepository struct {
+	*MockRepository
+	constraint *DBConstraint
+}
+
+func (c *constraintRepository) ResolveConstraint(dbErr *unidb.Error) *DBConstraint {
+	return c.constraint
+}
+
+func TestManageDBErrorRules(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	repo := &constraintRepository{MockRepository: &MockRepository{}}
+	h.SetDefaultRepo(repo)
+
+	scope, err := h.Controller.NewScope(&Post{})
+	assert.NoError(t, err)
+
+	errorSource := func(rw *httptest.ResponseRecorder) (code, pointer string) {
+		var doc struct {
+			Errors []struct {
+				Code   string            `json:"code"`
+				Source map[string]string `json:"source"`
+			} `json:"errors"`
+		}
+		assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+		if len(doc.Errors) == 0 {
+			return "", ""
+		}
+		return doc.Errors[0].Code, doc.Errors[0].Source["pointer"]
+	}
+
+	// Case 1:
+	// No rule matches the constraint
+	repo.constraint = &DBConstraint{Name: "posts_title_key"}
+	rw := httptest.NewRecorder()
+	h.manageDBError(rw, scope, unidb.ErrUniqueViolation.New())
+	assert.Equal(t, 409, rw.Result().StatusCode)
+	_, pointer := errorSource(rw)
+	assert.Empty(t, pointer)
+
+	// Case 2:
+	// Error manager's rule by constraint name
+	h.DBErrMgr.AddRule(&DBErrorRule{Constraint: "posts_title_key", Field: "title", Code: "TITLE_TAKEN"})
+	rw = httptest.NewRecorder()
+	h.manageDBError(rw, scope, unidb.ErrUniqueViolation.New())
+	assert.Equal(t, 409, rw.Result().StatusCode)
+	code, pointer := errorSource(rw)
+	assert.Equal(t, "TITLE_TAKEN", code)
+	assert.Equal(t, "/data/attributes/title", pointer)
+
+	// Case 3:
+	// Model's rule by column precedes the manager's rules
+	postModel := h.ModelHandlers[reflect.TypeOf(Post{})]
+	postModel.AddDBErrorRule(&DBErrorRule{Column: "blog_id", Field: "comments", Status: "422", Code: "INVALID_BLOG"})
+	repo.constraint = &DBConstraint{Name: "posts_title_key", Columns: []string{"blog_id"}}
+
+	rw = httptest.NewRecorder()
+	h.manageDBError(rw, scope, unidb.ErrForeignKeyViolation.New())
+	assert.Equal(t, 422, rw.Result().StatusCode)
+	code, pointer = errorSource(rw)
+	assert.Equal(t, "INVALID_BLOG", code)
+	assert.Equal(t, "/data/relationships/comments", pointer)
+
+	// Case 4:
+	// No scope provided
+	rw = httptest.NewRecorder()
+	h.manageDBError(rw, nil, unidb.ErrUniqueViolation.New())
+	assert.Equal(t, 409, rw.Result().StatusCode)
+}
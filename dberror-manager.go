@@ -36,18 +36,53 @@ var DefaultErrorMap map[unidb.Error]jsonapi.ErrorObject = map[unidb.Error]jsonap
 	unidb.ErrUnspecifiedError:      jsonapi.ErrInternalError,
 }
 
+// DBErrorMapperFunc maps the database error into the jsonapi error object. The function gets
+// the whole unidb.Error with its message and code and the scope of the failed operation. The scope
+// might be nil. If the function returns nil, the error is passed to the next mapper function
+// and finally to the prototype error map.
+type DBErrorMapperFunc func(dbErr *unidb.Error, scope *jsonapi.Scope) *jsonapi.ErrorObject
+
 // ErrorManager defines the database unidb.Error one-to-one mapping
 // into jsonapi.Error. The default error mapping is defined
-// in package variable 'DefaultErrorMap'.
+// in package variable 'DefaultErrorMap'. Each manager contains its own copy of the mapping.
+// The mapping could be extended with the mapper functions that are called in the order they
+// were added, before the prototype mapping.
 //
 type ErrorManager struct {
 	dbToRest map[unidb.Error]jsonapi.ErrorObject
+
+	// mappers are the chained error mapping functions
+	mappers []DBErrorMapperFunc
+
+	// rules are the constraint rules for all the models
+	rules []*DBErrorRule
 	sync.RWMutex
 }
 
-// NewErrorMapper creates new error handler with already inited ErrorMap
+// NewErrorMapper creates new error handler with the copy of the DefaultErrorMap.
 func NewDBErrorMgr() *ErrorManager {
-	return &ErrorManager{dbToRest: DefaultErrorMap}
+	return &ErrorManager{dbToRest: copyErrorMap(DefaultErrorMap)}
+}
+
+// Clone creates the copy of the error manager with its own error map, mapper functions and
+// rules. The clone could be changed without affecting the manager.
+func (r *ErrorManager) Clone() *ErrorManager {
+	r.RLock()
+	defer r.RUnlock()
+
+	return &ErrorManager{
+		dbToRest: copyErrorMap(r.dbToRest),
+		mappers:  append([]DBErrorMapperFunc(nil), r.mappers...),
+		rules:    append([]*DBErrorRule(nil), r.rules...),
+	}
+}
+
+// AddMapper adds the mapper functions to the end of the manager's mapping chain.
+// This operation is thread safe - with RWMutex.Lock
+func (r *ErrorManager) AddMapper(mappers ...DBErrorMapperFunc) {
+	r.Lock()
+	r.mappers = append(r.mappers, mappers...)
+	r.Unlock()
 }
 
 // Handle enables unidb.Error handling so that proper jsonapi.ErrorObject is returned.
@@ -56,10 +91,36 @@ func NewDBErrorMgr() *ErrorManager {
 // application 'error' would be returned.
 // Thread safety by using RWMutex.RLock
 func (r *ErrorManager) Handle(dberr *unidb.Error) (*jsonapi.ErrorObject, error) {
+	return r.HandleScope(dberr, nil)
+}
+
+// HandleScope maps the database error of the operation on the 'scope' into jsonapi.ErrorObject.
+// At first the mapper functions are called. If none of them returns the error object, the error's
+// prototype mapping is used. The 'scope' might be nil.
+func (r *ErrorManager) HandleScope(dberr *unidb.Error, scope *jsonapi.Scope) (*jsonapi.ErrorObject, error) {
+	apierr, _, err := r.handleScope(dberr, scope)
+	return apierr, err
+}
+
+// handleScope maps the database error and gets the outcome of the mapping.
+func (r *ErrorManager) handleScope(
+	dberr *unidb.Error,
+	scope *jsonapi.Scope,
+) (*jsonapi.ErrorObject, string, error) {
+	r.RLock()
+	mappers := r.mappers
+	r.RUnlock()
+
+	for _, mapper := range mappers {
+		if apierr := mapper(dberr, scope); apierr != nil {
+			return apierr, DBErrorMapper, nil
+		}
+	}
+
 	// Get the prototype for given dberr
 	dbProto, err := dberr.GetPrototype()
 	if err != nil {
-		return nil, err
+		return nil, DBErrorUnmapped, err
 	}
 
 	// Get Rest
@@ -68,18 +129,19 @@ func (r *ErrorManager) Handle(dberr *unidb.Error) (*jsonapi.ErrorObject, error)
 	r.RUnlock()
 	if !ok {
 		err = errors.New("Given database error is unrecognised by the handler")
-		return nil, err
+		return nil, DBErrorUnmapped, err
 	}
 
 	// // Create new entity
-	return &apierr, nil
+	return &apierr, DBErrorMap, nil
 }
 
 // LoadCustomErrorMap enables replacement of the ErrorManager default error map.
+// The manager keeps its own copy of the 'errorMap'.
 // This operation is thread safe - with RWMutex.Lock
 func (r *ErrorManager) LoadCustomErrorMap(errorMap map[unidb.Error]jsonapi.ErrorObject) {
 	r.Lock()
-	r.dbToRest = errorMap
+	r.dbToRest = copyErrorMap(errorMap)
 	r.Unlock()
 }
 
@@ -90,6 +152,17 @@ func (r *ErrorManager) UpdateErrorEntry(
 	apierr jsonapi.ErrorObject,
 ) {
 	r.Lock()
+	if r.dbToRest == nil {
+		r.dbToRest = make(map[unidb.Error]jsonapi.ErrorObject)
+	}
 	r.dbToRest[dberr] = apierr
 	r.Unlock()
 }
+
+func copyErrorMap(errorMap map[unidb.Error]jsonapi.ErrorObject) map[unidb.Error]jsonapi.ErrorObject {
+	copied := make(map[unidb.Error]jsonapi.ErrorObject, len(errorMap))
+	for dberr, apierr := range errorMap {
+		copied[dberr] = apierr
+	}
+	return copied
+}
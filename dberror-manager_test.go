@@ -143,3 +143,80 @@ func TestHandle(t *testing.T) {
 	})
 
 }
+
+func TestErrorManagerIsolation(t *testing.T) {
+	Convey("Having two error managers", t, func() {
+		first := NewDBErrorMgr()
+		second := NewDBErrorMgr()
+
+		Convey("Updating the entry of one manager does not change the other nor the default map", func() {
+			customError := jsonapi.ErrorObject{Code: "C124", Title: "Custom not found", Status: "410"}
+			first.UpdateErrorEntry(unidb.ErrNoResult, customError)
+
+			firstErr, err := first.Handle(unidb.ErrNoResult.New())
+			So(err, ShouldBeNil)
+			So(firstErr.Code, ShouldEqual, customError.Code)
+
+			secondErr, err := second.Handle(unidb.ErrNoResult.New())
+			So(err, ShouldBeNil)
+			So(secondErr.Code, ShouldNotEqual, customError.Code)
+
+			So(DefaultErrorMap[unidb.ErrNoResult].Code, ShouldNotEqual, customError.Code)
+		})
+
+		Convey("The cloned manager does not share the mappers", func() {
+			clone := first.Clone()
+			clone.AddMapper(func(dbErr *unidb.Error, scope *jsonapi.Scope) *jsonapi.ErrorObject {
+				return &jsonapi.ErrorObject{Code: "MAPPED", Status: "400"}
+			})
+
+			cloneErr, err := clone.Handle(unidb.ErrNoResult.New())
+			So(err, ShouldBeNil)
+			So(cloneErr.Code, ShouldEqual, "MAPPED")
+
+			firstErr, err := first.Handle(unidb.ErrNoResult.New())
+			So(err, ShouldBeNil)
+			So(firstErr.Code, ShouldNotEqual, "MAPPED")
+		})
+	})
+}
+
+func TestErrorManagerMappers(t *testing.T) {
+	Convey("Having an error manager with chained mappers", t, func() {
+		errorManager := NewDBErrorMgr()
+		errorManager.AddMapper(
+			func(dbErr *unidb.Error, scope *jsonapi.Scope) *jsonapi.ErrorObject {
+				if dbErr.Message == "first" {
+					return &jsonapi.ErrorObject{Code: "FIRST", Status: "400"}
+				}
+				return nil
+			},
+			func(dbErr *unidb.Error, scope *jsonapi.Scope) *jsonapi.ErrorObject {
+				if dbErr.Message != "" {
+					return &jsonapi.ErrorObject{Code: "SECOND", Status: "400", Detail: dbErr.Message}
+				}
+				return nil
+			},
+		)
+
+		Convey("The first matching mapper is used", func() {
+			dbErr := unidb.ErrUniqueViolation.New()
+			dbErr.Message = "first"
+			apierr, err := errorManager.HandleScope(dbErr, nil)
+			So(err, ShouldBeNil)
+			So(apierr.Code, ShouldEqual, "FIRST")
+
+			dbErr.Message = "other"
+			apierr, err = errorManager.HandleScope(dbErr, nil)
+			So(err, ShouldBeNil)
+			So(apierr.Code, ShouldEqual, "SECOND")
+			So(apierr.Detail, ShouldEqual, "other")
+		})
+
+		Convey("If no mapper matches the prototype map is used", func() {
+			apierr, err := errorManager.HandleScope(unidb.ErrUniqueViolation.New(), nil)
+			So(err, ShouldBeNil)
+			So(apierr.Code, ShouldEqual, jsonapi.ErrResourceAlreadyExists.Code)
+		})
+	})
+}
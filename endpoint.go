@@ -51,8 +51,27 @@ type Endpoint struct {
 	// CountList is a flag that defines if the List result should include objects count
 	CountList bool
 
+	// ConditionalGet enables the 'ETag' and 'Last-Modified' headers for the Get and List
+	// responses. The requests with matching 'If-None-Match' or 'If-Modified-Since' headers
+	// would be responded with the status 304 Not Modified.
+	ConditionalGet bool
+
+	// CacheControl is the value of the 'Cache-Control' header set for the endpoint's responses.
+	CacheControl string
+
+	// Sideposting allows the Create endpoint to create the resources provided within the request's
+	// 'included' member. The included resources are created first by their models' Create
+	// endpoints and their local ids ('lid') are resolved within the relationships of the primary
+	// resource. If the models are stored within the same TransactionalRepository, all the
+	// resources are created within a single transaction. Otherwise, if any of the resources could
+	// not be created, the already created ones are deleted.
+	Sideposting bool
+
 	// CustomHandlerFunc is a http.HandlerFunc defined for this endpoint
 	CustomHandlerFunc http.HandlerFunc
+
+	// hooks are the hook functions registered for the endpoint
+	hooks hookFuncs
 }
 
 func (e *Endpoint) HasPrechecks() bool {
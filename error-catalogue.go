@@ -0,0 +1,218 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/kucjac/jsonapi"
+	"golang.org/x/text/language"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrorMessage is the localized 'title' and 'detail' of the error object with given code.
+type ErrorMessage struct {
+	Title  string `json:"title,omitempty" toml:"title"`
+	Detail string `json:"detail,omitempty" toml:"detail"`
+}
+
+// ErrorCatalogue contains the localized error messages keyed by the error code.
+// The error objects are written in english by default. The catalogue translates the 'title'
+// and the 'detail' of the error objects into the language matched with the request's
+// 'Accept-Language' header. If the catalogue does not contain the message for the error code
+// in the matched language, the parent languages are checked i.e. 'de-CH' -> 'de'. If none
+// matches, the english message is kept.
+// The detail is translated only if the error object's detail was not changed by the handler,
+// so that the details containing the request specific values are not lost.
+type ErrorCatalogue struct {
+	messages map[language.Tag]map[string]*ErrorMessage
+	tags     []language.Tag
+	lock     sync.RWMutex
+
+	// matcherTags are the tags of the matcher with english as the first one
+	matcherTags []language.Tag
+	matcher     language.Matcher
+}
+
+// NewErrorCatalogue creates the empty error catalogue.
+func NewErrorCatalogue() *ErrorCatalogue {
+	c := &ErrorCatalogue{messages: make(map[language.Tag]map[string]*ErrorMessage)}
+	c.resetMatcher()
+	return c
+}
+
+// Add adds the message for the error 'code' in the 'tag' language.
+func (c *ErrorCatalogue) Add(tag language.Tag, code string, msg *ErrorMessage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	messages, ok := c.messages[tag]
+	if !ok {
+		messages = make(map[string]*ErrorMessage)
+		c.messages[tag] = messages
+		c.tags = append(c.tags, tag)
+		c.resetMatcher()
+	}
+	messages[code] = msg
+}
+
+// LoadJSON loads the messages in the 'tag' language from the JSON document. The document is
+// an object with the error codes as keys and the messages as values i.e.:
+//
+//	{"JSONAPI_RESOURCE_NOT_FOUND": {"title": "...", "detail": "..."}}
+func (c *ErrorCatalogue) LoadJSON(tag language.Tag, r io.Reader) error {
+	messages := make(map[string]*ErrorMessage)
+	if err := json.NewDecoder(r).Decode(&messages); err != nil {
+		return fmt.Errorf("Cannot decode JSON error catalogue for language: '%s'. %v", tag, err)
+	}
+	c.addMessages(tag, messages)
+	return nil
+}
+
+// LoadTOML loads the messages in the 'tag' language from the TOML document. Each error code
+// is the table containing the message i.e.:
+//
+//	[JSONAPI_RESOURCE_NOT_FOUND]
+//	title = "..."
+//	detail = "..."
+func (c *ErrorCatalogue) LoadTOML(tag language.Tag, r io.Reader) error {
+	messages := make(map[string]*ErrorMessage)
+	if _, err := toml.DecodeReader(r, &messages); err != nil {
+		return fmt.Errorf("Cannot decode TOML error catalogue for language: '%s'. %v", tag, err)
+	}
+	c.addMessages(tag, messages)
+	return nil
+}
+
+// LoadFile loads the messages in the 'tag' language from the file at 'path'. The file format
+// is chosen by its extension: '.json' or '.toml'.
+func (c *ErrorCatalogue) LoadFile(tag language.Tag, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return c.LoadJSON(tag, f)
+	case ".toml":
+		return c.LoadTOML(tag, f)
+	default:
+		return fmt.Errorf("Unsupported error catalogue file extension: '%s'.", ext)
+	}
+}
+
+// Languages gets the languages of the catalogue's messages.
+func (c *ErrorCatalogue) Languages() []language.Tag {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]language.Tag(nil), c.tags...)
+}
+
+// Translate gets the copies of the 'errs' with the messages in the language matching the
+// preferred languages 'tags'. The returned tag is the language of the messages.
+// If none of the errors were translated, the returned tag is english.
+func (c *ErrorCatalogue) Translate(tags []language.Tag, errs ...*jsonapi.ErrorObject) ([]*jsonapi.ErrorObject, language.Tag) {
+	if c == nil || len(tags) == 0 {
+		return errs, language.English
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	_, index, confidence := c.matcher.Match(tags...)
+	if confidence == language.No {
+		return errs, language.English
+	}
+	tag := c.matcherTags[index]
+
+	translated := make([]*jsonapi.ErrorObject, len(errs))
+	var served bool
+	for i, errObj := range errs {
+		translated[i] = errObj
+		if errObj == nil {
+			continue
+		}
+
+		msg := c.message(tag, errObj.Code)
+		if msg == nil {
+			continue
+		}
+
+		errCopy := errObj.Copy()
+		if msg.Title != "" {
+			errCopy.Title = msg.Title
+		}
+		if msg.Detail != "" && isDefaultErrorDetail(errObj) {
+			errCopy.Detail = msg.Detail
+		}
+		translated[i] = errCopy
+		served = true
+	}
+
+	if !served {
+		return errs, language.English
+	}
+	return translated, tag
+}
+
+// message gets the message for the 'code' in the 'tag' language or its parents.
+func (c *ErrorCatalogue) message(tag language.Tag, code string) *ErrorMessage {
+	for {
+		if messages, ok := c.messages[tag]; ok {
+			if msg, ok := messages[code]; ok {
+				return msg
+			}
+		}
+		if tag.IsRoot() {
+			return nil
+		}
+		tag = tag.Parent()
+	}
+}
+
+func (c *ErrorCatalogue) addMessages(tag language.Tag, messages map[string]*ErrorMessage) {
+	for code, msg := range messages {
+		c.Add(tag, code, msg)
+	}
+}
+
+// resetMatcher creates the matcher for the catalogue's languages. English is the first tag,
+// so that it is the default.
+func (c *ErrorCatalogue) resetMatcher() {
+	c.matcherTags = append([]language.Tag{language.English}, c.tags...)
+	c.matcher = language.NewMatcher(c.matcherTags)
+}
+
+// defaultErrors are the built-in error objects which details are translated by the catalogue.
+var defaultErrors = []jsonapi.ErrorObject{
+	jsonapi.ErrInternalError,
+	jsonapi.ErrInvalidInput,
+	jsonapi.ErrInsufficientAccPerm,
+	jsonapi.ErrResourceNotFound,
+	jsonapi.ErrInvalidJSONFieldValue,
+	jsonapi.ErrResourceAlreadyExists,
+	jsonapi.ErrMissingRequiredJSONField,
+	jsonapi.ErrInvalidResourceName,
+	jsonapi.ErrInvalidHeaderValue,
+	jsonapi.ErrEndpointForbidden,
+	jsonapi.ErrLanguageNotAcceptable,
+}
+
+// isDefaultErrorDetail checks if the 'errObj' detail is empty or equal to the detail of the
+// built-in error object with the same code.
+func isDefaultErrorDetail(errObj *jsonapi.ErrorObject) bool {
+	if errObj.Detail == "" {
+		return true
+	}
+	for _, defaultErr := range defaultErrors {
+		if defaultErr.Code == errObj.Code {
+			return defaultErr.Detail == errObj.Detail
+		}
+	}
+	return false
+}
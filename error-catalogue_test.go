@@ -0,0 +1,105 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorCatalogueLoad(t *testing.T) {
+	c := NewErrorCatalogue()
+
+	err := c.LoadJSON(language.Polish, strings.NewReader(`{"C1": {"title": "Tytuł", "detail": "Szczegóły"}}`))
+	assert.NoError(t, err)
+
+	err = c.LoadTOML(language.German, strings.NewReader("[C1]\ntitle = \"Titel\"\n"))
+	assert.NoError(t, err)
+
+	err = c.LoadJSON(language.French, strings.NewReader(`invalid`))
+	assert.Error(t, err)
+
+	err = c.LoadFile(language.French, "catalogue.yaml")
+	assert.Error(t, err)
+
+	assert.Len(t, c.Languages(), 2)
+	assert.Equal(t, "Tytuł", c.message(language.Polish, "C1").Title)
+	assert.Equal(t, "Titel", c.message(language.MustParse("de-CH"), "C1").Title)
+	assert.Nil(t, c.message(language.Polish, "C2"))
+}
+
+func TestErrorCatalogueTranslate(t *testing.T) {
+	c := NewErrorCatalogue()
+	c.Add(language.Polish, jsonapi.ErrResourceNotFound.Code, &ErrorMessage{Title: "Nie znaleziono", Detail: "Nie znaleziono zasobu."})
+
+	tags := []language.Tag{language.MustParse("pl-PL"), language.English}
+
+	// Case 1:
+	// Default detail is translated
+	errObj := jsonapi.ErrResourceNotFound.Copy()
+	translated, tag := c.Translate(tags, errObj)
+	assert.Equal(t, language.Polish, tag)
+	assert.Equal(t, "Nie znaleziono", translated[0].Title)
+	assert.Equal(t, "Nie znaleziono zasobu.", translated[0].Detail)
+	assert.NotEqual(t, "Nie znaleziono", errObj.Title)
+
+	// Case 2:
+	// Custom detail is kept
+	errObj.Detail = "Blog: '1' not found."
+	translated, _ = c.Translate(tags, errObj)
+	assert.Equal(t, "Nie znaleziono", translated[0].Title)
+	assert.Equal(t, "Blog: '1' not found.", translated[0].Detail)
+
+	// Case 3:
+	// No message for the code falls back to english
+	errObj = jsonapi.ErrInvalidInput.Copy()
+	translated, tag = c.Translate(tags, errObj)
+	assert.Equal(t, language.English, tag)
+	assert.Equal(t, errObj, translated[0])
+
+	// Case 4:
+	// Unsupported language
+	translated, tag = c.Translate([]language.Tag{language.Japanese}, jsonapi.ErrResourceNotFound.Copy())
+	assert.Equal(t, language.English, tag)
+	assert.Equal(t, jsonapi.ErrResourceNotFound.Title, translated[0].Title)
+}
+
+func TestMarshalErrorsLocalized(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	h.ErrorCatalogue.Add(language.Polish, jsonapi.ErrResourceNotFound.Code, &ErrorMessage{Title: "Nie znaleziono"})
+
+	req := httptest.NewRequest("GET", "/blogs/1", nil)
+	req.Header.Set(headerAcceptLanguage, "pl")
+	rw := httptest.NewRecorder()
+
+	h.MarshalErrors(newResponseWriter(rw, req), jsonapi.ErrResourceNotFound.Copy())
+	assert.Equal(t, "pl", rw.Header().Get(headerContentLanguage))
+
+	doc := struct {
+		Errors []*jsonapi.ErrorObject `json:"errors"`
+	}{}
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	if assert.Len(t, doc.Errors, 1) {
+		assert.Equal(t, "Nie znaleziono", doc.Errors[0].Title)
+	}
+
+	// The content language set before the error is replaced
+	rw = httptest.NewRecorder()
+	rw.Header().Set(headerContentLanguage, "en")
+	h.MarshalErrors(newResponseWriter(rw, req), jsonapi.ErrResourceNotFound.Copy())
+	assert.Equal(t, "pl", rw.Header().Get(headerContentLanguage))
+
+	// The errors not translated are rendered in english
+	rw = httptest.NewRecorder()
+	rw.Header().Set(headerContentLanguage, "pl")
+	h.MarshalErrors(newResponseWriter(rw, req), jsonapi.ErrInvalidInput.Copy())
+	assert.Equal(t, "en", rw.Header().Get(headerContentLanguage))
+
+	// The writer not bound to the request is not translated
+	rw = httptest.NewRecorder()
+	h.MarshalErrors(rw, jsonapi.ErrResourceNotFound.Copy())
+	assert.Empty(t, rw.Header().Get(headerContentLanguage))
+}
@@ -1,8 +1,10 @@
 package jsonapisdk
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/kucjac/jsonapi"
+	"io"
 )
 
 type ErrorCode int
@@ -32,3 +34,60 @@ func newHandlerError(code ErrorCode, msg string) *HandlerError {
 func (e *HandlerError) Error() string {
 	return fmt.Sprintf("%d. %s", e.Code, e.Message)
 }
+
+const errorMetaSource = "source"
+
+// ErrorSource is the 'source' member of the jsonapi error object. It contains references to the
+// source of the error.
+type ErrorSource struct {
+	// Pointer is a JSON Pointer [RFC6901] to the associated entity in the request document
+	// i.e. '/data/attributes/title'.
+	Pointer string `json:"pointer,omitempty"`
+
+	// Parameter indicates which URI query parameter caused the error.
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// SetErrorSource sets the source for the provided error object. The source is kept within the
+// error's meta and is marshaled as the top-level 'source' member by the MarshalErrors method.
+func SetErrorSource(errObj *jsonapi.ErrorObject, source *ErrorSource) {
+	if errObj.Meta == nil {
+		errObj.Meta = &map[string]interface{}{}
+	}
+	(*errObj.Meta)[errorMetaSource] = source
+}
+
+// errorDocument is the top-level jsonapi errors document.
+type errorDocument struct {
+	Errors []*errorObjectPayload `json:"errors"`
+}
+
+// errorObjectPayload marshals the jsonapi.ErrorObject with the 'source' member lifted from its
+// meta.
+type errorObjectPayload struct {
+	*jsonapi.ErrorObject
+	Source *ErrorSource            `json:"source,omitempty"`
+	Meta   *map[string]interface{} `json:"meta,omitempty"`
+}
+
+func marshalErrors(w io.Writer, errs ...*jsonapi.ErrorObject) error {
+	doc := &errorDocument{Errors: make([]*errorObjectPayload, len(errs))}
+	for i, errObj := range errs {
+		payload := &errorObjectPayload{ErrorObject: errObj}
+		if errObj.Meta != nil {
+			meta := make(map[string]interface{}, len(*errObj.Meta))
+			for key, value := range *errObj.Meta {
+				if source, ok := value.(*ErrorSource); ok && key == errorMetaSource {
+					payload.Source = source
+					continue
+				}
+				meta[key] = value
+			}
+			if len(meta) > 0 {
+				payload.Meta = &meta
+			}
+		}
+		doc.Errors[i] = payload
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
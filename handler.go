@@ -1,17 +1,23 @@
 package jsonapisdk
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/universal-translator"
 	"github.com/kucjac/jsonapi"
 	"github.com/kucjac/uni-db"
 	"github.com/kucjac/uni-logger"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/text/language"
 	"gopkg.in/go-playground/validator.v9"
+	"io/ioutil"
 	"net/http"
 	"reflect"
 	"runtime/debug"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -32,6 +38,10 @@ type JSONAPIHandler struct {
 	// Logger
 	log unilogger.LeveledLogger
 
+	// LogLevels are the log levels of the handler's subsystems. The subsystems without the level
+	// log with the DefaultLogLevel.
+	LogLevels map[Subsystem]LogLevel
+
 	// Repositories
 	DefaultRepository Repository
 
@@ -44,12 +54,57 @@ type JSONAPIHandler struct {
 	// LanguageMatcher matches the possible language
 	LanguageMatcher language.Matcher
 
+	// LanguageQueryParameter is the name of the query parameter that selects the response
+	// language i.e. 'lang'. If empty, the language is taken only from the 'Accept-Language'
+	// header.
+	LanguageQueryParameter string
+
+	// LanguagePrecedence defines if the language query parameter or the 'Accept-Language'
+	// header is preferred.
+	LanguagePrecedence LanguagePrecedence
+
 	// Validators validate given
 	CreateValidator *validator.Validate
 	PatchValidator  *validator.Validate
 
+	// ValidationTranslator translates the validation error messages into the request's language.
+	// By default it contains english translations.
+	ValidationTranslator *ut.UniversalTranslator
+
+	// ErrorCatalogue translates the error objects' messages into the request's language.
+	ErrorCatalogue *ErrorCatalogue
+
 	// ModelHandlers
 	ModelHandlers map[reflect.Type]*ModelHandler
+
+	// DescribedBy adds the top-level 'describedby' link to the collection's JSON Schema within
+	// the resource documents. The schemas are served by the ServeJSONSchema handlers.
+	DescribedBy bool
+
+	// Metrics collects the metrics of the handler's endpoints and repositories. If nil, the
+	// metrics are not collected.
+	Metrics MetricsCollector
+
+	// TracerProvider provides the tracer for the spans of the handler's endpoints, their stages
+	// and the repository calls. If nil, the global OpenTelemetry tracer provider is used.
+	TracerProvider trace.TracerProvider
+
+	// IdempotencyStore stores the responses for the Create, Patch and Delete requests with
+	// the 'Idempotency-Key' header. If nil, the header is ignored.
+	IdempotencyStore IdempotencyStore
+
+	// IdempotencyPrincipal gets the principal of the request that scopes its idempotency key.
+	// If nil, the request's 'Authorization' header is used.
+	IdempotencyPrincipal func(req *http.Request) string
+
+	// IdempotencyWait is the maximum time the request waits for the request with the same
+	// idempotency key that is still processed. If zero, the requests wait for 30 seconds.
+	IdempotencyWait time.Duration
+
+	// validationTranslations are the translations registered for the validators
+	validationTranslations []*validationTranslation
+	translatedValidators   map[*validator.Validate]int
+	validatorsLock         sync.Mutex
 }
 
 // NewHandler creates new handler on the base of
@@ -66,16 +121,15 @@ func NewHandler(
 		log:             log,
 		DBErrMgr:        DBErrMgr,
 		ModelHandlers:   make(map[reflect.Type]*ModelHandler),
-		CreateValidator: validator.New(),
-		PatchValidator:  validator.New(),
+		CreateValidator: newValidator(createValidatorTag),
+		PatchValidator:  newValidator(patchValidatorTag),
+		ErrorCatalogue:  NewErrorCatalogue(),
 	}
 
-	h.CreateValidator.SetTagName("create")
-	h.PatchValidator.SetTagName("patch")
-
-	// Register jsonapi name func
-	h.CreateValidator.RegisterTagNameFunc(JSONAPITagFunc)
-	h.PatchValidator.RegisterTagNameFunc(JSONAPITagFunc)
+	// Register default english validation messages
+	if err := h.RegisterValidationTranslations(en.New(), registerEnglishTranslations); err != nil {
+		log.Errorf("Cannot register english validation translations. %v", err)
+	}
 
 	return h
 }
@@ -98,7 +152,7 @@ func (h *JSONAPIHandler) CheckPrecheckValues(
 	filter *jsonapi.FilterField,
 ) (err error) {
 	if scope.Value == nil {
-		h.log.Errorf("Provided no value for the scope of type: '%s'", scope.Struct.GetType().Name())
+		h.logger(nil, SubsystemEndpoint).Errorf("Provided no value for the scope of type: '%s'", scope.Struct.GetType().Name())
 		return IErrScopeNoValue
 	}
 
@@ -126,7 +180,7 @@ func (h *JSONAPIHandler) CheckPrecheckValues(
 				}
 				return true
 			default:
-				h.log.Errorf("Invalid filter field kind for field: '%s'. Within model: '%s'.", filter.GetFieldName(), scope.Struct.GetType().Name())
+				h.logger(nil, SubsystemEndpoint).Errorf("Invalid filter field kind for field: '%s'. Within model: '%s'.", filter.GetFieldName(), scope.Struct.GetType().Name())
 				err = IErrInvalidValueType
 				return false
 			}
@@ -160,8 +214,9 @@ func (h *JSONAPIHandler) CheckPrecheckValues(
 // GetRepositoryByType returns the repository by provided model type.
 // If no modelHandler is found within the jsonapi handler - then the default repository would be
 // set.
+// If the handler has the metrics collector, the repository calls are observed.
 func (h *JSONAPIHandler) GetRepositoryByType(model reflect.Type) (repo Repository) {
-	return h.getModelRepositoryByType(model)
+	return h.instrumentRepository(model, h.getModelRepositoryByType(model))
 }
 
 // Exported method to get included values for given scope
@@ -175,9 +230,10 @@ func (h *JSONAPIHandler) GetIncluded(
 	if scope.IsRoot() && len(scope.IncludedScopes) == 0 {
 		return true
 	}
+	defer h.traceSpan(rw, "jsonapi.include", h.modelAttributes(scope.Struct.GetType())...)()
 
 	if err := scope.SetCollectionValues(); err != nil {
-		h.log.Errorf("Setting collection values for the scope of type: %v. Err: %v", scope.Struct.GetType(), err)
+		h.logger(rw, SubsystemInclude).Errorf("Setting collection values for the scope of type: %v. Err: %v", scope.Struct.GetType(), err)
 		h.MarshalInternalError(rw)
 		return
 	}
@@ -190,7 +246,7 @@ func (h *JSONAPIHandler) GetIncluded(
 		// Get next included field
 		includedField, err := scope.CurrentIncludedField()
 		if err != nil {
-			h.log.Error(err)
+			h.logger(rw, SubsystemInclude).Error(err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -198,7 +254,7 @@ func (h *JSONAPIHandler) GetIncluded(
 		// Get the primaries from the scope.collection primaries
 		missing, err := includedField.GetMissingPrimaries()
 		if err != nil {
-			h.log.Errorf("While getting missing objects for: '%v'over included field an error occured: %v", includedField.GetFieldName(), err)
+			h.logger(rw, SubsystemInclude).Errorf("While getting missing objects for: '%v'over included field an error occured: %v", includedField.GetFieldName(), err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -208,27 +264,37 @@ func (h *JSONAPIHandler) GetIncluded(
 			includedField.Scope.SetIDFilters(missing...)
 			// h.log.Debugf("Created ID Filters: '%v'", includedField.Scope.PrimaryFilters)
 
+			var languages []language.Tag
 			if includedField.Scope.UseI18n() {
-				includedField.Scope.SetLanguageFilter(tag.String())
+				languages = h.modelLanguageChain(includedField.Scope.Struct.GetType(), tag)
+				setLanguageChainFilter(includedField.Scope, languages)
 			}
 
-			includedRepo := h.GetRepositoryByType(includedField.Scope.Struct.GetType())
+			// the trashed resources are never included.
+			if err = h.addTrashedFilter(includedField.Scope, TrashedWithout); err != nil {
+				h.logger(rw, SubsystemInclude).Errorf("Cannot add trashed filter for included field: '%v'. %v", includedField.GetFieldName(), err)
+				h.MarshalInternalError(rw)
+				return
+			}
+
+			includedRepo := h.repository(rw, includedField.Scope.Struct.GetType())
 
 			// Get NewMultipleValue
 			includedField.Scope.NewValueMany()
 
-			if errObj := h.HookBeforeReader(includedField.Scope); errObj != nil {
+			includedModel := h.ModelHandlers[includedField.Scope.Struct.GetType()]
+			if errObj := h.runHooks(includedModel, nil, BeforeRead, req, includedField.Scope); errObj != nil {
 				h.MarshalErrors(rw, errObj)
 				return
 			}
 
-			dbErr := includedRepo.List(includedField.Scope)
+			dbErr := listTranslations(includedRepo, includedField.Scope, languages)
 			if dbErr != nil {
-				h.manageDBError(rw, dbErr)
+				h.manageDBError(rw, includedField.Scope, dbErr)
 				return
 			}
 
-			if errObj := h.HookAfterReader(includedField.Scope); errObj != nil {
+			if errObj := h.runHooks(includedModel, nil, AfterRead, req, includedField.Scope); errObj != nil {
 				h.MarshalErrors(rw, errObj)
 				return
 			}
@@ -246,17 +312,17 @@ func (h *JSONAPIHandler) EndpointForbidden(
 	model *ModelHandler,
 	endpoint EndpointType,
 ) http.HandlerFunc {
-	return func(rw http.ResponseWriter, req *http.Request) {
+	return h.wrapHandler(model, endpoint, func(rw http.ResponseWriter, req *http.Request) {
 		mStruct := h.Controller.Models.Get(model.ModelType)
 		if mStruct == nil {
-			h.log.Errorf("Invalid model provided. The Controller does not contain provided model type within ModelMap. Model: '%s'", model.ModelType)
+			h.logger(rw, SubsystemEndpoint).Errorf("Invalid model provided. The Controller does not contain provided model type within ModelMap. Model: '%s'", model.ModelType)
 			h.MarshalInternalError(rw)
 			return
 		}
 		errObj := jsonapi.ErrEndpointForbidden.Copy()
 		errObj.Detail = fmt.Sprintf("Server does not allow '%s' operation, at given URI: '%s' for the collection: '%s'.", endpoint.String(), req.URL.Path, mStruct.GetCollectionType())
 		h.MarshalErrors(rw, errObj)
-	}
+	})
 
 }
 
@@ -269,10 +335,12 @@ func (h *JSONAPIHandler) MarshalScope(
 	SetContentType(rw)
 	payload, err := h.Controller.MarshalScope(scope)
 	if err != nil {
-		h.log.Errorf("Error while marshaling scope for model: '%v', for path: '%s', and method: '%s', Error: %s", scope.Struct.GetType(), req.URL.Path, req.Method, err)
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while marshaling scope for model: '%v', for path: '%s', and method: '%s', Error: %s", scope.Struct.GetType(), req.URL.Path, req.Method, err)
 		h.errMarshalScope(rw, req)
 		return
 	}
+	setLanguageMeta(scope, payload)
+	h.setDescribedByLink(scope, payload)
 
 	if err = jsonapi.MarshalPayload(rw, payload); err != nil {
 		h.errMarshalPayload(payload, err, scope.Struct.GetType(), rw, req)
@@ -298,16 +366,34 @@ func (h *JSONAPIHandler) UnmarshalScope(
 	rw http.ResponseWriter,
 	req *http.Request,
 ) *jsonapi.Scope {
-	scope, errObj, err := jsonapi.UnmarshalScopeOne(req.Body, h.Controller)
+	scope, _ := h.unmarshalScope(model, rw, req)
+	return scope
+}
+
+// unmarshalScope unmarshals the scope from the request's body. It returns the read body, so
+// that it could be inspected after the unmarshaling.
+func (h *JSONAPIHandler) unmarshalScope(
+	model reflect.Type,
+	rw http.ResponseWriter,
+	req *http.Request,
+) (*jsonapi.Scope, []byte) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while reading body for path: '%s' and method: %s. Error: %s.", req.URL.Path, req.Method, err)
+		h.MarshalErrors(rw, jsonapi.ErrInvalidInput.Copy())
+		return nil, nil
+	}
+
+	scope, errObj, err := jsonapi.UnmarshalScopeOne(bytes.NewReader(body), h.Controller)
 	if err != nil {
-		h.log.Errorf("Error while unmarshaling: '%v' for path: '%s' and method: %s. Error: %s.", model, req.URL.Path, req.Method, err)
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while unmarshaling: '%v' for path: '%s' and method: %s. Error: %s.", model, req.URL.Path, req.Method, err)
 		h.MarshalInternalError(rw)
-		return nil
+		return nil, nil
 	}
 
 	if errObj != nil {
 		h.MarshalErrors(rw, errObj)
-		return nil
+		return nil, nil
 	}
 
 	if scope.Struct.GetType() != model {
@@ -315,30 +401,44 @@ func (h *JSONAPIHandler) UnmarshalScope(
 		// h.MarshalInternalError(rw)
 		mStruct := h.Controller.Models.Get(model)
 		if mStruct == nil {
-			h.log.Errorf("No model found for: '%v' within the controller.", model)
+			h.logger(rw, SubsystemEndpoint).Errorf("No model found for: '%v' within the controller.", model)
 			h.MarshalInternalError(rw)
-			return nil
+			return nil, nil
 		}
 		errObj = jsonapi.ErrInvalidResourceName.Copy()
 		errObj.Detail = fmt.Sprintf("Provided resource: '%s' is not proper for this endpoint. This endpoint support '%s' collection.", scope.Struct.GetCollectionType(), mStruct.GetCollectionType())
 		h.MarshalErrors(rw, errObj)
-		return nil
+		return nil, nil
 	}
-	return scope
+	return scope, body
 }
 
 func (h *JSONAPIHandler) MarshalInternalError(rw http.ResponseWriter) {
 	SetContentType(rw)
+	errors := h.translateErrors(rw, language.English, jsonapi.ErrInternalError.Copy())
+	errors = h.identifyErrors(rw, errors...)
+	setErrorCode(rw, errors...)
 	rw.WriteHeader(http.StatusInternalServerError)
-	jsonapi.MarshalErrors(rw, jsonapi.ErrInternalError.Copy())
+	jsonapi.MarshalErrors(rw, errors...)
 }
 
+// MarshalErrors writes the error objects to the response. The error messages are translated
+// by the handler's ErrorCatalogue into the language of the request bound to the 'rw'.
+// Each error object gets the id that is logged with the request's id.
 func (h *JSONAPIHandler) MarshalErrors(rw http.ResponseWriter, errors ...*jsonapi.ErrorObject) {
+	h.writeErrors(rw, language.English, errors...)
+}
+
+// writeErrors writes the error objects rendered in the 'rendered' language to the response.
+func (h *JSONAPIHandler) writeErrors(rw http.ResponseWriter, rendered language.Tag, errors ...*jsonapi.ErrorObject) {
 	SetContentType(rw)
+	errors = h.translateErrors(rw, rendered, errors...)
+	errors = h.identifyErrors(rw, errors...)
+	setErrorCode(rw, errors...)
 	if len(errors) > 0 {
 		code, err := strconv.Atoi(errors[0].Status)
 		if err != nil {
-			h.log.Errorf("Status: '%s', for error: %v cannot be converted into http.Status.", errors[0].Status, errors[0])
+			h.logger(rw, SubsystemEndpoint).Errorf("Status: '%s', for error: %v cannot be converted into http.Status.", errors[0].Status, errors[0])
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -346,47 +446,56 @@ func (h *JSONAPIHandler) MarshalErrors(rw http.ResponseWriter, errors ...*jsonap
 	} else {
 		rw.WriteHeader(http.StatusBadRequest)
 	}
-	jsonapi.MarshalErrors(rw, errors...)
+	if err := marshalErrors(rw, errors...); err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while marshaling errors: %v", err)
+	}
+}
+
+// translateErrors translates the 'errors' rendered in the 'rendered' language into the language
+// of the request bound to the 'rw'. The 'Content-Language' header is set to the language
+// the errors are finally rendered in.
+func (h *JSONAPIHandler) translateErrors(
+	rw http.ResponseWriter,
+	rendered language.Tag,
+	errors ...*jsonapi.ErrorObject,
+) []*jsonapi.ErrorObject {
+	req := requestOf(rw)
+	if req == nil {
+		return errors
+	}
+
+	tags, errObj := h.requestLanguages(req)
+	if errObj != nil {
+		return errors
+	}
+
+	errors, tag := h.ErrorCatalogue.Translate(tags, errors...)
+	if tag == language.English {
+		// the errors were not translated by the catalogue
+		tag = rendered
+	}
+	rw.Header().Set(headerContentLanguage, tag.String())
+	return errors
 }
 
 func SetContentType(rw http.ResponseWriter) {
 	rw.Header().Set("Content-Type", jsonapi.MediaType)
 }
 
+// HandleValidateError writes the validation error into the response.
+// The messages are not translated. Use the handlers with the request to get localized messages.
 func (h *JSONAPIHandler) HandleValidateError(
 	model *ModelHandler,
 	err error,
 	rw http.ResponseWriter,
 ) {
-	if _, ok := err.(*validator.InvalidValidationError); ok {
-		h.log.Debug("Invalid Validation Error")
-		h.MarshalInternalError(rw)
-	}
-
-	// mStruct, err := h.Controller.GetModelStruct(model)
-	// if err != nil {
-	// 	h.log.Error("Cannot retrieve model from struct.")
-	// 	h.MarshalInternalError(rw)
-	// }
-	vErrors, ok := err.(validator.ValidationErrors)
-	if !ok {
-		h.MarshalInternalError(rw)
-	}
-
-	var errs []*jsonapi.ErrorObject
-	for _, fieldError := range vErrors {
-		errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
-		errObj.Detail = fmt.Sprintf("Invalid: '%s' for : '%s'", fieldError.ActualTag(), fieldError.Field())
-		errs = append(errs, errObj)
-	}
-	h.MarshalErrors(rw, errs...)
-	return
+	h.handleValidateError(model, err, rw, nil)
 }
 
 func (h *JSONAPIHandler) checkValues(filterValue *jsonapi.FilterValues, fieldValue reflect.Value) (ok bool) {
 	defer func() {
 		if r := recover(); r != nil {
-			h.log.Error("Paniced while checking values. '%s'", r)
+			h.logger(nil, SubsystemEndpoint).Errorf("Paniced while checking values. '%s'", r)
 		}
 		ok = false
 	}()
@@ -501,7 +610,7 @@ func (h *JSONAPIHandler) addPresetFilterToPresetScope(
 	case jsonapi.Attribute:
 		presetScope.AttributeFilters = append(presetScope.AttributeFilters, presetFilter)
 	default:
-		h.log.Warningf("PrecheckFilter cannot be of reltionship field filter type.")
+		h.logger(nil, SubsystemEndpoint).Warningf("PrecheckFilter cannot be of reltionship field filter type.")
 		return false
 	}
 	return true
@@ -523,14 +632,14 @@ func (h *JSONAPIHandler) getModelRepositoryByType(modelType reflect.Type) (repo
 func (h *JSONAPIHandler) handleHandlerError(hErr *HandlerError, rw http.ResponseWriter) bool {
 	switch hErr.Code {
 	case ErrInternal:
-		h.log.Error(hErr.Error())
-		h.log.Error(string(debug.Stack()))
+		h.logger(rw, SubsystemEndpoint).Error(hErr.Error())
+		h.logger(rw, SubsystemEndpoint).Error(string(debug.Stack()))
 		h.MarshalInternalError(rw)
 		return false
 	case ErrAlreadyWritten:
 		return false
 	case ErrBadValues, ErrNoModel, ErrValuePreset:
-		h.log.Error(hErr.Error())
+		h.logger(rw, SubsystemEndpoint).Error(hErr.Error())
 		h.MarshalInternalError(rw)
 		return false
 	case ErrNoValues:
@@ -538,23 +647,32 @@ func (h *JSONAPIHandler) handleHandlerError(hErr *HandlerError, rw http.Response
 		h.MarshalErrors(rw, errObj)
 		return false
 	case ErrWarning:
-		h.log.Warning(hErr)
+		h.logger(rw, SubsystemEndpoint).Warning(hErr)
 		return true
 	}
 	return true
 
 }
 
-func (h *JSONAPIHandler) manageDBError(rw http.ResponseWriter, dbErr *unidb.Error) {
-	errObj, err := h.DBErrMgr.Handle(dbErr)
+// manageDBError writes the jsonapi error for the 'dbErr' mapped by the handler's DBErrMgr.
+// If the 'scope' is provided and its repository resolves the constraint that caused the error,
+// the matching DBErrorRule is applied.
+func (h *JSONAPIHandler) manageDBError(rw http.ResponseWriter, scope *jsonapi.Scope, dbErr *unidb.Error) {
+	errObj, outcome, err := h.DBErrMgr.handleScope(dbErr, scope)
 	if err != nil {
-		h.log.Error(dbErr.Message)
+		h.observeDBError(dbErr, DBErrorUnmapped)
+		h.logger(rw, SubsystemDatabase).Error(dbErr.Message)
 		h.MarshalInternalError(rw)
 		return
 	}
 
+	if ruleErr := h.constraintErrorObject(scope, dbErr, errObj); ruleErr != nil {
+		errObj, outcome = ruleErr, DBErrorRuleMatched
+	}
+	h.observeDBError(dbErr, outcome)
+
 	if proto, _ := dbErr.GetPrototype(); proto == unidb.ErrUnspecifiedError || proto == unidb.ErrInternalError {
-		h.log.Error(dbErr)
+		h.logger(rw, SubsystemDatabase).Error(dbErr)
 	}
 
 	h.MarshalErrors(rw, errObj)
@@ -567,7 +685,7 @@ func (h *JSONAPIHandler) errSetIDFilter(
 	rw http.ResponseWriter,
 	req *http.Request,
 ) {
-	h.log.Errorf("Error while setting id filter for the path: '%s', and scope: of type '%v'. Error: %v", req.URL.Path, scope.Struct.GetType(), err)
+	h.logger(rw, SubsystemEndpoint).Errorf("Error while setting id filter for the path: '%s', and scope: of type '%v'. Error: %v", req.URL.Path, scope.Struct.GetType(), err)
 	h.MarshalInternalError(rw)
 	return
 }
@@ -579,7 +697,7 @@ func (h *JSONAPIHandler) errMarshalPayload(
 	rw http.ResponseWriter,
 	req *http.Request,
 ) {
-	h.log.Errorf("Error while marshaling payload: '%v'. For model: '%v', Path: '%s', Method: '%s', Error: %v", payload, model, req.URL.Path, req.Method, err)
+	h.logger(rw, SubsystemEndpoint).Errorf("Error while marshaling payload: '%v'. For model: '%v', Path: '%s', Method: '%s', Error: %v", payload, model, req.URL.Path, req.Method, err)
 	h.MarshalInternalError(rw)
 }
 
@@ -54,7 +54,7 @@ func TestManageDBError(t *testing.T) {
 
 	// error not registered in the manager
 	rw := httptest.NewRecorder()
-	h.manageDBError(rw, customError)
+	h.manageDBError(rw, nil, customError)
 	assert.Equal(t, 500, rw.Result().StatusCode)
 
 }
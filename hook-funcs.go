@@ -0,0 +1,140 @@
+package jsonapisdk
+
+import (
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"net/http"
+	"sort"
+)
+
+// HookStage defines the stage of the endpoint's lifecycle in which the hook functions are called.
+type HookStage int
+
+const (
+	UnknownHookStage HookStage = iota
+	BeforeCreate
+	AfterCreate
+	BeforeRead
+	AfterRead
+	BeforePatch
+	AfterPatch
+	BeforeDelete
+	AfterDelete
+)
+
+func (s HookStage) String() string {
+	var op string
+	switch s {
+	case BeforeCreate:
+		op = "BEFORE CREATE"
+	case AfterCreate:
+		op = "AFTER CREATE"
+	case BeforeRead:
+		op = "BEFORE READ"
+	case AfterRead:
+		op = "AFTER READ"
+	case BeforePatch:
+		op = "BEFORE PATCH"
+	case AfterPatch:
+		op = "AFTER PATCH"
+	case BeforeDelete:
+		op = "BEFORE DELETE"
+	case AfterDelete:
+		op = "AFTER DELETE"
+	default:
+		op = "UNKNOWN"
+	}
+	return op
+}
+
+// HookFunc is the function called at the given HookStage.
+// If the returned error is of *jsonapi.ErrorObject type it is written to the response and
+// the request is stopped. Any other non nil error results with the internal error.
+type HookFunc func(req *http.Request, scope *jsonapi.Scope) error
+
+type orderedHookFunc struct {
+	order int
+	fn    HookFunc
+}
+
+// hookFuncs is the registry of the hook functions for the model or the endpoint.
+type hookFuncs struct {
+	stages map[HookStage][]*orderedHookFunc
+}
+
+func (r *hookFuncs) add(stage HookStage, order int, hook HookFunc) error {
+	if hook == nil {
+		return fmt.Errorf("Nil hook function provided for stage: '%s'.", stage)
+	}
+
+	if stage <= UnknownHookStage || stage > AfterDelete {
+		return fmt.Errorf("Invalid hook stage provided: '%d'.", stage)
+	}
+
+	if r.stages == nil {
+		r.stages = make(map[HookStage][]*orderedHookFunc)
+	}
+	r.stages[stage] = append(r.stages[stage], &orderedHookFunc{order: order, fn: hook})
+	return nil
+}
+
+func (r *hookFuncs) get(stage HookStage) []*orderedHookFunc {
+	if r == nil || r.stages == nil {
+		return nil
+	}
+	return r.stages[stage]
+}
+
+// AddHookFunc registers the hook function for the endpoint at the given stage.
+// The hook functions are called in the ascending 'order', the functions with equal order are
+// called in the registration order.
+func (e *Endpoint) AddHookFunc(stage HookStage, order int, hook HookFunc) error {
+	return e.hooks.add(stage, order, hook)
+}
+
+// AddHookFunc registers the hook function for all the model's endpoints at the given stage.
+// The hook functions are called in the ascending 'order'. The model's hook functions are called
+// before the endpoint's ones with the equal order.
+func (m *ModelHandler) AddHookFunc(stage HookStage, order int, hook HookFunc) error {
+	return m.hooks.add(stage, order, hook)
+}
+
+// runHookFuncs calls the hook functions registered for the model and the endpoint at given stage.
+// The hook functions are called after the model's interface hooks.
+func (h *JSONAPIHandler) runHookFuncs(
+	model *ModelHandler,
+	endpoint *Endpoint,
+	stage HookStage,
+	req *http.Request,
+	scope *jsonapi.Scope,
+) *jsonapi.ErrorObject {
+	var modelHooks, endpointHooks []*orderedHookFunc
+	if model != nil {
+		modelHooks = model.hooks.get(stage)
+	}
+	if endpoint != nil {
+		endpointHooks = endpoint.hooks.get(stage)
+	}
+
+	if len(modelHooks)+len(endpointHooks) == 0 {
+		return nil
+	}
+
+	hooks := make([]*orderedHookFunc, 0, len(modelHooks)+len(endpointHooks))
+	hooks = append(hooks, modelHooks...)
+	hooks = append(hooks, endpointHooks...)
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].order < hooks[j].order
+	})
+
+	for _, hook := range hooks {
+		if err := hook.fn(req, scope); err != nil {
+			if errObj, ok := err.(*jsonapi.ErrorObject); ok {
+				return errObj
+			}
+			h.loggerFor(req, SubsystemEndpoint).Errorf("Unknown error in hook function: '%s' for model: '%v'. Path: %v. Error: %v", stage, scope.Struct.GetType(), req.URL.Path, err)
+			return jsonapi.ErrInternalError.Copy()
+		}
+	}
+	return nil
+}
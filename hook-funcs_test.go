@@ -0,0 +1,61 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHookFuncs(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	blogModel := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	var calls []string
+	hookFunc := func(name string) HookFunc {
+		return func(req *http.Request, scope *jsonapi.Scope) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	assert.NoError(t, blogModel.AddHookFunc(AfterRead, 1, hookFunc("model-after")))
+	assert.NoError(t, blogModel.Get.AddHookFunc(BeforeRead, 2, hookFunc("endpoint-before-2")))
+	assert.NoError(t, blogModel.AddHookFunc(BeforeRead, 2, hookFunc("model-before-2")))
+	assert.NoError(t, blogModel.Get.AddHookFunc(BeforeRead, 1, hookFunc("endpoint-before-1")))
+	assert.Error(t, blogModel.AddHookFunc(UnknownHookStage, 1, hookFunc("invalid")))
+	assert.Error(t, blogModel.AddHookFunc(BeforeRead, 1, nil))
+
+	mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value = &Blog{ID: 1, Lang: arg.LanguageFilters.Values[0].Values[0].(string)}
+		})
+
+	// Case 1:
+	// The hook funcs are called in the order
+	rw, req := getHttpPair("GET", "/blogs/1", nil)
+	h.Get(blogModel, blogModel.Get).ServeHTTP(rw, req)
+
+	assert.Equal(t, 200, rw.Result().StatusCode)
+	assert.Equal(t, []string{"endpoint-before-1", "model-before-2", "endpoint-before-2", "model-after"}, calls)
+
+	// Case 2:
+	// The hook func short-circuits with the ErrorObject
+	assert.NoError(t, blogModel.Get.AddHookFunc(BeforeRead, 0, func(req *http.Request, scope *jsonapi.Scope) error {
+		return jsonapi.ErrInsufficientAccPerm.Copy()
+	}))
+	calls = nil
+
+	rw, req = getHttpPair("GET", "/blogs/1", nil)
+	h.Get(blogModel, blogModel.Get).ServeHTTP(rw, req)
+
+	assert.Equal(t, 403, rw.Result().StatusCode)
+	assert.Empty(t, calls)
+	mockRepo.AssertNumberOfCalls(t, "Get", 1)
+}
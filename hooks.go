@@ -1,9 +1,10 @@
 package jsonapisdk
 
 import (
-	"fmt"
 	"github.com/kucjac/jsonapi"
+	"net/http"
 	"reflect"
+	"sync"
 )
 
 type HookBeforeCreator interface {
@@ -38,111 +39,155 @@ type HookAfterDeleter interface {
 	JSONAPIAfterDelete(scope *jsonapi.Scope) error
 }
 
-func (h *JSONAPIHandler) HookBeforeReader(scope *jsonapi.Scope) *jsonapi.ErrorObject {
-	if scope.Value == nil {
-		h.log.Errorf("Provided nil value to HookBeforeReader. Model: %v", scope.Struct.GetType().Name())
-		return jsonapi.ErrInternalError.Copy()
-	}
-
-	beforeReader := reflect.TypeOf((*HookBeforeReader)(nil)).Elem()
-	t := reflect.New(scope.Struct.GetType()).Type()
-	if !t.Implements(beforeReader) {
-		h.log.Debugf("The %v does not implement: %v.", t, beforeReader)
-		return nil
-	}
+// hookInterfaces maps the hook stages to the interfaces that the models implements for them.
+var hookInterfaces = map[HookStage]reflect.Type{
+	BeforeCreate: reflect.TypeOf((*HookBeforeCreator)(nil)).Elem(),
+	AfterCreate:  reflect.TypeOf((*HookAfterCreator)(nil)).Elem(),
+	BeforeRead:   reflect.TypeOf((*HookBeforeReader)(nil)).Elem(),
+	AfterRead:    reflect.TypeOf((*HookAfterReader)(nil)).Elem(),
+	BeforePatch:  reflect.TypeOf((*HookBeforePatcher)(nil)).Elem(),
+	AfterPatch:   reflect.TypeOf((*HookAfterPatcher)(nil)).Elem(),
+	BeforeDelete: reflect.TypeOf((*HookBeforeDeleter)(nil)).Elem(),
+	AfterDelete:  reflect.TypeOf((*HookAfterDeleter)(nil)).Elem(),
+}
 
-	v := reflect.ValueOf(scope.Value)
-	switch v.Kind() {
-	case reflect.Ptr:
-		beforeReader, ok := scope.Value.(HookBeforeReader)
-		if ok {
-			if err := beforeReader.JSONAPIBeforeRead(scope); err != nil {
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					return errObj
-				}
-				h.log.Errorf("Unknown error type in JSONAPIBeforeRead. Error: %v", err)
-				return jsonapi.ErrInternalError.Copy()
-			}
-		}
-	case reflect.Slice:
+// hookImplementations caches the hook interfaces implemented by the model types.
+var hookImplementations = &hookTypeCache{types: make(map[reflect.Type]map[HookStage]bool)}
 
-		for i := 0; i < v.Len(); i++ {
+type hookTypeCache struct {
+	sync.RWMutex
+	types map[reflect.Type]map[HookStage]bool
+}
 
-			single := v.Index(i).Interface()
-			h.log.Debugf("Elem: %v", single)
-
-			beforeReader, ok := single.(HookBeforeReader)
-			if ok {
-				h.log.Debugf("Implements: %v", beforeReader)
-				if err := beforeReader.JSONAPIBeforeRead(scope); err != nil {
-					if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-						return errObj
-					}
-					h.log.Errorf("Unknown error type in JSONAPIBeforeRead. Error: %v", err)
-					return jsonapi.ErrInternalError.Copy()
-				}
-			} else {
-				h.log.Debug("Does not implement elem inside slice!!!")
-			}
-			v.Index(i).Set(reflect.ValueOf(single))
+// implements checks if the pointer to the 'model' implements the hook interface for the 'stage'.
+func (c *hookTypeCache) implements(model reflect.Type, stage HookStage) bool {
+	c.RLock()
+	stages, ok := c.types[model]
+	c.RUnlock()
+
+	if !ok {
+		ptr := reflect.PtrTo(model)
+		stages = make(map[HookStage]bool, len(hookInterfaces))
+		for hookStage, hookInterface := range hookInterfaces {
+			stages[hookStage] = ptr.Implements(hookInterface)
 		}
-	default:
-		h.log.Errorf("Provided invalid value for HookBeforeReader. Model: %v. Value: %v", scope.Struct.GetType().Name(), v)
-		return jsonapi.ErrInternalError.Copy()
+
+		c.Lock()
+		c.types[model] = stages
+		c.Unlock()
 	}
+	return stages[stage]
+}
 
-	return nil
+// HookBeforeReader calls the HookBeforeReader for each value within the scope.
+func (h *JSONAPIHandler) HookBeforeReader(scope *jsonapi.Scope) *jsonapi.ErrorObject {
+	return h.runInterfaceHooks(BeforeRead, nil, scope)
 }
 
+// HookAfterReader calls the HookAfterReader for each value within the scope.
 func (h *JSONAPIHandler) HookAfterReader(scope *jsonapi.Scope) *jsonapi.ErrorObject {
+	return h.runInterfaceHooks(AfterRead, nil, scope)
+}
+
+// runHooks executes all the hooks for the provided stage and scope. At first the model's
+// interface hooks are called for each value within the scope, then the hook functions registered
+// for the model and the endpoint.
+// The hook functions are not called if the request is nil.
+func (h *JSONAPIHandler) runHooks(
+	model *ModelHandler,
+	endpoint *Endpoint,
+	stage HookStage,
+	req *http.Request,
+	scope *jsonapi.Scope,
+) *jsonapi.ErrorObject {
+	if errObj := h.runInterfaceHooks(stage, req, scope); errObj != nil {
+		return errObj
+	}
+
+	if req == nil {
+		return nil
+	}
+	return h.runHookFuncs(model, endpoint, stage, req, scope)
+}
+
+// runInterfaceHooks calls the hook interface method for every value within the scope.
+// The scope value may be a pointer to the model or a slice of models. The 'req' might be nil
+// and is used only for the log entries.
+func (h *JSONAPIHandler) runInterfaceHooks(
+	stage HookStage,
+	req *http.Request,
+	scope *jsonapi.Scope,
+) *jsonapi.ErrorObject {
 	if scope.Value == nil {
-		h.log.Errorf("Provided nil value after HookAfterReader. Model: %v", scope.Struct.GetType().Name())
+		h.loggerFor(req, SubsystemEndpoint).Errorf("Provided nil value to hook: '%s'. Model: %v", stage, scope.Struct.GetType().Name())
 		return jsonapi.ErrInternalError.Copy()
 	}
 
-	afterReader := reflect.TypeOf((*HookAfterReader)(nil)).Elem()
-	if !reflect.New(scope.Struct.GetType()).Type().Implements(afterReader) {
-		h.log.Debugf("'%v' does not implement After Reader: %v", reflect.New(scope.Struct.GetType()).Type(), afterReader)
+	if !hookImplementations.implements(scope.Struct.GetType(), stage) {
 		return nil
 	}
 
 	v := reflect.ValueOf(scope.Value)
 	switch v.Kind() {
 	case reflect.Ptr:
-		afterReader, ok := scope.Value.(HookAfterReader)
-		if ok {
-			if err := afterReader.JSONAPIAfterRead(scope); err != nil {
-				if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-					return errObj
-				}
-				h.log.Error(err)
-				errObj := jsonapi.ErrInternalError.Copy()
-				errObj.Detail = fmt.Sprintf("Error while using HookAfterReader for single value. Model %v", scope.Struct.GetType().Name())
-				return errObj
-			}
+		if v.IsNil() {
+			return nil
 		}
+		return h.callHook(stage, req, scope.Value, scope)
 	case reflect.Slice:
 		for i := 0; i < v.Len(); i++ {
-			single := v.Index(i).Interface()
-
-			afterReader, ok := single.(HookAfterReader)
-			if ok {
-				if err := afterReader.JSONAPIAfterRead(scope); err != nil {
-					if errObj, ok := err.(*jsonapi.ErrorObject); ok {
-						return errObj
-					}
-					h.log.Error(err)
-					errObj := jsonapi.ErrInternalError.Copy()
-					errObj.Detail = fmt.Sprintf("Error while using HookAfterReader for single value. Model %v", scope.Struct.GetType().Name())
-					return errObj
+			single := v.Index(i)
+			if single.Kind() == reflect.Ptr {
+				if single.IsNil() {
+					continue
 				}
+			} else {
+				single = single.Addr()
+			}
+
+			if errObj := h.callHook(stage, req, single.Interface(), scope); errObj != nil {
+				return errObj
 			}
-			v.Index(i).Set(reflect.ValueOf(single))
 		}
 	default:
-		h.log.Errorf("Provided invalid value for HookAfterReader. Model: %v. Value: %v", scope.Struct.GetType().Name(), v)
+		h.loggerFor(req, SubsystemEndpoint).Errorf("Provided invalid value for hook: '%s'. Model: %v. Value: %v", stage, scope.Struct.GetType().Name(), v)
 		return jsonapi.ErrInternalError.Copy()
 	}
 	return nil
+}
 
+func (h *JSONAPIHandler) callHook(
+	stage HookStage,
+	req *http.Request,
+	value interface{},
+	scope *jsonapi.Scope,
+) *jsonapi.ErrorObject {
+	var err error
+	switch stage {
+	case BeforeCreate:
+		err = value.(HookBeforeCreator).JSONAPIBeforeCreate(scope)
+	case AfterCreate:
+		err = value.(HookAfterCreator).JSONAPIAfterCreate(scope)
+	case BeforeRead:
+		err = value.(HookBeforeReader).JSONAPIBeforeRead(scope)
+	case AfterRead:
+		err = value.(HookAfterReader).JSONAPIAfterRead(scope)
+	case BeforePatch:
+		err = value.(HookBeforePatcher).JSONAPIBeforePatch(scope)
+	case AfterPatch:
+		err = value.(HookAfterPatcher).JSONAPIAfterPatch(scope)
+	case BeforeDelete:
+		err = value.(HookBeforeDeleter).JSONAPIBeforeDelete(scope)
+	case AfterDelete:
+		err = value.(HookAfterDeleter).JSONAPIAfterDelete(scope)
+	}
+
+	if err != nil {
+		if errObj, ok := err.(*jsonapi.ErrorObject); ok {
+			return errObj
+		}
+		h.loggerFor(req, SubsystemEndpoint).Errorf("Unknown error type in hook: '%s' for model: %v. Error: %v", stage, scope.Struct.GetType().Name(), err)
+		return jsonapi.ErrInternalError.Copy()
+	}
+	return nil
 }
@@ -0,0 +1,66 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type HookedModel struct {
+	ID   int    `jsonapi:"primary,hooked"`
+	Name string `jsonapi:"attr,name"`
+}
+
+func (m *HookedModel) JSONAPIAfterRead(scope *jsonapi.Scope) error {
+	if m.ID == 0 {
+		return jsonapi.ErrResourceNotFound.Copy()
+	}
+	m.Name = "read"
+	return nil
+}
+
+func TestHookImplementations(t *testing.T) {
+	modelType := reflect.TypeOf(HookedModel{})
+
+	assert.True(t, hookImplementations.implements(modelType, AfterRead))
+	assert.False(t, hookImplementations.implements(modelType, BeforeRead))
+	assert.False(t, hookImplementations.implements(reflect.TypeOf(Blog{}), AfterRead))
+
+	hookImplementations.RLock()
+	_, cached := hookImplementations.types[modelType]
+	hookImplementations.RUnlock()
+	assert.True(t, cached)
+}
+
+func TestRunInterfaceHooks(t *testing.T) {
+	h := prepareHandler(defaultLanguages, &HookedModel{})
+
+	scope, err := h.Controller.NewScope(&HookedModel{})
+	assert.NoError(t, err)
+
+	// Case 1:
+	// Hook is called on each slice element
+	scope.Value = []*HookedModel{{ID: 1}, nil, {ID: 2}}
+	assert.Nil(t, h.runInterfaceHooks(AfterRead, nil, scope))
+	values := scope.Value.([]*HookedModel)
+	assert.Equal(t, "read", values[0].Name)
+	assert.Equal(t, "read", values[2].Name)
+
+	// Case 2:
+	// Hook error is returned
+	scope.Value = &HookedModel{}
+	errObj := h.runInterfaceHooks(AfterRead, nil, scope)
+	if assert.NotNil(t, errObj) {
+		assert.Equal(t, jsonapi.ErrResourceNotFound.Code, errObj.Code)
+	}
+
+	// Case 3:
+	// Not implemented hook is skipped
+	assert.Nil(t, h.runInterfaceHooks(BeforeCreate, nil, scope))
+
+	// Case 4:
+	// Nil value
+	scope.Value = nil
+	assert.NotNil(t, h.runInterfaceHooks(AfterRead, nil, scope))
+}
@@ -0,0 +1,314 @@
+package jsonapisdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	headerIdempotencyKey      = "Idempotency-Key"
+	headerIdempotencyReplayed = "Idempotent-Replayed"
+	headerAuthorization       = "Authorization"
+	maxIdempotencyKeyLength   = 255
+
+	// defaultIdempotencyWait is the default time the request waits for the request with the
+	// same idempotency key that is still processed.
+	defaultIdempotencyWait = 30 * time.Second
+
+	// idempotencyPollInterval is the interval of claiming the pending idempotency key.
+	idempotencyPollInterval = 25 * time.Millisecond
+)
+
+// IdempotentResponse is the response stored for the request with the 'Idempotency-Key' header.
+type IdempotentResponse struct {
+	// Fingerprint is the hash of the request's method, URI and body.
+	Fingerprint string
+
+	// Status is the response status code. The zero status marks the pending response of the
+	// request that is still processed.
+	Status int
+
+	// Header contains the response headers.
+	Header http.Header
+
+	// Body is the response body.
+	Body []byte
+
+	// CreatedAt is the time when the response was stored or claimed.
+	CreatedAt time.Time
+}
+
+// Pending checks if the response is the claim of the request that is still processed.
+func (r *IdempotentResponse) Pending() bool {
+	return r.Status == 0
+}
+
+// IdempotencyStore stores the responses for the requests with the 'Idempotency-Key' header.
+// The keys are claimed atomically, so that the requests with the same key are processed once,
+// even if the store is shared between the processes. The requests with the pending key are
+// serialized by claiming the key again until the response is stored or the claim is released.
+type IdempotencyStore interface {
+	// Claim atomically claims the 'key' for the request with the 'fingerprint'. If the key is
+	// not stored, the pending response is stored and the store returns true. Otherwise the
+	// store returns the response stored for the key, which might be the pending one.
+	// The expired responses are replaced by the claim.
+	Claim(key, fingerprint string) (stored *IdempotentResponse, claimed bool, err error)
+
+	// Set stores the response for the claimed 'key'.
+	Set(key string, response *IdempotentResponse) error
+
+	// Release removes the claim of the 'key', so that the request might be retried.
+	Release(key string) error
+}
+
+// MemoryIdempotencyStore is the in-memory IdempotencyStore. The responses are kept for the
+// TTL duration. The expired responses are removed by the Claim and Set methods at most once per
+// TTL. The store is not shared between the processes.
+type MemoryIdempotencyStore struct {
+	TTL time.Duration
+
+	responses map[string]*IdempotentResponse
+	swept     time.Time
+	lock      sync.Mutex
+}
+
+// NewMemoryIdempotencyStore creates the in-memory IdempotencyStore that keeps the responses
+// for the 'ttl' duration. Zero 'ttl' keeps the responses forever.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{TTL: ttl, responses: make(map[string]*IdempotentResponse)}
+}
+
+// Claim implements IdempotencyStore.
+func (m *MemoryIdempotencyStore) Claim(key, fingerprint string) (*IdempotentResponse, bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.responses == nil {
+		m.responses = make(map[string]*IdempotentResponse)
+	}
+	m.sweep()
+
+	response, ok := m.responses[key]
+	if ok && !m.expired(response) {
+		return response, false, nil
+	}
+
+	m.responses[key] = &IdempotentResponse{Fingerprint: fingerprint, CreatedAt: time.Now()}
+	return nil, true, nil
+}
+
+// Set implements IdempotencyStore.
+func (m *MemoryIdempotencyStore) Set(key string, response *IdempotentResponse) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.responses == nil {
+		m.responses = make(map[string]*IdempotentResponse)
+	}
+	m.sweep()
+
+	m.responses[key] = response
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (m *MemoryIdempotencyStore) Release(key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.responses, key)
+	return nil
+}
+
+// expired checks if the 'response' is kept longer than the TTL.
+func (m *MemoryIdempotencyStore) expired(response *IdempotentResponse) bool {
+	return m.TTL != 0 && time.Since(response.CreatedAt) > m.TTL
+}
+
+// sweep removes the expired responses. The responses are swept at most once per TTL.
+// The store's lock must be held by the caller.
+func (m *MemoryIdempotencyStore) sweep() {
+	if m.TTL == 0 || time.Since(m.swept) < m.TTL {
+		return
+	}
+
+	for key, response := range m.responses {
+		if m.expired(response) {
+			delete(m.responses, key)
+		}
+	}
+	m.swept = time.Now()
+}
+
+// idempotent wraps the unsafe endpoint's handler function, so that the requests with
+// the 'Idempotency-Key' header are processed once. The key is scoped by the request's principal,
+// method and path. The key is claimed within the handler's IdempotencyStore and the response is
+// stored and replayed for the following requests with the same key.
+// If the request with the same key is still processed, the request waits until its response is
+// stored or its claim is released. If it is still processed after the handler's IdempotencyWait,
+// the 409 Conflict error is returned.
+// If the key is reused with a different request, the 422 Unprocessable Entity error is returned.
+// The server errors are not stored, so that the request might be retried.
+func (h *JSONAPIHandler) idempotent(fn http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
+		key := req.Header.Get(headerIdempotencyKey)
+		if h.IdempotencyStore == nil || key == "" {
+			fn(rw, req)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			errObj := jsonapi.ErrInvalidHeaderValue.Copy()
+			errObj.Detail = fmt.Sprintf("The '%s' header value is too long. The maximum length is: %d.", headerIdempotencyKey, maxIdempotencyKeyLength)
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			h.logger(rw, SubsystemIdempotency).Errorf("Error while reading body for path: '%s' and method: %s. Error: %s.", req.URL.Path, req.Method, err)
+			h.MarshalErrors(rw, jsonapi.ErrInvalidInput.Copy())
+			return
+		}
+		fingerprint := requestFingerprint(req, body)
+		key = h.idempotencyScope(req, key)
+
+		/**
+
+		  IDEMPOTENCY: CLAIM KEY
+
+		*/
+		st.next("IDEMPOTENCY: CLAIM KEY")
+		stored, claimed, err := h.claimIdempotencyKey(req, key, fingerprint)
+		if err != nil {
+			h.logger(rw, SubsystemIdempotency).Errorf("Cannot claim the idempotency key: '%s'. %v", key, err)
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		if !claimed {
+			switch {
+			case stored.Fingerprint != fingerprint:
+				errObj := jsonapi.ErrInvalidInput.Copy()
+				errObj.Status = fmt.Sprintf("%d", http.StatusUnprocessableEntity)
+				errObj.Detail = fmt.Sprintf("The '%s' header value was already used with a different request.", headerIdempotencyKey)
+				h.MarshalErrors(rw, errObj)
+			case stored.Pending():
+				errObj := jsonapi.ErrInvalidInput.Copy()
+				errObj.Status = fmt.Sprintf("%d", http.StatusConflict)
+				errObj.Detail = fmt.Sprintf("The request with the same '%s' header value is being processed.", headerIdempotencyKey)
+				h.MarshalErrors(rw, errObj)
+			default:
+				copyHeader(rw.Header(), stored.Header)
+				rw.Header().Set(headerIdempotencyReplayed, "true")
+				rw.WriteHeader(stored.Status)
+				rw.Write(stored.Body)
+			}
+			return
+		}
+
+		/**
+
+		  IDEMPOTENCY: PROCESS AND STORE RESPONSE
+
+		*/
+		st.next("IDEMPOTENCY: PROCESS AND STORE RESPONSE")
+		rec := newResponseRecorder(rw, req)
+		defer func() {
+			// the claim of the panicking request is released, so that the key is not pending
+			// until it expires
+			if r := recover(); r != nil {
+				h.releaseIdempotencyKey(rw, key)
+				panic(r)
+			}
+		}()
+		fn(rec, requestWithBody(req, body))
+
+		if rec.Status() < http.StatusInternalServerError {
+			response := &IdempotentResponse{
+				Fingerprint: fingerprint,
+				Status:      rec.Status(),
+				Header:      rec.Header(),
+				Body:        rec.body.Bytes(),
+				CreatedAt:   time.Now(),
+			}
+			if err = h.IdempotencyStore.Set(key, response); err != nil {
+				h.logger(rw, SubsystemIdempotency).Errorf("Cannot store the idempotent response for the key: '%s'. %v", key, err)
+				h.releaseIdempotencyKey(rw, key)
+			}
+		} else {
+			h.releaseIdempotencyKey(rw, key)
+		}
+		rec.writeTo(rw)
+	}
+}
+
+// claimIdempotencyKey claims the 'key' within the handler's IdempotencyStore. While the key is
+// pending for the request with the same 'fingerprint', the key is claimed again every
+// idempotencyPollInterval, until the IdempotencyWait passes or the request is cancelled.
+func (h *JSONAPIHandler) claimIdempotencyKey(req *http.Request, key, fingerprint string) (*IdempotentResponse, bool, error) {
+	wait := h.IdempotencyWait
+	if wait == 0 {
+		wait = defaultIdempotencyWait
+	}
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	for {
+		stored, claimed, err := h.IdempotencyStore.Claim(key, fingerprint)
+		if err != nil || claimed || !stored.Pending() || stored.Fingerprint != fingerprint {
+			return stored, claimed, err
+		}
+
+		select {
+		case <-req.Context().Done():
+			return stored, false, nil
+		case <-timeout.C:
+			return stored, false, nil
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// idempotencyScope scopes the idempotency 'key' by the request's principal, method and path,
+// so that the keys of the different clients and endpoints do not collide.
+func (h *JSONAPIHandler) idempotencyScope(req *http.Request, key string) string {
+	principal := req.Header.Get(headerAuthorization)
+	if h.IdempotencyPrincipal != nil {
+		principal = h.IdempotencyPrincipal(req)
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{principal, req.Method, req.URL.Path, key} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// releaseIdempotencyKey releases the claim of the 'key'.
+func (h *JSONAPIHandler) releaseIdempotencyKey(rw http.ResponseWriter, key string) {
+	if err := h.IdempotencyStore.Release(key); err != nil {
+		h.logger(rw, SubsystemIdempotency).Errorf("Cannot release the idempotency key: '%s'. %v", key, err)
+	}
+}
+
+// requestFingerprint gets the hash of the request's method, URI and the 'body'.
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method))
+	hash.Write([]byte{'\n'})
+	hash.Write([]byte(req.URL.RequestURI()))
+	hash.Write([]byte{'\n'})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,160 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kucjac/jsonapi-sdk"
+	"net/http"
+	"time"
+)
+
+// DefaultTable is the default name of the table with the idempotent responses.
+const DefaultTable = "idempotency_keys"
+
+// maxClaimAttempts is the number of attempts to claim the key whose row is expired or released
+// in the meantime.
+const maxClaimAttempts = 3
+
+// SQLStore is the jsonapisdk.IdempotencyStore that keeps the responses within the SQL database
+// table. The store could be shared between the processes. The pending rows of the requests
+// that are still processed are stored with the zero status.
+type SQLStore struct {
+	db *sql.DB
+
+	// Table is the name of the table with the responses.
+	Table string
+
+	// TTL is the duration for which the responses are valid. Zero value keeps the responses forever.
+	TTL time.Duration
+
+	// DollarPlaceholders defines if the queries should use the '$1' placeholders i.e. for
+	// the postgres database. By default the '?' placeholders are used.
+	DollarPlaceholders bool
+}
+
+// New creates the SQLStore for the provided 'db'.
+func New(db *sql.DB) (*SQLStore, error) {
+	if db == nil {
+		return nil, errors.New("Nil pointer as an argument provided.")
+	}
+	return &SQLStore{db: db, Table: DefaultTable}, nil
+}
+
+// CreateTable creates the store's table if it does not exists.
+func (s *SQLStore) CreateTable() error {
+	_, err := s.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	idempotency_key VARCHAR(255) NOT NULL PRIMARY KEY,
+	fingerprint VARCHAR(64) NOT NULL,
+	status INTEGER NOT NULL,
+	header TEXT NOT NULL,
+	body TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+)`, s.Table))
+	return err
+}
+
+// Claim implements jsonapisdk.IdempotencyStore. The key is claimed by inserting the pending
+// row, so that the primary key constraint allows a single claim among all the processes.
+// The expired row for the 'key' is replaced.
+func (s *SQLStore) Claim(key, fingerprint string) (*jsonapisdk.IdempotentResponse, bool, error) {
+	var insertErr error
+	for i := 0; i < maxClaimAttempts; i++ {
+		_, insertErr = s.db.Exec(
+			fmt.Sprintf("INSERT INTO %s (idempotency_key, fingerprint, status, header, body, created_at) VALUES (%s, %s, %s, %s, %s, %s)",
+				s.Table, s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4), s.placeholder(5), s.placeholder(6)),
+			key, fingerprint, 0, "{}", "", time.Now(),
+		)
+		if insertErr == nil {
+			return nil, true, nil
+		}
+
+		// the insert fails if the key is already claimed
+		response, err := s.get(key)
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch {
+		case response == nil:
+			// the claim was released in the meantime
+			continue
+		case s.TTL > 0 && time.Since(response.CreatedAt) > s.TTL:
+			// only the expired row is deleted, so that the claim of the other process is kept
+			_, err = s.db.Exec(
+				fmt.Sprintf("DELETE FROM %s WHERE idempotency_key = %s AND created_at < %s", s.Table, s.placeholder(1), s.placeholder(2)),
+				key, time.Now().Add(-s.TTL),
+			)
+			if err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		return response, false, nil
+	}
+	return nil, false, insertErr
+}
+
+// Set implements jsonapisdk.IdempotencyStore. The pending row of the claimed 'key' is updated
+// with the response.
+func (s *SQLStore) Set(key string, response *jsonapisdk.IdempotentResponse) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(
+		fmt.Sprintf("UPDATE %s SET fingerprint = %s, status = %s, header = %s, body = %s, created_at = %s WHERE idempotency_key = %s",
+			s.Table, s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4), s.placeholder(5), s.placeholder(6)),
+		response.Fingerprint, response.Status, string(header), string(response.Body), response.CreatedAt, key,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("The idempotency key: '%s' is not claimed.", key)
+	}
+	return nil
+}
+
+// Release implements jsonapisdk.IdempotencyStore.
+func (s *SQLStore) Release(key string) error {
+	_, err := s.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE idempotency_key = %s", s.Table, s.placeholder(1)), key)
+	return err
+}
+
+// get gets the row stored for the 'key'. If there is no such row the nil response is returned.
+func (s *SQLStore) get(key string) (*jsonapisdk.IdempotentResponse, error) {
+	var (
+		response = &jsonapisdk.IdempotentResponse{}
+		header   string
+		body     string
+	)
+
+	row := s.db.QueryRow(
+		fmt.Sprintf("SELECT fingerprint, status, header, body, created_at FROM %s WHERE idempotency_key = %s", s.Table, s.placeholder(1)),
+		key,
+	)
+	err := row.Scan(&response.Fingerprint, &response.Status, &header, &body, &response.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	response.Header = make(http.Header)
+	if err = json.Unmarshal([]byte(header), &response.Header); err != nil {
+		return nil, err
+	}
+	response.Body = []byte(body)
+	return response, nil
+}
+
+func (s *SQLStore) placeholder(i int) string {
+	if s.DollarPlaceholders {
+		return fmt.Sprintf("$%d", i)
+	}
+	return "?"
+}
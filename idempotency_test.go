@@ -0,0 +1,166 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotentCreate(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	h.IdempotencyStore = NewMemoryIdempotencyStore(time.Hour)
+
+	postModel := h.ModelHandlers[reflect.TypeOf(Post{})]
+	body := `{"data":{"type":"posts","attributes":{"title":"Idempotent"}}}`
+
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Post{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*Post).ID = 4
+		})
+
+	// Case 1:
+	// The first request is processed
+	rw, req := getHttpPair("POST", "/posts", strings.NewReader(body))
+	req.Header.Set(headerIdempotencyKey, "key-1")
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode)
+	first := rw.Body.String()
+
+	// Case 2:
+	// The retry gets the stored response
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(body))
+	req.Header.Set(headerIdempotencyKey, "key-1")
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode)
+	assert.Equal(t, "true", rw.Header().Get(headerIdempotencyReplayed))
+	assert.Equal(t, first, rw.Body.String())
+	mockRepo.AssertNumberOfCalls(t, "Create", 1)
+
+	// Case 3:
+	// The key is reused with different body
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(`{"data":{"type":"posts","attributes":{"title":"Other"}}}`))
+	req.Header.Set(headerIdempotencyKey, "key-1")
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 422, rw.Result().StatusCode)
+	mockRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	h.IdempotencyStore = NewMemoryIdempotencyStore(time.Hour)
+
+	postModel := h.ModelHandlers[reflect.TypeOf(Post{})]
+	body := `{"data":{"type":"posts","attributes":{"title":"Idempotent"}}}`
+
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Post{}))).Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*Post).ID = 4
+		})
+
+	// Case 1:
+	// The same key used by the different principals
+	for _, principal := range []string{"Bearer first", "Bearer second"} {
+		rw, req := getHttpPair("POST", "/posts", strings.NewReader(body))
+		req.Header.Set(headerIdempotencyKey, "key-1")
+		req.Header.Set(headerAuthorization, principal)
+		h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+		assert.Equal(t, 201, rw.Result().StatusCode)
+		assert.Empty(t, rw.Header().Get(headerIdempotencyReplayed))
+	}
+	mockRepo.AssertNumberOfCalls(t, "Create", 2)
+
+	// Case 2:
+	// The request with the same key is still processed and its response is replayed once stored
+	rw, req := getHttpPair("POST", "/posts", strings.NewReader(body))
+	req.Header.Set(headerIdempotencyKey, "key-2")
+	key, fingerprint := h.idempotencyScope(req, "key-2"), requestFingerprint(req, []byte(body))
+	_, claimed, err := h.IdempotencyStore.Claim(key, fingerprint)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	time.AfterFunc(50*time.Millisecond, func() {
+		h.IdempotencyStore.Set(key, &IdempotentResponse{Fingerprint: fingerprint, Status: 201, CreatedAt: time.Now()})
+	})
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode)
+	assert.Equal(t, "true", rw.Header().Get(headerIdempotencyReplayed))
+	mockRepo.AssertNumberOfCalls(t, "Create", 2)
+
+	// Case 3:
+	// The request with the same key is processed longer than the IdempotencyWait
+	h.IdempotencyWait = 50 * time.Millisecond
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(body))
+	req.Header.Set(headerIdempotencyKey, "key-3")
+	_, claimed, err = h.IdempotencyStore.Claim(h.idempotencyScope(req, "key-3"), fingerprint)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 409, rw.Result().StatusCode)
+	mockRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Minute)
+
+	// Case 1:
+	// Not stored key is claimed
+	response, claimed, err := store.Claim("key", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Nil(t, response)
+
+	// Case 2:
+	// Pending key
+	response, claimed, err = store.Claim("key", "fingerprint")
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	if assert.NotNil(t, response) {
+		assert.True(t, response.Pending())
+	}
+
+	// Case 3:
+	// Stored response
+	assert.NoError(t, store.Set("key", &IdempotentResponse{Fingerprint: "fingerprint", Status: 201, CreatedAt: time.Now()}))
+	response, claimed, err = store.Claim("key", "fingerprint")
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	if assert.NotNil(t, response) {
+		assert.Equal(t, 201, response.Status)
+	}
+
+	// Case 4:
+	// Expired response
+	assert.NoError(t, store.Set("key", &IdempotentResponse{Status: 201, CreatedAt: time.Now().Add(-time.Hour)}))
+	_, claimed, err = store.Claim("key", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Case 5:
+	// Released key
+	assert.NoError(t, store.Release("key"))
+	_, claimed, err = store.Claim("key", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Case 6:
+	// The expired responses of the other keys are removed
+	store = NewMemoryIdempotencyStore(time.Minute)
+	assert.NoError(t, store.Set("expired", &IdempotentResponse{Status: 201, CreatedAt: time.Now().Add(-time.Hour)}))
+	store.swept = time.Time{}
+	_, claimed, err = store.Claim("other", "fingerprint")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.NotContains(t, store.responses, "expired")
+	assert.Contains(t, store.responses, "other")
+}
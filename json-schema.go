@@ -0,0 +1,117 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/kucjac/jsonapi"
+	"net/http"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect of the resources' schemas.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+const (
+	mediaTypeJSONSchema = "application/schema+json"
+
+	// linkDescribedBy is the name of the top-level link to the collection's JSON Schema.
+	linkDescribedBy = "describedby"
+)
+
+// The names of the collection's JSON Schema definitions.
+const (
+	schemaDefResource       = "resource"
+	schemaDefCreate         = "create"
+	schemaDefPatch          = "patch"
+	schemaDefCreateDocument = "create-document"
+	schemaDefPatchDocument  = "patch-document"
+)
+
+// JSONSchema generates the JSON Schema of the model's collection. The root schema describes
+// the resource document returned by the collection's endpoints. The '$defs' contain the schemas
+// of the resource object and of the Create and Patch request payloads, with the constraints
+// derived from the 'create' and 'patch' validation tags i.e. '/schemas/blogs#/$defs/create-document'.
+// The schema's '$id' is the collection's JSON Schema path.
+func (h *JSONAPIHandler) JSONSchema(model *ModelHandler) *Schema {
+	collection := h.modelLabel(model.ModelType)
+
+	defs := map[string]*Schema{
+		componentLinks:    {Type: "object", AdditionalProperties: true},
+		componentMeta:     {Type: "object", AdditionalProperties: true},
+		schemaDefResource: h.resourceSchema(model, "", schemaDef),
+		schemaDefCreate:   h.resourceSchema(model, createValidatorTag, schemaDef),
+		schemaDefPatch:    h.resourceSchema(model, patchValidatorTag, schemaDef),
+	}
+	defs[schemaDefCreateDocument] = objectSchema(map[string]*Schema{
+		"data": schemaDef(schemaDefCreate),
+	}, "data")
+	defs[schemaDefPatchDocument] = objectSchema(map[string]*Schema{
+		"data": schemaDef(schemaDefPatch),
+	}, "data")
+
+	resource := schemaDef(schemaDefResource)
+	schema := objectSchema(map[string]*Schema{
+		"data": {OneOf: []*Schema{
+			resource,
+			{Type: "array", Items: resource},
+			{Type: "null"},
+		}},
+		"included": {Type: "array", Items: &Schema{Type: "object"}},
+		"links":    schemaDef(componentLinks),
+		"meta":     schemaDef(componentMeta),
+	}, "data")
+
+	schema.Schema = JSONSchemaDialect
+	schema.ID = h.JSONSchemaPath(model)
+	schema.Title = collection
+	schema.Description = "The JSON:API document of the '" + collection + "' collection."
+	schema.Defs = defs
+	return schema
+}
+
+// ServeJSONSchema returns the http.HandlerFunc that responds with the JSON Schema of the model's
+// collection. The handler should be served at the collection's JSONSchemaPath.
+func (h *JSONAPIHandler) ServeJSONSchema(model *ModelHandler) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", mediaTypeJSONSchema)
+		if err := json.NewEncoder(rw).Encode(h.JSONSchema(model)); err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Cannot marshal the JSON Schema for model: '%s'. %v", model.ModelType, err)
+		}
+	}
+}
+
+// JSONSchemaPath gets the path of the model's collection JSON Schema.
+func (h *JSONAPIHandler) JSONSchemaPath(model *ModelHandler) string {
+	return h.Controller.APIURLBase + "/schemas/" + h.modelLabel(model.ModelType)
+}
+
+// setDescribedByLink sets the top-level 'describedby' link to the JSON Schema of the scope's
+// collection if the handler's DescribedBy is enabled.
+func (h *JSONAPIHandler) setDescribedByLink(scope *jsonapi.Scope, payload jsonapi.Payloader) {
+	if !h.DescribedBy {
+		return
+	}
+
+	model, ok := h.ModelHandlers[scope.Struct.GetType()]
+	if !ok {
+		return
+	}
+
+	var links **jsonapi.Links
+	switch p := payload.(type) {
+	case *jsonapi.OnePayload:
+		links = &p.Links
+	case *jsonapi.ManyPayload:
+		links = &p.Links
+	default:
+		return
+	}
+
+	if *links == nil {
+		*links = &jsonapi.Links{}
+	}
+	(**links)[linkDescribedBy] = h.JSONSchemaPath(model)
+}
+
+// schemaDef creates the schema referencing the definition within the '$defs'.
+func schemaDef(name string) *Schema {
+	return schemaRef("#/$defs/" + name)
+}
@@ -0,0 +1,84 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	h := prepareHandler(defaultLanguages, append(blogModels, &Article{})...)
+	model := h.ModelHandlers[reflect.TypeOf(Article{})]
+
+	schema := h.JSONSchema(model)
+	assert.Equal(t, JSONSchemaDialect, schema.Schema)
+	assert.Equal(t, h.Controller.APIURLBase+"/schemas/articles", schema.ID)
+
+	// Case 1:
+	// The create payload with the validation constraints
+	create := schema.Defs[schemaDefCreate]
+	if assert.NotNil(t, create) {
+		attributes := create.Properties["attributes"]
+		assert.Equal(t, []string{"title"}, attributes.Required)
+		if assert.NotNil(t, attributes.Properties["title"].MinLength) {
+			assert.Equal(t, 3, *attributes.Properties["title"].MinLength)
+		}
+		assert.Equal(t, []interface{}{"draft", "published"}, attributes.Properties["status"].Enum)
+		assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, attributes.Properties["rating"].Enum)
+		assert.NotContains(t, attributes.Properties, "secret")
+	}
+	assert.Equal(t, "#/$defs/"+schemaDefCreate, schema.Defs[schemaDefCreateDocument].Properties["data"].Ref)
+
+	// Case 2:
+	// The schema references only its own definitions
+	data, err := json.Marshal(schema)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(data), "#/components/")
+	}
+
+	// Case 3:
+	// Serve the schema
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", h.JSONSchemaPath(model), nil)
+	h.ServeJSONSchema(model)(rw, req)
+	assert.Equal(t, 200, rw.Code)
+	assert.Equal(t, mediaTypeJSONSchema, rw.Header().Get("Content-Type"))
+}
+
+func TestDescribedByLink(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	doc := struct {
+		Links map[string]interface{} `json:"links"`
+	}{}
+
+	mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Return(nil).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value = &Blog{ID: 1, Lang: h.SupportedLanguages[0].String()}
+		})
+
+	// Case 1:
+	// The link is disabled by default
+	rw, req := getHttpPair("GET", "/blogs/1", nil)
+	h.Get(model, model.Get).ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Code)
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	assert.NotContains(t, doc.Links, linkDescribedBy)
+
+	// Case 2:
+	// The link references the collection's schema
+	h.DescribedBy = true
+	rw, req = getHttpPair("GET", "/blogs/1", nil)
+	h.Get(model, model.Get).ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Code)
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	assert.Equal(t, h.JSONSchemaPath(model), doc.Links[linkDescribedBy])
+}
@@ -0,0 +1,301 @@
+package jsonapisdk
+
+import (
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"golang.org/x/text/language"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// metaLanguage is the resource's meta key that contains the served language.
+const metaLanguage = "language"
+
+// SetLanguages sets the languages supported by the i18n model. The first language is the
+// model's default language, used as the last fallback.
+func (m *ModelHandler) SetLanguages(languages ...language.Tag) {
+	m.Languages = languages
+	m.languageMatcher = language.NewMatcher(languages)
+}
+
+// SetLanguageFallback sets the languages used in the provided order, if the resource has no
+// translation in the 'tag' language. The fallbacks are also used for the 'tag' sublanguages
+// i.e. the fallbacks for 'de' are used for the 'de-AT'.
+func (m *ModelHandler) SetLanguageFallback(tag language.Tag, fallbacks ...language.Tag) {
+	if m.LanguageFallbacks == nil {
+		m.LanguageFallbacks = make(map[language.Tag][]language.Tag)
+	}
+	m.LanguageFallbacks[tag] = fallbacks
+}
+
+// languageChain gets the languages in which the model's resources are searched for the
+// matched 'tag'. The chain starts with the 'tag', followed by its supported parent languages,
+// the model's fallbacks for the 'tag' and the default language.
+func (h *JSONAPIHandler) languageChain(model *ModelHandler, tag language.Tag) []language.Tag {
+	supported := h.SupportedLanguages
+	if len(model.Languages) > 0 {
+		supported = model.Languages
+	}
+
+	chain := []language.Tag{tag}
+	add := func(tags ...language.Tag) {
+		for _, t := range tags {
+			if !containsTag(chain, t) {
+				chain = append(chain, t)
+			}
+		}
+	}
+
+	for parent := tag.Parent(); !parent.IsRoot(); parent = parent.Parent() {
+		if containsTag(supported, parent) {
+			add(parent)
+		}
+	}
+
+	for t := tag; ; t = t.Parent() {
+		if fallbacks, ok := model.LanguageFallbacks[t]; ok {
+			add(fallbacks...)
+			break
+		}
+		if t.IsRoot() {
+			break
+		}
+	}
+
+	if len(supported) > 0 {
+		add(supported[0])
+	}
+	return chain
+}
+
+// getTranslation gets the scope's resource in the first language from the 'languages' chain
+// that the resource is translated into.
+func (h *JSONAPIHandler) getTranslation(
+	repo Repository,
+	scope *jsonapi.Scope,
+	languages []language.Tag,
+) (dbErr *unidb.Error) {
+	if len(languages) == 0 {
+		return repo.Get(scope)
+	}
+
+	for _, lang := range languages {
+		scope.SetLanguageFilter(lang.String())
+		if dbErr = repo.Get(scope); dbErr == nil || !dbErr.Compare(unidb.ErrNoResult) {
+			return dbErr
+		}
+	}
+	return dbErr
+}
+
+// listTranslations lists the scope's resources in the first language from the 'languages' chain
+// that the resources are translated into. The repositories that implement
+// the TranslationRepository select the translations within their queries. Otherwise
+// the translations are listed without the pagination, selected by the selectTranslations and
+// paginated afterwards, so that the pages contain the whole selected resources.
+func listTranslations(
+	repo Repository,
+	scope *jsonapi.Scope,
+	languages []language.Tag,
+) *unidb.Error {
+	if len(languages) < 2 || scope.LanguageFilters == nil {
+		return repo.List(scope)
+	}
+
+	if tr, ok := asTranslationRepository(repo); ok {
+		tags := make([]string, len(languages))
+		for i, lang := range languages {
+			tags[i] = lang.String()
+		}
+		return tr.ListTranslations(scope, tags)
+	}
+
+	pagination := scope.Pagination
+	scope.Pagination = nil
+	dbErr := repo.List(scope)
+	scope.Pagination = pagination
+	if dbErr != nil {
+		return dbErr
+	}
+
+	selectTranslations(scope, languages)
+	if pagination != nil {
+		limit, offset := pagination.GetLimitOffset()
+		scope.Value = paginateValues(scope.Value, limit, offset)
+	}
+	return nil
+}
+
+// paginateValues gets the page of the 'values' slice with the 'limit' and 'offset'. The zero
+// limit gets all the values after the offset.
+func paginateValues(values interface{}, limit, offset int) interface{} {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice {
+		return values
+	}
+
+	start := offset
+	if start > v.Len() || start < 0 {
+		start = v.Len()
+	}
+	end := v.Len()
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	return v.Slice(start, end).Interface()
+}
+
+// modelLanguageChain gets the language chain for the model with the 'modelType'.
+func (h *JSONAPIHandler) modelLanguageChain(modelType reflect.Type, tag language.Tag) []language.Tag {
+	model, ok := h.ModelHandlers[modelType]
+	if !ok || model == nil {
+		model = &ModelHandler{ModelType: modelType}
+	}
+	return h.languageChain(model, tag)
+}
+
+// setLanguageChainFilter sets the scope's language filter for all the 'languages'.
+func setLanguageChainFilter(scope *jsonapi.Scope, languages []language.Tag) {
+	values := make([]interface{}, len(languages))
+	for i, lang := range languages {
+		values[i] = lang.String()
+	}
+	scope.SetLanguageFilter(values...)
+}
+
+// selectTranslations keeps single translation of each listed resource. The translation in the
+// language that comes first in the 'languages' chain is selected. The order of the resources
+// is preserved. It is used for the repositories that do not implement the TranslationRepository.
+func selectTranslations(scope *jsonapi.Scope, languages []language.Tag) {
+	if len(languages) < 2 || scope.LanguageFilters == nil || scope.Value == nil {
+		return
+	}
+
+	v := reflect.ValueOf(scope.Value)
+	if v.Kind() != reflect.Slice {
+		return
+	}
+
+	primIndex := scope.Struct.GetPrimaryField().GetFieldIndex()
+	langIndex := scope.LanguageFilters.GetFieldIndex()
+	rank := func(single reflect.Value) int {
+		lang := fmt.Sprint(single.Field(langIndex).Interface())
+		for i, tag := range languages {
+			if strings.EqualFold(tag.String(), lang) {
+				return i
+			}
+		}
+		return len(languages)
+	}
+
+	selected := make(map[interface{}]int)
+	result := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		single := item
+		if single.Kind() == reflect.Ptr {
+			if single.IsNil() {
+				continue
+			}
+			single = single.Elem()
+		}
+
+		primary := single.Field(primIndex).Interface()
+		j, ok := selected[primary]
+		if !ok {
+			selected[primary] = result.Len()
+			result = reflect.Append(result, item)
+			continue
+		}
+
+		current := result.Index(j)
+		if current.Kind() == reflect.Ptr {
+			current = current.Elem()
+		}
+		if rank(single) < rank(current) {
+			result.Index(j).Set(item)
+		}
+	}
+	scope.Value = result.Interface()
+}
+
+// servedLanguages gets the languages of the scope's resources keyed by the resources' ids.
+func servedLanguages(scope *jsonapi.Scope) map[string]string {
+	if !scope.UseI18n() || scope.LanguageFilters == nil {
+		return nil
+	}
+
+	primIndex := scope.Struct.GetPrimaryField().GetFieldIndex()
+	langIndex := scope.LanguageFilters.GetFieldIndex()
+	served := make(map[string]string)
+	forEachScopeValue(scope, func(single reflect.Value) {
+		if lang := fmt.Sprint(single.Field(langIndex).Interface()); lang != "" {
+			served[fmt.Sprint(single.Field(primIndex).Interface())] = lang
+		}
+	})
+	return served
+}
+
+// setServedContentLanguage sets the 'Content-Language' header to the languages of the scope's
+// resources.
+func setServedContentLanguage(rw http.ResponseWriter, scope *jsonapi.Scope) {
+	var languages []string
+	for _, lang := range servedLanguages(scope) {
+		if !containsString(languages, lang) {
+			languages = append(languages, lang)
+		}
+	}
+
+	if len(languages) > 0 {
+		rw.Header().Set(headerContentLanguage, strings.Join(languages, ", "))
+	}
+}
+
+// setLanguageMeta sets the served language within the meta of the payload's primary resources.
+func setLanguageMeta(scope *jsonapi.Scope, payload jsonapi.Payloader) {
+	served := servedLanguages(scope)
+	if len(served) == 0 {
+		return
+	}
+
+	var nodes []*jsonapi.Node
+	switch p := payload.(type) {
+	case *jsonapi.OnePayload:
+		if p.Data != nil {
+			nodes = append(nodes, p.Data)
+		}
+	case *jsonapi.ManyPayload:
+		nodes = p.Data
+	}
+
+	for _, node := range nodes {
+		lang, ok := served[node.ID]
+		if !ok {
+			continue
+		}
+		if node.Meta == nil {
+			node.Meta = &jsonapi.Meta{}
+		}
+		(*node.Meta)[metaLanguage] = lang
+	}
+}
+
+func containsTag(tags []language.Tag, tag language.Tag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/text/language"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLanguageChain(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	deAT := language.MustParse("de-AT")
+	model.SetLanguages(language.English, language.German, deAT, language.Polish)
+
+	assert.Equal(t, []language.Tag{deAT, language.German, language.English}, h.languageChain(model, deAT))
+	assert.Equal(t, []language.Tag{language.Polish, language.English}, h.languageChain(model, language.Polish))
+
+	model.SetLanguageFallback(language.German, language.Polish)
+	assert.Equal(t, []language.Tag{deAT, language.German, language.Polish, language.English}, h.languageChain(model, deAT))
+}
+
+func TestGetModelLanguage(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+	model.SetLanguages(language.German, language.English)
+
+	req := httptest.NewRequest("GET", "/blogs", nil)
+	req.Header.Set(headerAcceptLanguage, "de-AT")
+
+	tag, ok := h.getModelLanguage(model, req, httptest.NewRecorder())
+	assert.True(t, ok)
+	assert.Equal(t, language.German, tag)
+
+	// The model without languages uses the handler's languages
+	tag, ok = h.getModelLanguage(h.ModelHandlers[reflect.TypeOf(Post{})], req, httptest.NewRecorder())
+	assert.True(t, ok)
+	assert.Contains(t, h.SupportedLanguages, tag)
+}
+
+func TestSelectTranslations(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	scope, err := h.Controller.NewScope(&Blog{})
+	assert.NoError(t, err)
+
+	languages := []language.Tag{language.German, language.English}
+	setLanguageChainFilter(scope, languages)
+	scope.Value = []*Blog{
+		{ID: 1, Lang: "en"},
+		{ID: 2, Lang: "en"},
+		{ID: 1, Lang: "de"},
+		{ID: 3, Lang: "de"},
+	}
+
+	selectTranslations(scope, languages)
+
+	blogs, ok := scope.Value.([]*Blog)
+	if assert.True(t, ok) && assert.Len(t, blogs, 3) {
+		assert.Equal(t, &Blog{ID: 1, Lang: "de"}, blogs[0])
+		assert.Equal(t, &Blog{ID: 2, Lang: "en"}, blogs[1])
+		assert.Equal(t, &Blog{ID: 3, Lang: "de"}, blogs[2])
+	}
+
+	served := servedLanguages(scope)
+	assert.Equal(t, map[string]string{"1": "de", "2": "en", "3": "de"}, served)
+}
+
+type mockTranslationRepository struct {
+	MockRepository
+}
+
+func (m *mockTranslationRepository) ListTranslations(scope *jsonapi.Scope, languages []string) *unidb.Error {
+	args := m.Called(scope, languages)
+	if dbErr, ok := args.Get(0).(*unidb.Error); ok {
+		return dbErr
+	}
+	return nil
+}
+
+func TestListTranslationsFallback(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	languages := []language.Tag{language.German, language.English}
+
+	newScope := func() *jsonapi.Scope {
+		scope, err := h.Controller.NewScope(&Blog{})
+		assert.NoError(t, err)
+		scope.NewValueMany()
+		setLanguageChainFilter(scope, languages)
+		return scope
+	}
+
+	// Case 1:
+	// The translation repository selects the translations within its query
+	trRepo := &mockTranslationRepository{}
+	trRepo.On("ListTranslations", mock.Anything, []string{"de", "en"}).Once().Return(nil)
+	assert.Nil(t, listTranslations(trRepo, newScope(), languages))
+	trRepo.AssertNotCalled(t, "List", mock.Anything)
+
+	// Case 2:
+	// The translations listed by the repository are selected by the handler
+	mockRepo := &MockRepository{}
+	mockRepo.On("List", mock.Anything).Once().Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*jsonapi.Scope)
+		arg.Value = []*Blog{{ID: 1, Lang: "en"}, {ID: 1, Lang: "de"}}
+	})
+
+	scope := newScope()
+	assert.Nil(t, listTranslations(mockRepo, scope, languages))
+	assert.Equal(t, []*Blog{{ID: 1, Lang: "de"}}, scope.Value)
+
+	// Case 3:
+	// Single language is listed with the repository's List
+	trRepo.On("List", mock.Anything).Once().Return(nil)
+	assert.Nil(t, listTranslations(trRepo, newScope(), languages[:1]))
+	trRepo.AssertNumberOfCalls(t, "ListTranslations", 1)
+
+	// Case 4:
+	// The paginated translations are listed whole and paginated after the selection
+	_, req := getHttpPair("GET", "/blogs?page[limit]=2&page[offset]=1", nil)
+	scope, errs, err := h.Controller.BuildScopeList(req, &Blog{})
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	setLanguageChainFilter(scope, languages)
+	pagination := scope.Pagination
+
+	fallbackRepo := &mockTranslationRepository{}
+	fallbackRepo.On("List", mock.Anything).Once().Return(nil).Run(func(args mock.Arguments) {
+		arg := args.Get(0).(*jsonapi.Scope)
+		assert.Nil(t, arg.Pagination)
+		arg.Value = []*Blog{
+			{ID: 1, Lang: "en"}, {ID: 1, Lang: "de"},
+			{ID: 2, Lang: "en"},
+			{ID: 3, Lang: "de"}, {ID: 3, Lang: "en"},
+			{ID: 4, Lang: "en"},
+		}
+	})
+
+	assert.Nil(t, listTranslations(&fallbackRepo.MockRepository, scope, languages))
+	assert.Equal(t, []*Blog{{ID: 2, Lang: "en"}, {ID: 3, Lang: "de"}}, scope.Value)
+	assert.Equal(t, pagination, scope.Pagination)
+}
+
+func TestPaginateValues(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5}
+	assert.Equal(t, []int{2, 3}, paginateValues(values, 2, 1))
+	assert.Equal(t, []int{4, 5}, paginateValues(values, 10, 3))
+	assert.Equal(t, []int{3, 4, 5}, paginateValues(values, 0, 2))
+	assert.Equal(t, []int{}, paginateValues(values, 2, 10))
+}
@@ -1,6 +1,8 @@
 package jsonapisdk
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/kucjac/jsonapi"
 	"golang.org/x/text/language"
@@ -12,23 +14,185 @@ import (
 const (
 	headerAcceptLanguage  = "Accept-Language"
 	headerContentLanguage = "Content-Language"
+	headerVary            = "Vary"
 	annotationSeperator   = ","
 )
 
+// LanguagePrecedence defines which of the request's language sources is preferred, if both
+// the 'Accept-Language' header and the language query parameter are provided.
+type LanguagePrecedence int
+
+const (
+	// LanguageQueryFirst prefers the language query parameter over the 'Accept-Language'
+	// header. The header languages are used as the following preferences.
+	LanguageQueryFirst LanguagePrecedence = iota
+
+	// LanguageHeaderFirst uses the language query parameter only if the request has no
+	// 'Accept-Language' header.
+	LanguageHeaderFirst
+)
+
+type languageQueryCtxKey struct{}
+
+// GetLanguage gets the handler's supported language that matches the request's
+// 'Accept-Language' header or the language query parameter.
 func (h *JSONAPIHandler) GetLanguage(
 	req *http.Request,
 	rw http.ResponseWriter,
 ) (tag language.Tag, ok bool) {
+	return h.getModelLanguage(nil, req, rw)
+}
+
+// getModelLanguage gets the model's supported language that matches the request's languages.
+// If the model does not define its languages, the handler's supported languages are used.
+func (h *JSONAPIHandler) getModelLanguage(
+	model *ModelHandler,
+	req *http.Request,
+	rw http.ResponseWriter,
+) (tag language.Tag, ok bool) {
+	tags, errObj := h.requestLanguages(req)
+	if errObj != nil {
+		h.MarshalErrors(rw, errObj)
+		return
+	}
+
+	supported, matcher := h.SupportedLanguages, h.LanguageMatcher
+	if model != nil && model.languageMatcher != nil {
+		supported, matcher = model.Languages, model.languageMatcher
+	}
+
+	var index int
+	tag, index, _ = matcher.Match(tags...)
+	if index < len(supported) {
+		// use the supported tag without the matcher's extensions
+		tag = supported[index]
+	}
+	h.HeaderContentLanguage(rw, tag)
+	addVary(rw, headerAcceptLanguage)
+	return tag, true
+}
+
+// requestLanguages gets the languages preferred by the request. The languages are taken from
+// the 'Accept-Language' header and the handler's LanguageQueryParameter in the order defined by
+// the handler's LanguagePrecedence.
+func (h *JSONAPIHandler) requestLanguages(req *http.Request) ([]language.Tag, *jsonapi.ErrorObject) {
 	tags, _, err := language.ParseAcceptLanguage(req.Header.Get(headerAcceptLanguage))
 	if err != nil {
 		errObj := jsonapi.ErrInvalidHeaderValue.Copy()
 		errObj.Detail = err.Error()
-		h.MarshalErrors(rw, errObj)
-		return
+		return nil, errObj
 	}
-	tag, _, _ = h.LanguageMatcher.Match(tags...)
-	rw.Header().Add(headerContentLanguage, tag.String())
-	return tag, true
+
+	query, _ := req.Context().Value(languageQueryCtxKey{}).(string)
+	if query == "" {
+		query = req.URL.Query().Get(h.LanguageQueryParameter)
+	}
+
+	if h.LanguageQueryParameter == "" || query == "" {
+		return tags, nil
+	}
+
+	queryTag, err := language.Parse(query)
+	if err != nil {
+		errObj := jsonapi.ErrInvalidInput.Copy()
+		errObj.Detail = fmt.Sprintf("Provided invalid language: '%s'.", query)
+		SetErrorSource(errObj, &ErrorSource{Parameter: h.LanguageQueryParameter})
+		return nil, errObj
+	}
+
+	if h.LanguagePrecedence == LanguageHeaderFirst && len(tags) > 0 {
+		return tags, nil
+	}
+	return append([]language.Tag{queryTag}, tags...), nil
+}
+
+// withLanguageQuery moves the handler's language query parameter from the request's URL into
+// its context, so that the query is not parsed as the jsonapi query parameter.
+func (h *JSONAPIHandler) withLanguageQuery(req *http.Request) *http.Request {
+	if h.LanguageQueryParameter == "" {
+		return req
+	}
+
+	query := req.URL.Query()
+	lang, ok := query[h.LanguageQueryParameter]
+	if !ok {
+		return req
+	}
+	query.Del(h.LanguageQueryParameter)
+
+	r := req.WithContext(context.WithValue(req.Context(), languageQueryCtxKey{}, lang[0]))
+	u := *req.URL
+	u.RawQuery = query.Encode()
+	r.URL = &u
+	return r
+}
+
+// addVary adds the 'header' to the response's 'Vary' header if it is not already there.
+func addVary(rw http.ResponseWriter, header string) {
+	for _, value := range rw.Header()[headerVary] {
+		for _, vary := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(vary), header) {
+				return
+			}
+		}
+	}
+	rw.Header().Add(headerVary, header)
+}
+
+// languageResource is the resource of the languages endpoint.
+type languageResource struct {
+	Type       string             `json:"type"`
+	ID         string             `json:"id"`
+	Attributes languageAttributes `json:"attributes"`
+}
+
+type languageAttributes struct {
+	// Name is the language name displayed in the request's language
+	Name string `json:"name"`
+
+	// NativeName is the language name displayed in the language itself
+	NativeName string `json:"native-name"`
+
+	// Default defines if the language is the default one
+	Default bool `json:"default"`
+}
+
+// ListLanguages returns a http.HandlerFunc that lists the handler's supported languages. The
+// language names are displayed in the language matched for the request.
+func (h *JSONAPIHandler) ListLanguages() http.HandlerFunc {
+	return h.wrapHandler(nil, List, func(rw http.ResponseWriter, req *http.Request) {
+		tag, ok := h.GetLanguage(req, rw)
+		if !ok {
+			return
+		}
+
+		namer := display.Tags(tag)
+		if namer == nil {
+			namer = display.Tags(language.English)
+		}
+
+		doc := struct {
+			Data []*languageResource `json:"data"`
+		}{Data: make([]*languageResource, len(h.SupportedLanguages))}
+
+		for i, lang := range h.SupportedLanguages {
+			doc.Data[i] = &languageResource{
+				Type: "languages",
+				ID:   lang.String(),
+				Attributes: languageAttributes{
+					Name:       namer.Name(lang),
+					NativeName: display.Self.Name(lang),
+					Default:    i == 0,
+				},
+			}
+		}
+
+		SetContentType(rw)
+		rw.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(rw).Encode(doc); err != nil {
+			h.logger(rw, SubsystemLanguage).Errorf("Error while writing the languages response. %v", err)
+		}
+	})
 }
 
 func (h *JSONAPIHandler) DisplaySupportedLanguages() []string {
@@ -48,7 +212,7 @@ func (h *JSONAPIHandler) CheckValueLanguage(
 ) (langtag language.Tag, ok bool) {
 	lang, err := scope.GetLangtagValue()
 	if err != nil {
-		h.log.Errorf("Error while getting langtag from scope: '%v', Error: %v", scope.Struct.GetType(), err)
+		h.logger(rw, SubsystemLanguage).Errorf("Error while getting langtag from scope: '%v', Error: %v", scope.Struct.GetType(), err)
 		h.MarshalInternalError(rw)
 		return
 	}
@@ -89,7 +253,7 @@ func (h *JSONAPIHandler) CheckValueLanguage(
 
 	err = scope.SetLangtagValue(langtag.String())
 	if err != nil {
-		h.log.Error(err)
+		h.logger(rw, SubsystemLanguage).Error(err)
 		h.MarshalInternalError(rw)
 		return
 	}
@@ -100,5 +264,5 @@ func (h *JSONAPIHandler) CheckValueLanguage(
 // HeaderContentLanguage sets the response Header 'Content-Language' to the lang tag provided in
 // argument.
 func (h *JSONAPIHandler) HeaderContentLanguage(rw http.ResponseWriter, langtag language.Tag) {
-	rw.Header().Add(headerAcceptLanguage, langtag.String())
+	rw.Header().Set(headerContentLanguage, langtag.String())
 }
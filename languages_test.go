@@ -1,6 +1,7 @@
 package jsonapisdk
 
 import (
+	"encoding/json"
 	"github.com/kucjac/jsonapi"
 	"github.com/kucjac/uni-logger"
 	"github.com/stretchr/testify/assert"
@@ -109,3 +110,63 @@ func TestHandlerCheckLanguage(t *testing.T) {
 	assert.False(t, ok)
 
 }
+
+func TestGetLanguageQueryParameter(t *testing.T) {
+	h := prepareHandler([]language.Tag{language.English, language.Polish, language.German})
+	h.LanguageQueryParameter = "lang"
+
+	// Case 1:
+	// The query parameter takes precedence
+	req := httptest.NewRequest("GET", "/blogs?lang=de", nil)
+	req.Header.Add(headerAcceptLanguage, "pl")
+	rw := httptest.NewRecorder()
+
+	lang, ok := h.GetLanguage(req, rw)
+	assert.True(t, ok)
+	assert.Equal(t, language.German, lang)
+	assert.Equal(t, "de", rw.Header().Get(headerContentLanguage))
+	assert.Equal(t, headerAcceptLanguage, rw.Header().Get(headerVary))
+
+	// Case 2:
+	// The header takes precedence
+	h.LanguagePrecedence = LanguageHeaderFirst
+	lang, ok = h.GetLanguage(req, httptest.NewRecorder())
+	assert.True(t, ok)
+	assert.Equal(t, language.Polish, lang)
+
+	// Case 3:
+	// The query is moved into the context
+	req = h.withLanguageQuery(httptest.NewRequest("GET", "/blogs?lang=de&include=posts", nil))
+	assert.Equal(t, "include=posts", req.URL.RawQuery)
+	lang, ok = h.GetLanguage(req, httptest.NewRecorder())
+	assert.True(t, ok)
+	assert.Equal(t, language.German, lang)
+
+	// Case 4:
+	// Invalid query language
+	rw = httptest.NewRecorder()
+	_, ok = h.GetLanguage(httptest.NewRequest("GET", "/blogs?lang=invalid-language-tag", nil), rw)
+	assert.False(t, ok)
+	assert.Equal(t, 400, rw.Code)
+}
+
+func TestListLanguages(t *testing.T) {
+	h := prepareHandler([]language.Tag{language.English, language.Polish})
+
+	req := httptest.NewRequest("GET", "/languages", nil)
+	req.Header.Add(headerAcceptLanguage, "pl")
+	rw := httptest.NewRecorder()
+
+	h.ListLanguages().ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Code)
+
+	doc := struct {
+		Data []*languageResource `json:"data"`
+	}{}
+	if assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc)) && assert.Len(t, doc.Data, 2) {
+		assert.Equal(t, "en", doc.Data[0].ID)
+		assert.True(t, doc.Data[0].Attributes.Default)
+		assert.Equal(t, "angielski", doc.Data[0].Attributes.Name)
+		assert.Equal(t, "polski", doc.Data[1].Attributes.NativeName)
+	}
+}
@@ -0,0 +1,247 @@
+package jsonapisdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"net/http"
+	"strings"
+)
+
+const (
+	headerRequestID = "X-Request-ID"
+
+	// maxRequestIDLength is the maximum length of the client provided request id.
+	maxRequestIDLength = 128
+)
+
+// LogLevel is the level of the handler's log entries.
+type LogLevel int
+
+// The log levels in the increasing severity order.
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarning
+	LogError
+	LogOff
+)
+
+// DefaultLogLevel is the log level of the subsystems without the level set.
+const DefaultLogLevel = LogInfo
+
+// Subsystem is the part of the handler that writes the log entries. The log level is
+// configured per subsystem by the JSONAPIHandler's SetLogLevel method.
+type Subsystem string
+
+// The handler's subsystems.
+const (
+	SubsystemEndpoint    Subsystem = "endpoint"
+	SubsystemErrors      Subsystem = "errors"
+	SubsystemFilters     Subsystem = "filters"
+	SubsystemInclude     Subsystem = "include"
+	SubsystemValidation  Subsystem = "validation"
+	SubsystemDatabase    Subsystem = "database"
+	SubsystemIdempotency Subsystem = "idempotency"
+	SubsystemLanguage    Subsystem = "language"
+)
+
+// SetLogLevel sets the log 'level' for the handler's 'subsystem'.
+func (h *JSONAPIHandler) SetLogLevel(subsystem Subsystem, level LogLevel) {
+	if h.LogLevels == nil {
+		h.LogLevels = make(map[Subsystem]LogLevel)
+	}
+	h.LogLevels[subsystem] = level
+}
+
+// logLevel gets the log level of the 'subsystem'.
+func (h *JSONAPIHandler) logLevel(subsystem Subsystem) LogLevel {
+	if level, ok := h.LogLevels[subsystem]; ok {
+		return level
+	}
+	return DefaultLogLevel
+}
+
+type loggerCtxKey struct{}
+
+// logFields are the request's fields included in each of its log entries.
+type logFields struct {
+	requestID string
+	model     string
+	endpoint  EndpointType
+}
+
+// withRequestLogger sets the log fields of the request handled by the model's endpoint in the
+// request's context. The request id is taken from the 'X-Request-ID' header or generated and
+// written into the response header. The header value that is too long or contains
+// characters other than letters, digits and '-', '_', '.', ':' is replaced by the generated id.
+func (h *JSONAPIHandler) withRequestLogger(
+	model *ModelHandler,
+	endpoint EndpointType,
+	rw http.ResponseWriter,
+	req *http.Request,
+) *http.Request {
+	requestID := req.Header.Get(headerRequestID)
+	if !validRequestID(requestID) {
+		requestID = newID()
+	}
+	rw.Header().Set(headerRequestID, requestID)
+
+	fields := &logFields{requestID: requestID, endpoint: endpoint}
+	if model != nil {
+		fields.model = h.modelLabel(model.ModelType)
+	}
+	return req.WithContext(context.WithValue(req.Context(), loggerCtxKey{}, fields))
+}
+
+// requestLogger writes the structured log entries of the handler's subsystem. The entries of
+// the logger bound to the request contain the request id, model and the endpoint.
+type requestLogger struct {
+	h         *JSONAPIHandler
+	subsystem Subsystem
+	fields    *logFields
+}
+
+// validRequestID checks if the client provided request 'id' is not empty, is not too long and
+// contains only the allowed characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// logger gets the subsystem's logger for the request bound to the 'rw'. The 'rw' might be nil
+// for the entries not related to any request.
+func (h *JSONAPIHandler) logger(rw http.ResponseWriter, subsystem Subsystem) *requestLogger {
+	return h.loggerFor(requestOf(rw), subsystem)
+}
+
+// loggerFor gets the subsystem's logger for the 'req'. The 'req' might be nil for the entries
+// not related to any request.
+func (h *JSONAPIHandler) loggerFor(req *http.Request, subsystem Subsystem) *requestLogger {
+	l := &requestLogger{h: h, subsystem: subsystem}
+	if req != nil {
+		l.fields, _ = req.Context().Value(loggerCtxKey{}).(*logFields)
+	}
+	return l
+}
+
+// requestID gets the id of the logger's request.
+func (l *requestLogger) requestID() string {
+	if l.fields == nil {
+		return ""
+	}
+	return l.fields.requestID
+}
+
+func (l *requestLogger) entry(msg string, fields ...interface{}) string {
+	var b strings.Builder
+	if l.fields != nil {
+		fmt.Fprintf(&b, "request_id=%q ", l.fields.requestID)
+		if l.fields.model != "" {
+			fmt.Fprintf(&b, "model=%s ", l.fields.model)
+		}
+		fmt.Fprintf(&b, "endpoint=%q ", l.fields.endpoint.String())
+	}
+	fmt.Fprintf(&b, "subsystem=%s", l.subsystem)
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", fields[i], fields[i+1])
+	}
+	fmt.Fprintf(&b, " msg=%q", msg)
+	return b.String()
+}
+
+func (l *requestLogger) log(level LogLevel, msg string, fields ...interface{}) {
+	if l.h.log == nil || level < l.h.logLevel(l.subsystem) {
+		return
+	}
+
+	entry := l.entry(msg, fields...)
+	switch level {
+	case LogDebug:
+		l.h.log.Debug(entry)
+	case LogInfo:
+		l.h.log.Info(entry)
+	case LogWarning:
+		l.h.log.Warning(entry)
+	default:
+		l.h.log.Error(entry)
+	}
+}
+
+// Debugf writes the debug entry.
+func (l *requestLogger) Debugf(format string, args ...interface{}) {
+	l.log(LogDebug, fmt.Sprintf(format, args...))
+}
+
+// Infof writes the info entry.
+func (l *requestLogger) Infof(format string, args ...interface{}) {
+	l.log(LogInfo, fmt.Sprintf(format, args...))
+}
+
+// Warningf writes the warning entry.
+func (l *requestLogger) Warningf(format string, args ...interface{}) {
+	l.log(LogWarning, fmt.Sprintf(format, args...))
+}
+
+// Warning writes the warning entry.
+func (l *requestLogger) Warning(args ...interface{}) {
+	l.log(LogWarning, fmt.Sprint(args...))
+}
+
+// Errorf writes the error entry.
+func (l *requestLogger) Errorf(format string, args ...interface{}) {
+	l.log(LogError, fmt.Sprintf(format, args...))
+}
+
+// Error writes the error entry.
+func (l *requestLogger) Error(args ...interface{}) {
+	l.log(LogError, fmt.Sprint(args...))
+}
+
+// identifyErrors gets the copies of the error objects written to the 'rw' with their ids set
+// and logs them, so that the error seen by the client could be matched with the log entry.
+// The provided error objects are not modified, as they might be shared i.e. the package level
+// errors. The server errors are logged with the error level.
+func (h *JSONAPIHandler) identifyErrors(rw http.ResponseWriter, errors ...*jsonapi.ErrorObject) []*jsonapi.ErrorObject {
+	l := h.logger(rw, SubsystemErrors)
+	identified := make([]*jsonapi.ErrorObject, len(errors))
+	for i, errObj := range errors {
+		if errObj == nil {
+			continue
+		}
+		errObj = errObj.Copy()
+		if errObj.ID == "" {
+			errObj.ID = newID()
+		}
+		identified[i] = errObj
+
+		level := LogInfo
+		if strings.HasPrefix(errObj.Status, "5") {
+			level = LogError
+		}
+		l.log(level, errObj.Title, "error_id", errObj.ID, "status", errObj.Status, "code", errObj.Code)
+	}
+	return identified
+}
+
+// newID generates the random identifier for the requests and the error objects.
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
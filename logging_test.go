@@ -0,0 +1,110 @@
+package jsonapisdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/kucjac/uni-logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogLevels(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	buf := &bytes.Buffer{}
+	h.log = unilogger.MustGetLoggerWrapper(unilogger.NewBasicLogger(buf, "", 0))
+
+	h.SetLogLevel(SubsystemFilters, LogOff)
+	h.logger(nil, SubsystemFilters).Errorf("hidden")
+	h.logger(nil, SubsystemEndpoint).Errorf("shown")
+	h.logger(nil, SubsystemEndpoint).Debugf("debug")
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "subsystem=endpoint")
+	assert.Contains(t, buf.String(), "shown")
+	assert.NotContains(t, buf.String(), "debug")
+}
+
+func TestErrorIDs(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	buf := &bytes.Buffer{}
+	h.log = unilogger.MustGetLoggerWrapper(unilogger.NewBasicLogger(buf, "", 0))
+
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	rw, req := getHttpPair("GET", "/blogs/1", nil)
+	req.Header.Set(headerRequestID, "request-1")
+	mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(unidb.ErrInternalError.New())
+	h.Get(model, model.Get).ServeHTTP(rw, req)
+	assert.Equal(t, 500, rw.Code)
+	assert.Equal(t, "request-1", rw.Header().Get(headerRequestID))
+
+	doc := struct {
+		Errors []*jsonapi.ErrorObject `json:"errors"`
+	}{}
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	if assert.Len(t, doc.Errors, 1) {
+		assert.NotEmpty(t, doc.Errors[0].ID)
+		assert.Contains(t, buf.String(), "error_id="+doc.Errors[0].ID)
+	}
+	assert.Contains(t, buf.String(), `request_id="request-1"`)
+	assert.Contains(t, buf.String(), "model=blogs")
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	for _, requestID := range []string{"request id", strings.Repeat("a", maxRequestIDLength+1), "id\nforged=entry"} {
+		rw, req := getHttpPair("GET", "/blogs/1", nil)
+		req.Header.Set(headerRequestID, requestID)
+		mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(unidb.ErrNoResult.New())
+		h.Get(model, model.Get).ServeHTTP(rw, req)
+
+		generated := rw.Header().Get(headerRequestID)
+		assert.NotEqual(t, requestID, generated)
+		assert.True(t, validRequestID(generated))
+	}
+}
+
+func TestIdentifyErrorsCopies(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+
+	errObj := jsonapi.ErrResourceNotFound.Copy()
+	identified := h.identifyErrors(nil, errObj, nil)
+	if assert.Len(t, identified, 2) {
+		assert.NotEmpty(t, identified[0].ID)
+		assert.Nil(t, identified[1])
+	}
+	assert.Empty(t, errObj.ID)
+}
+
+func TestHookFuncLogRequestID(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	buf := &bytes.Buffer{}
+	h.log = unilogger.MustGetLoggerWrapper(unilogger.NewBasicLogger(buf, "", 0))
+
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+	assert.NoError(t, model.AddHookFunc(BeforeRead, 0, func(req *http.Request, scope *jsonapi.Scope) error {
+		return errors.New("hook failure")
+	}))
+
+	rw, req := getHttpPair("GET", "/blogs/1", nil)
+	req.Header.Set(headerRequestID, "request-2")
+	h.Get(model, model.Get).ServeHTTP(rw, req)
+	assert.Equal(t, 500, rw.Code)
+	assert.Contains(t, buf.String(), `request_id="request-2"`)
+	assert.Contains(t, buf.String(), "hook failure")
+}
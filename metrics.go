@@ -0,0 +1,347 @@
+package jsonapisdk
+
+import (
+	"context"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// The outcomes of the database errors mapping.
+const (
+	// DBErrorMapper is the outcome of the error mapped by the ErrorManager's mapper function.
+	DBErrorMapper = "mapper"
+
+	// DBErrorMap is the outcome of the error mapped by the ErrorManager's prototype map.
+	DBErrorMap = "map"
+
+	// DBErrorRuleMatched is the outcome of the error mapped by the database error rule.
+	DBErrorRuleMatched = "rule"
+
+	// DBErrorUnmapped is the outcome of the error that could not be mapped.
+	DBErrorUnmapped = "unmapped"
+)
+
+// MetricsCollector collects the metrics of the handler's endpoints, repositories and the
+// database errors mapping. The collector is set by the JSONAPIHandler's SetMetrics method.
+// The model is the model's collection name.
+type MetricsCollector interface {
+	// ObserveRequest observes the request handled by the model's endpoint. The 'errorCode' is
+	// the code of the first error object written to the response.
+	ObserveRequest(model string, endpoint EndpointType, status int, errorCode string, duration time.Duration)
+
+	// ObserveRepository observes the call of the repository 'method' for the model.
+	// The 'dbError' is the title of the database error prototype or empty if no error occurred.
+	ObserveRepository(model string, method string, dbError string, duration time.Duration)
+
+	// ObserveDBError observes the mapping of the database error into the error object.
+	ObserveDBError(dbError string, outcome string)
+}
+
+// SetMetrics sets the metrics collector for the handler. The handler's endpoints and the
+// repositories taken by the GetRepositoryByType method are instrumented.
+func (h *JSONAPIHandler) SetMetrics(metrics MetricsCollector) {
+	h.Metrics = metrics
+}
+
+// observeRequest observes the request handled by the model's endpoint.
+func (h *JSONAPIHandler) observeRequest(
+	model *ModelHandler,
+	endpoint EndpointType,
+	rw *responseWriter,
+	start time.Time,
+) {
+	if h.Metrics == nil {
+		return
+	}
+
+	var modelType reflect.Type
+	if model != nil {
+		modelType = model.ModelType
+	}
+	h.Metrics.ObserveRequest(h.modelLabel(modelType), endpoint, rw.Status(), rw.errorCode, time.Since(start))
+}
+
+// observeDBError observes the database error mapping outcome.
+func (h *JSONAPIHandler) observeDBError(dbErr *unidb.Error, outcome string) {
+	if h.Metrics == nil {
+		return
+	}
+	h.Metrics.ObserveDBError(dbErrorLabel(dbErr), outcome)
+}
+
+// modelLabel gets the collection name of the model.
+func (h *JSONAPIHandler) modelLabel(model reflect.Type) string {
+	if model == nil {
+		return ""
+	}
+
+	if mStruct := h.Controller.Models.Get(model); mStruct != nil {
+		return mStruct.GetCollectionType()
+	}
+	return model.Name()
+}
+
+// dbErrorLabel gets the title of the database error prototype.
+func dbErrorLabel(dbErr *unidb.Error) string {
+	if dbErr == nil {
+		return ""
+	}
+
+	if proto, err := dbErr.GetPrototype(); err == nil {
+		return proto.Title
+	}
+	return dbErr.Title
+}
+
+// errorCodeWriter is the http.ResponseWriter that keeps the code of the first written
+// error object.
+type errorCodeWriter interface {
+	setErrorCode(code string)
+}
+
+// setErrorCode sets the error code for the 'rw' if it keeps the error codes.
+func setErrorCode(rw http.ResponseWriter, errs ...*jsonapi.ErrorObject) {
+	w, ok := rw.(errorCodeWriter)
+	if !ok || len(errs) == 0 || errs[0] == nil {
+		return
+	}
+	w.setErrorCode(errs[0].Code)
+}
+
+// instrumentedRepository is the Repository that observes the calls of the wrapped repository.
+// If the repository is bound to the request's response writer, the calls are traced within the
+// writer's current trace context.
+type instrumentedRepository struct {
+	repo    Repository
+	model   string
+	metrics MetricsCollector
+	tracer  trace.Tracer
+	rw      http.ResponseWriter
+}
+
+// instrumentRepository wraps the model's repository with the handler's metrics collector.
+func (h *JSONAPIHandler) instrumentRepository(model reflect.Type, repo Repository) Repository {
+	if h.Metrics == nil || repo == nil {
+		return repo
+	}
+
+	if _, ok := repo.(*instrumentedRepository); ok {
+		return repo
+	}
+	return &instrumentedRepository{repo: repo, model: h.modelLabel(model), metrics: h.Metrics}
+}
+
+// repositoryCall is the observed call of the repository method.
+type repositoryCall struct {
+	method string
+	scope  *jsonapi.Scope
+	start  time.Time
+	ctx    context.Context
+	span   trace.Span
+}
+
+func (r *instrumentedRepository) call(method string, scope *jsonapi.Scope) *repositoryCall {
+	c := &repositoryCall{method: method, scope: scope, start: time.Now(), ctx: traceContext(r.rw)}
+	if c.ctx != nil && r.tracer != nil {
+		c.span = startRepositorySpan(c.ctx, r.tracer, method, scope)
+	}
+	return c
+}
+
+func (r *instrumentedRepository) observe(c *repositoryCall, dbErr **unidb.Error) {
+	if c.span != nil {
+		endRepositorySpan(c.span, c.scope, *dbErr)
+	}
+
+	if r.metrics != nil {
+		r.metrics.ObserveRepository(r.model, c.method, dbErrorLabel(*dbErr), time.Since(c.start))
+	}
+}
+
+// bind binds the wrapped repository to the call's trace context.
+func (r *instrumentedRepository) bind(c *repositoryCall) Repository {
+	if cr, ok := r.repo.(ContextRepository); ok && c.ctx != nil {
+		return cr.WithContext(c.ctx)
+	}
+	return r.repo
+}
+
+// Create implements Repository.
+func (r *instrumentedRepository) Create(scope *jsonapi.Scope) (dbErr *unidb.Error) {
+	c := r.call("create", scope)
+	defer r.observe(c, &dbErr)
+	return r.bind(c).Create(scope)
+}
+
+// Get implements Repository.
+func (r *instrumentedRepository) Get(scope *jsonapi.Scope) (dbErr *unidb.Error) {
+	c := r.call("get", scope)
+	defer r.observe(c, &dbErr)
+	return r.bind(c).Get(scope)
+}
+
+// List implements Repository.
+func (r *instrumentedRepository) List(scope *jsonapi.Scope) (dbErr *unidb.Error) {
+	c := r.call("list", scope)
+	defer r.observe(c, &dbErr)
+	return r.bind(c).List(scope)
+}
+
+// Patch implements Repository.
+func (r *instrumentedRepository) Patch(scope *jsonapi.Scope) (dbErr *unidb.Error) {
+	c := r.call("patch", scope)
+	defer r.observe(c, &dbErr)
+	return r.bind(c).Patch(scope)
+}
+
+// Delete implements Repository.
+func (r *instrumentedRepository) Delete(scope *jsonapi.Scope) (dbErr *unidb.Error) {
+	c := r.call("delete", scope)
+	defer r.observe(c, &dbErr)
+	return r.bind(c).Delete(scope)
+}
+
+// instrumentedSoftDeleteRepository observes the calls of the wrapped SoftDeleteRepository.
+type instrumentedSoftDeleteRepository struct {
+	*instrumentedRepository
+	soft SoftDeleteRepository
+}
+
+// bindSoft binds the wrapped soft delete repository to the call's trace context.
+func (r *instrumentedSoftDeleteRepository) bindSoft(c *repositoryCall) SoftDeleteRepository {
+	if soft, ok := r.bind(c).(SoftDeleteRepository); ok {
+		return soft
+	}
+	return r.soft
+}
+
+// SoftDelete implements SoftDeleteRepository.
+func (r *instrumentedSoftDeleteRepository) SoftDelete(
+	scope *jsonapi.Scope,
+	field *jsonapi.StructField,
+) (dbErr *unidb.Error) {
+	c := r.call("soft_delete", scope)
+	defer r.observe(c, &dbErr)
+	return r.bindSoft(c).SoftDelete(scope, field)
+}
+
+// Restore implements SoftDeleteRepository.
+func (r *instrumentedSoftDeleteRepository) Restore(
+	scope *jsonapi.Scope,
+	field *jsonapi.StructField,
+) (dbErr *unidb.Error) {
+	c := r.call("restore", scope)
+	defer r.observe(c, &dbErr)
+	return r.bindSoft(c).Restore(scope, field)
+}
+
+// Unscoped implements SoftDeleteRepository.
+func (r *instrumentedSoftDeleteRepository) Unscoped() Repository {
+	return &instrumentedRepository{
+		repo:    r.soft.Unscoped(),
+		model:   r.model,
+		metrics: r.metrics,
+		tracer:  r.tracer,
+		rw:      r.rw,
+	}
+}
+
+// unwrapRepository gets the repository wrapped by the instrumented repository.
+func unwrapRepository(repo Repository) Repository {
+	if r, ok := repo.(*instrumentedRepository); ok {
+		return r.repo
+	}
+	return repo
+}
+
+// asSoftDeleteRepository gets the SoftDeleteRepository implementation of the 'repo'.
+// The instrumented repository is checked by its wrapped repository.
+func asSoftDeleteRepository(repo Repository) (SoftDeleteRepository, bool) {
+	r, instrumented := repo.(*instrumentedRepository)
+	if !instrumented {
+		soft, ok := repo.(SoftDeleteRepository)
+		return soft, ok
+	}
+
+	soft, ok := r.repo.(SoftDeleteRepository)
+	if !ok {
+		return nil, false
+	}
+	return &instrumentedSoftDeleteRepository{instrumentedRepository: r, soft: soft}, true
+}
+
+// asLastModifiedRepository gets the LastModifiedRepository implementation of the 'repo'.
+func asLastModifiedRepository(repo Repository) (LastModifiedRepository, bool) {
+	r, instrumented := repo.(*instrumentedRepository)
+	if !instrumented {
+		lm, ok := repo.(LastModifiedRepository)
+		return lm, ok
+	}
+
+	lm, ok := r.repo.(LastModifiedRepository)
+	if !ok {
+		return nil, false
+	}
+	return &instrumentedLastModifiedRepository{instrumentedRepository: r, lm: lm}, true
+}
+
+// instrumentedLastModifiedRepository observes the calls of the wrapped LastModifiedRepository.
+type instrumentedLastModifiedRepository struct {
+	*instrumentedRepository
+	lm LastModifiedRepository
+}
+
+// LastModified implements LastModifiedRepository.
+func (r *instrumentedLastModifiedRepository) LastModified(
+	scope *jsonapi.Scope,
+	field string,
+) (t time.Time, dbErr *unidb.Error) {
+	c := r.call("last_modified", scope)
+	defer r.observe(c, &dbErr)
+
+	lm, ok := r.bind(c).(LastModifiedRepository)
+	if !ok {
+		lm = r.lm
+	}
+	return lm.LastModified(scope, field)
+}
+
+// asTranslationRepository gets the TranslationRepository implementation of the 'repo'.
+func asTranslationRepository(repo Repository) (TranslationRepository, bool) {
+	r, instrumented := repo.(*instrumentedRepository)
+	if !instrumented {
+		tr, ok := repo.(TranslationRepository)
+		return tr, ok
+	}
+
+	tr, ok := r.repo.(TranslationRepository)
+	if !ok {
+		return nil, false
+	}
+	return &instrumentedTranslationRepository{instrumentedRepository: r, tr: tr}, true
+}
+
+// instrumentedTranslationRepository observes the calls of the wrapped TranslationRepository.
+type instrumentedTranslationRepository struct {
+	*instrumentedRepository
+	tr TranslationRepository
+}
+
+// ListTranslations implements TranslationRepository.
+func (r *instrumentedTranslationRepository) ListTranslations(
+	scope *jsonapi.Scope,
+	languages []string,
+) (dbErr *unidb.Error) {
+	c := r.call("list_translations", scope)
+	defer r.observe(c, &dbErr)
+
+	tr, ok := r.bind(c).(TranslationRepository)
+	if !ok {
+		tr = r.tr
+	}
+	return tr.ListTranslations(scope, languages)
+}
@@ -0,0 +1,113 @@
+package promjsonapi
+
+import (
+	"github.com/kucjac/jsonapi-sdk"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultNamespace is the default namespace of the collector's metrics.
+const DefaultNamespace = "jsonapi"
+
+// Collector is the jsonapisdk.MetricsCollector that exposes the metrics for prometheus.
+// The collector implements prometheus.Collector, so that it could be registered within any
+// prometheus.Registerer i.e.:
+//
+//	collector := promjsonapi.New(promjsonapi.DefaultNamespace)
+//	prometheus.MustRegister(collector)
+//	handler.SetMetrics(collector)
+type Collector struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	repositoryCalls    *prometheus.CounterVec
+	repositoryDuration *prometheus.HistogramVec
+
+	dbErrors *prometheus.CounterVec
+}
+
+// New creates the Collector with the metrics within the provided 'namespace'.
+func New(namespace string) *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "The number of the requests handled by the model's endpoints.",
+		}, []string{"model", "endpoint", "status", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "The duration of the requests handled by the model's endpoints.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"model", "endpoint"}),
+		repositoryCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_calls_total",
+			Help:      "The number of the model's repository calls.",
+		}, []string{"model", "method", "error"}),
+		repositoryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_call_duration_seconds",
+			Help:      "The duration of the model's repository calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"model", "method"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_errors_total",
+			Help:      "The number of the database errors by their mapping outcome.",
+		}, []string{"error", "outcome"}),
+	}
+}
+
+// ObserveRequest implements jsonapisdk.MetricsCollector.
+func (c *Collector) ObserveRequest(
+	model string,
+	endpoint jsonapisdk.EndpointType,
+	status int,
+	errorCode string,
+	duration time.Duration,
+) {
+	c.requests.WithLabelValues(model, endpoint.String(), strconv.Itoa(status), errorCode).Inc()
+	c.requestDuration.WithLabelValues(model, endpoint.String()).Observe(duration.Seconds())
+}
+
+// ObserveRepository implements jsonapisdk.MetricsCollector.
+func (c *Collector) ObserveRepository(model string, method string, dbError string, duration time.Duration) {
+	c.repositoryCalls.WithLabelValues(model, method, dbError).Inc()
+	c.repositoryDuration.WithLabelValues(model, method).Observe(duration.Seconds())
+}
+
+// ObserveDBError implements jsonapisdk.MetricsCollector.
+func (c *Collector) ObserveDBError(dbError string, outcome string) {
+	c.dbErrors.WithLabelValues(dbError, outcome).Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.requestDuration.Describe(ch)
+	c.repositoryCalls.Describe(ch)
+	c.repositoryDuration.Describe(ch)
+	c.dbErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.requestDuration.Collect(ch)
+	c.repositoryCalls.Collect(ch)
+	c.repositoryDuration.Collect(ch)
+	c.dbErrors.Collect(ch)
+}
+
+// Handler gets the http.Handler that exposes the metrics gathered by the 'gatherer'. If the
+// 'gatherer' is nil, the prometheus.DefaultGatherer is used.
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
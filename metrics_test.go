@@ -0,0 +1,95 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type observedRequest struct {
+	model     string
+	endpoint  EndpointType
+	status    int
+	errorCode string
+}
+
+type observedRepositoryCall struct {
+	model, method, dbError string
+}
+
+type testMetrics struct {
+	requests  []observedRequest
+	repoCalls []observedRepositoryCall
+	dbErrors  []string
+}
+
+func (m *testMetrics) ObserveRequest(model string, endpoint EndpointType, status int, errorCode string, duration time.Duration) {
+	m.requests = append(m.requests, observedRequest{model, endpoint, status, errorCode})
+}
+
+func (m *testMetrics) ObserveRepository(model string, method string, dbError string, duration time.Duration) {
+	m.repoCalls = append(m.repoCalls, observedRepositoryCall{model, method, dbError})
+}
+
+func (m *testMetrics) ObserveDBError(dbError string, outcome string) {
+	m.dbErrors = append(m.dbErrors, outcome)
+}
+
+func TestHandlerMetrics(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	metrics := &testMetrics{}
+	h.SetMetrics(metrics)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	// Case 1:
+	// Correct get
+	rw, req := getHttpPair("GET", "/blogs/1", nil)
+	mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value = &Blog{ID: 1, Lang: "en"}
+		})
+	h.Get(model, model.Get).ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Code)
+
+	if assert.Len(t, metrics.requests, 1) {
+		assert.Equal(t, observedRequest{"blogs", Get, 200, ""}, metrics.requests[0])
+	}
+	if assert.Len(t, metrics.repoCalls, 1) {
+		assert.Equal(t, observedRepositoryCall{"blogs", "get", ""}, metrics.repoCalls[0])
+	}
+
+	// Case 2:
+	// Repository error
+	rw, req = getHttpPair("GET", "/blogs/1", nil)
+	mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Return(unidb.ErrNoResult.New())
+	h.Get(model, model.Get).ServeHTTP(rw, req)
+	assert.Equal(t, 404, rw.Code)
+
+	if assert.Len(t, metrics.requests, 2) {
+		assert.Equal(t, observedRequest{"blogs", Get, 404, jsonapi.ErrResourceNotFound.Code}, metrics.requests[1])
+	}
+	assert.Equal(t, unidb.ErrNoResult.Title, metrics.repoCalls[len(metrics.repoCalls)-1].dbError)
+	assert.Equal(t, []string{DBErrorMap}, metrics.dbErrors)
+}
+
+func TestAsSoftDeleteRepository(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	h.SetDefaultRepo(&MockRepository{})
+	h.SetMetrics(&testMetrics{})
+
+	repo := h.GetRepositoryByType(reflect.TypeOf(Blog{}))
+	_, ok := repo.(*instrumentedRepository)
+	assert.True(t, ok)
+
+	_, ok = asSoftDeleteRepository(repo)
+	_, isSoft := interface{}(&MockRepository{}).(SoftDeleteRepository)
+	assert.Equal(t, isSoft, ok)
+	assert.Equal(t, &MockRepository{}, unwrapRepository(repo))
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/kucjac/jsonapi"
+	"golang.org/x/text/language"
+	"gopkg.in/go-playground/validator.v9"
 	"net/http"
 	"reflect"
 )
@@ -30,8 +32,65 @@ type ModelHandler struct {
 
 	Delete *Endpoint
 
+	// Restore is the endpoint that brings back the soft deleted resources.
+	Restore *Endpoint
+
+	// Translations enables the translations endpoints for the i18n model. The translations are
+	// listed, created, patched and deleted by the model's List, Create, Patch and Delete
+	// endpoints at the '/:id/translations' and '/:id/translations/:language' paths.
+	Translations bool
+
 	// Repository defines the repository for the provided model
 	Repository Repository
+
+	// LastModifiedField is the name of the model's time.Time struct field that contains the
+	// last modification time i.e. 'UpdatedAt'. If set, the endpoints with ConditionalGet use
+	// it for the 'Last-Modified' header and the weak ETags.
+	LastModifiedField string
+
+	// SoftDelete if set defines that the model's resources are only marked as trashed by the
+	// Delete endpoint.
+	SoftDelete *SoftDelete
+
+	// CreateValidator and PatchValidator are the model specific validators. If nil the
+	// handler's validators are used. The validation functions are registered by the
+	// RegisterValidation method.
+	CreateValidator *validator.Validate
+	PatchValidator  *validator.Validate
+
+	// ClientIDPolicy defines if the client is allowed to provide the resource's id on create.
+	// By default the client ids are allowed.
+	ClientIDPolicy ClientIDPolicy
+
+	// IDGenerator if set generates the id for the created resources that were not provided with
+	// the client id. The ids are generated before the BeforeCreate hooks. The model's primary
+	// field must be a string i.e. NewUUID, NewULID or NewKSUID.
+	IDGenerator IDGenerator
+
+	// Languages are the languages supported by the i18n model. The first language is the
+	// model's default. If empty, the handler's SupportedLanguages are used. The languages are
+	// set by the SetLanguages method.
+	Languages []language.Tag
+
+	// LanguageFallbacks defines the languages used by the Get and List endpoints, if the resource
+	// has no translation in the matched language i.e. 'de-AT' -> 'de' -> 'en'.
+	LanguageFallbacks map[language.Tag][]language.Tag
+
+	// ValidateRelationships defines if the relationships provided in the Create and Patch
+	// request bodies should be checked if they exists and are available for the client.
+	ValidateRelationships bool
+
+	// scopeValidators are the struct level validation functions for the Create and Patch
+	scopeValidators map[EndpointType][]ScopeValidatorFunc
+
+	// languageMatcher matches the request's language with the model's Languages
+	languageMatcher language.Matcher
+
+	// dbErrorRules are the database error rules for the model's constraints
+	dbErrorRules []*DBErrorRule
+
+	// hooks are the hook functions registered for all the model's endpoints
+	hooks hookFuncs
 }
 
 type ModelPresetGetter interface {
@@ -69,6 +128,8 @@ func NewModelHandler(
 			m.Patch = &Endpoint{Type: endpoint}
 		case Delete:
 			m.Delete = &Endpoint{Type: endpoint}
+		case Restore:
+			m.Restore = &Endpoint{Type: endpoint}
 		default:
 			err = fmt.Errorf("Provided invalid endpoint type for model: %s", m.ModelType.Name())
 			return
@@ -140,6 +201,8 @@ func (m *ModelHandler) AddMiddlewareFunctions(endpoint EndpointType, middlewares
 		modelEndpoint = m.Patch
 	case Delete:
 		modelEndpoint = m.Delete
+	case Restore:
+		modelEndpoint = m.Restore
 	}
 
 	if modelEndpoint == nil {
@@ -219,6 +282,17 @@ func (m *ModelHandler) addPresetPair(
 			m.Delete.PresetPairs = append(m.Delete.PresetPairs, presetPair)
 		}
 
+	case Restore:
+		if m.Restore == nil {
+			return nilEndpoint("Restore")
+		}
+
+		if check {
+			m.Restore.PrecheckPairs = append(m.Restore.PrecheckPairs, presetPair)
+		} else {
+			m.Restore.PresetPairs = append(m.Restore.PresetPairs, presetPair)
+		}
+
 	default:
 		return errors.New("Endpoint not specified.")
 	}
@@ -249,6 +323,8 @@ func (m *ModelHandler) changeEndpoint(endpoint *Endpoint, replace bool) error {
 		m.PatchRelationship = endpoint
 	case Delete:
 		m.Delete = endpoint
+	case Restore:
+		m.Restore = endpoint
 	default:
 		return IErrInvalidModelEndpoint
 	}
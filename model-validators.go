@@ -0,0 +1,373 @@
+package jsonapisdk
+
+import (
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"gopkg.in/go-playground/validator.v9"
+	"net/http"
+	"reflect"
+)
+
+const validationTagExists = "exists"
+
+// ScopeValidatorFunc is the struct level validation function for the Create and Patch endpoints.
+// It is called with the unmarshaled scope after the field validation passed. The returned error
+// objects are written to the response. The source of the error could be set using
+// the SetErrorSource function.
+type ScopeValidatorFunc func(req *http.Request, scope *jsonapi.Scope) []*jsonapi.ErrorObject
+
+// RegisterValidation registers the validation function with provided 'tag' for the model's
+// Create or Patch validator. If the model does not have its own validator, a new one is created.
+// The model's validator is used instead of the handler's one.
+func (m *ModelHandler) RegisterValidation(
+	endpoint EndpointType,
+	tag string,
+	fn validator.Func,
+) error {
+	var v *validator.Validate
+	switch endpoint {
+	case Create:
+		if m.CreateValidator == nil {
+			m.CreateValidator = newValidator(createValidatorTag)
+		}
+		v = m.CreateValidator
+	case Patch:
+		if m.PatchValidator == nil {
+			m.PatchValidator = newValidator(patchValidatorTag)
+		}
+		v = m.PatchValidator
+	default:
+		return fmt.Errorf("Validation may be registered only for the Create and Patch endpoints. Provided: '%s'", endpoint)
+	}
+	return v.RegisterValidation(tag, fn)
+}
+
+// AddScopeValidator adds the struct level validation function for the Create or Patch endpoint.
+func (m *ModelHandler) AddScopeValidator(endpoint EndpointType, fn ScopeValidatorFunc) error {
+	if endpoint != Create && endpoint != Patch {
+		return fmt.Errorf("Scope validator may be added only for the Create and Patch endpoints. Provided: '%s'", endpoint)
+	}
+
+	if m.scopeValidators == nil {
+		m.scopeValidators = make(map[EndpointType][]ScopeValidatorFunc)
+	}
+	m.scopeValidators[endpoint] = append(m.scopeValidators[endpoint], fn)
+	return nil
+}
+
+// validatorFor gets the model's validator for the endpoint or the handler's one if the model
+// does not define its own.
+func (h *JSONAPIHandler) validatorFor(model *ModelHandler, endpoint EndpointType) *validator.Validate {
+	var v *validator.Validate
+	switch endpoint {
+	case Create:
+		v = model.CreateValidator
+		if v == nil {
+			return h.CreateValidator
+		}
+	case Patch:
+		v = model.PatchValidator
+		if v == nil {
+			return h.PatchValidator
+		}
+	}
+
+	if err := h.translateValidator(v); err != nil {
+		h.logger(nil, SubsystemValidation).Errorf("Cannot register validation translations for model: '%v'. %v", model.ModelType, err)
+	}
+	return v
+}
+
+// ValidateScope validates the scope's value for the Create or Patch endpoint.
+// At first the fields are validated by the model's validator. The Patch endpoint validates only
+// the fields within the scope's fieldset. Then the scope validators are called. If the model has
+// ValidateRelationships flag set, the provided relationships are checked if they exists.
+// If the scope is not valid the errors are written to the response and the function returns false.
+func (h *JSONAPIHandler) ValidateScope(
+	model *ModelHandler,
+	endpoint EndpointType,
+	scope *jsonapi.Scope,
+	rw http.ResponseWriter,
+	req *http.Request,
+) bool {
+	var err error
+	if endpoint == Patch {
+		// only the provided fields are validated
+		err = h.validatorFor(model, endpoint).StructPartial(scope.Value, fieldsetStructNames(scope)...)
+	} else {
+		err = h.validatorFor(model, endpoint).Struct(scope.Value)
+	}
+
+	if err != nil {
+		h.handleValidateError(model, err, rw, req)
+		return false
+	}
+
+	var errs []*jsonapi.ErrorObject
+	for _, scopeValidator := range model.scopeValidators[endpoint] {
+		errs = append(errs, scopeValidator(req, scope)...)
+	}
+
+	if len(errs) > 0 {
+		h.MarshalErrors(rw, errs...)
+		return false
+	}
+
+	if model.ValidateRelationships {
+		return h.validateRelationshipsExist(scope, rw, req)
+	}
+	return true
+}
+
+// validateStoredValues checks the 'provided' fields with the 'immutable' and 'writeonce' patch
+// tags against their stored values. The stored values are taken from the repository with the
+// filters of the prechecked patch 'scope', so that the resources out of the prechecks are not
+// read. The immutable field could be provided only with its stored value. The write once field
+// could be patched if its stored value is zero or equal to the provided one.
+// The fields preset by the server are not within the 'provided' fieldset and are not checked.
+func (h *JSONAPIHandler) validateStoredValues(
+	model *ModelHandler,
+	scope *jsonapi.Scope,
+	provided map[string]*jsonapi.StructField,
+	repo Repository,
+	rw http.ResponseWriter,
+	req *http.Request,
+) bool {
+	checked := make(map[string]*jsonapi.StructField)
+	tags := make(map[string]string)
+	for name, field := range provided {
+		structField := model.ModelType.Field(field.GetFieldIndex())
+		for _, tag := range []string{validationTagImmutable, validationTagWriteOnce} {
+			if hasValidationTag(structField, patchValidatorTag, tag) {
+				checked[name] = field
+				tags[name] = tag
+			}
+		}
+	}
+
+	if len(checked) == 0 {
+		return true
+	}
+
+	stored, err := h.Controller.NewScope(reflect.New(model.ModelType).Interface())
+	if err != nil {
+		h.logger(rw, SubsystemValidation).Errorf("Cannot create scope for the model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(rw)
+		return false
+	}
+	stored.PrimaryFilters = scope.PrimaryFilters
+	stored.AttributeFilters = scope.AttributeFilters
+	stored.RelationshipFilters = scope.RelationshipFilters
+	stored.LanguageFilters = scope.LanguageFilters
+	stored.Fieldset = checked
+
+	if dbErr := repo.Get(stored); dbErr != nil {
+		if dbErr.Compare(unidb.ErrNoResult) {
+			// the not found error is returned by the patch
+			return true
+		}
+		h.manageDBError(rw, stored, dbErr)
+		return false
+	}
+
+	v := reflect.ValueOf(scope.Value).Elem()
+	storedValue := reflect.ValueOf(stored.Value).Elem()
+
+	trans := h.validationTranslator(req)
+
+	var errs []*jsonapi.ErrorObject
+	for name, field := range checked {
+		storedField := storedValue.Field(field.GetFieldIndex())
+		providedField := v.Field(field.GetFieldIndex())
+
+		tag := tags[name]
+		if tag == validationTagWriteOnce && reflect.DeepEqual(storedField.Interface(), reflect.Zero(field.GetFieldType()).Interface()) {
+			continue
+		}
+
+		if field.IsRelationship() {
+			primIndex := h.Controller.Models.Get(field.GetRelatedModelType()).GetPrimaryField().GetFieldIndex()
+			if fmt.Sprint(relationshipPrimaries(storedField, primIndex)) == fmt.Sprint(relationshipPrimaries(providedField, primIndex)) {
+				continue
+			}
+		} else if reflect.DeepEqual(storedField.Interface(), providedField.Interface()) {
+			continue
+		}
+
+		errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
+		errObj.Code = ValidationErrorCode(tag)
+		if tag == validationTagImmutable {
+			errObj.Detail = fmt.Sprintf("%s cannot be changed", name)
+		} else {
+			errObj.Detail = fmt.Sprintf("%s is already set and cannot be changed", name)
+		}
+		if trans != nil {
+			if translated, err := trans.T(tag, name); err == nil {
+				errObj.Detail = translated
+			}
+		}
+		errObj.Meta = &map[string]interface{}{validationMetaTag: tag}
+
+		pointer := pointerAttributes + name
+		if field.IsRelationship() {
+			pointer = pointerRelationships + name
+		}
+		SetErrorSource(errObj, &ErrorSource{Pointer: pointer})
+		errs = append(errs, errObj)
+	}
+
+	if len(errs) > 0 {
+		h.MarshalErrors(rw, errs...)
+		return false
+	}
+	return true
+}
+
+// validateRelationshipsExist checks if all the relationships provided in the scope's value
+// exists within the related models' repositories. The related model's List endpoint prechecks are
+// applied, so that the client could not set relationships to the resources it could not list.
+func (h *JSONAPIHandler) validateRelationshipsExist(
+	scope *jsonapi.Scope,
+	rw http.ResponseWriter,
+	req *http.Request,
+) bool {
+	v := reflect.ValueOf(scope.Value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		h.logger(rw, SubsystemValidation).Errorf("Invalid scope value for relationship validation. Model: '%v'.", scope.Struct.GetType())
+		h.MarshalInternalError(rw)
+		return false
+	}
+	v = v.Elem()
+
+	var errs []*jsonapi.ErrorObject
+	for _, name := range scope.Struct.ListRelationshipNames() {
+		field := scope.Struct.GetRelationshipField(name)
+		if field == nil {
+			continue
+		}
+
+		relatedStruct := h.Controller.Models.Get(field.GetRelatedModelType())
+		if relatedStruct == nil {
+			h.logger(rw, SubsystemValidation).Errorf("No model found for: '%v' within the controller.", field.GetRelatedModelType())
+			h.MarshalInternalError(rw)
+			return false
+		}
+
+		ids := relationshipPrimaries(v.Field(field.GetFieldIndex()), relatedStruct.GetPrimaryField().GetFieldIndex())
+		if len(ids) == 0 {
+			continue
+		}
+
+		missing, ok := h.missingRelated(field.GetRelatedModelType(), ids, rw, req)
+		if !ok {
+			return false
+		}
+
+		for _, id := range missing {
+			errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
+			errObj.Code = ValidationErrorCode(validationTagExists)
+			errObj.Detail = fmt.Sprintf("The related resource with id: '%v' for the relationship: '%s' does not exists.", id, name)
+			errObj.Meta = &map[string]interface{}{validationMetaTag: validationTagExists}
+			SetErrorSource(errObj, &ErrorSource{Pointer: pointerRelationships + name})
+			errs = append(errs, errObj)
+		}
+	}
+
+	if len(errs) > 0 {
+		h.MarshalErrors(rw, errs...)
+		return false
+	}
+	return true
+}
+
+// missingRelated gets the 'ids' that were not found for the related model.
+func (h *JSONAPIHandler) missingRelated(
+	relatedType reflect.Type,
+	ids []interface{},
+	rw http.ResponseWriter,
+	req *http.Request,
+) (missing []interface{}, ok bool) {
+	relatedScope, err := h.Controller.NewScope(reflect.New(relatedType).Interface())
+	if err != nil {
+		h.logger(rw, SubsystemValidation).Errorf("Cannot create scope for the related model: '%v'. %v", relatedType, err)
+		h.MarshalInternalError(rw)
+		return
+	}
+	relatedScope.Fieldset = nil
+	relatedScope.SetIDFilters(ids...)
+
+	if relatedModel, found := h.ModelHandlers[relatedType]; found && relatedModel.List != nil {
+		if !h.AddPrecheckPairFilters(relatedScope, relatedModel, relatedModel.List, req, rw, relatedModel.List.PrecheckPairs...) {
+			return
+		}
+
+		if !h.AddPrecheckFilters(relatedScope, req, rw, relatedModel.List.PrecheckFilters...) {
+			return
+		}
+	}
+
+	if err = h.addTrashedFilter(relatedScope, TrashedWithout); err != nil {
+		h.logger(rw, SubsystemValidation).Errorf("Cannot add trashed filter for the related model: '%v'. %v", relatedType, err)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	relatedScope.NewValueMany()
+	if dbErr := h.repository(rw, relatedType).List(relatedScope); dbErr != nil {
+		h.manageDBError(rw, relatedScope, dbErr)
+		return
+	}
+
+	found, err := relatedScope.GetPrimaryFieldValues()
+	if err != nil {
+		h.logger(rw, SubsystemValidation).Errorf("Cannot get primary values for the related model: '%v'. %v", relatedType, err)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	existing := make(map[string]struct{}, len(found))
+	for _, id := range found {
+		existing[fmt.Sprint(id)] = struct{}{}
+	}
+
+	for _, id := range ids {
+		if _, exists := existing[fmt.Sprint(id)]; !exists {
+			missing = append(missing, id)
+		}
+	}
+	return missing, true
+}
+
+// relationshipPrimaries gets the primary field values from the relationship field value.
+// The relationship is a pointer to the model or a slice of pointers.
+func relationshipPrimaries(relationship reflect.Value, primIndex int) (ids []interface{}) {
+	primaryOf := func(related reflect.Value) {
+		if related.Kind() == reflect.Ptr {
+			if related.IsNil() {
+				return
+			}
+			related = related.Elem()
+		}
+
+		if related.Kind() != reflect.Struct {
+			return
+		}
+
+		primary := related.Field(primIndex)
+		if reflect.DeepEqual(primary.Interface(), reflect.Zero(primary.Type()).Interface()) {
+			return
+		}
+		ids = append(ids, primary.Interface())
+	}
+
+	switch relationship.Kind() {
+	case reflect.Ptr, reflect.Struct:
+		primaryOf(relationship)
+	case reflect.Slice:
+		for i := 0; i < relationship.Len(); i++ {
+			primaryOf(relationship.Index(i))
+		}
+	}
+	return
+}
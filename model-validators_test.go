@@ -0,0 +1,189 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestValidateScope(t *testing.T) {
+	h := prepareHandler(defaultLanguages, &ValidatedModel{}, &Author{}, &Model{}, &Blog{}, &Post{}, &Comment{})
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	model := h.ModelHandlers[reflect.TypeOf(ValidatedModel{})]
+	assert.Error(t, model.RegisterValidation(Get, "custom", nil))
+	assert.Error(t, model.AddScopeValidator(List, nil))
+
+	// Case 1:
+	// Scope validator errors are written
+	assert.NoError(t, model.AddScopeValidator(Create, func(req *http.Request, scope *jsonapi.Scope) []*jsonapi.ErrorObject {
+		if scope.Value.(*ValidatedModel).Name == scope.Value.(*ValidatedModel).Code {
+			errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
+			SetErrorSource(errObj, &ErrorSource{Pointer: "/data/attributes/code"})
+			return []*jsonapi.ErrorObject{errObj}
+		}
+		return nil
+	}))
+
+	scope, err := h.Controller.NewScope(&ValidatedModel{Name: "abc", Code: "abc", Author: &Author{ID: 2}})
+	assert.NoError(t, err)
+
+	rw, req := getHttpPair("POST", "/validated", nil)
+	assert.False(t, h.ValidateScope(model, Create, scope, rw, req))
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	// Case 2:
+	// Not existing relationship
+	model.ValidateRelationships = true
+	mockRepo.On("List", mock.Anything).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value = []*Author{}
+		})
+
+	scope, err = h.Controller.NewScope(&ValidatedModel{Name: "name", Code: "abc", Author: &Author{ID: 2}})
+	assert.NoError(t, err)
+
+	rw, req = getHttpPair("POST", "/validated", nil)
+	assert.False(t, h.ValidateScope(model, Create, scope, rw, req))
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	var doc struct {
+		Errors []struct {
+			Code   string            `json:"code"`
+			Source map[string]string `json:"source"`
+		} `json:"errors"`
+	}
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	if assert.Len(t, doc.Errors, 1) {
+		assert.Equal(t, ValidationErrorCode(validationTagExists), doc.Errors[0].Code)
+		assert.Equal(t, "/data/relationships/author", doc.Errors[0].Source["pointer"])
+	}
+
+	// Case 3:
+	// Existing relationship
+	mockRepo.On("List", mock.Anything).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value = []*Author{{ID: 2}}
+		})
+
+	rw, req = getHttpPair("POST", "/validated", nil)
+	assert.True(t, h.ValidateScope(model, Create, scope, rw, req))
+}
+
+type PatchRulesModel struct {
+	ID     int    `jsonapi:"primary,patch_rules"`
+	Name   string `jsonapi:"attr,name"`
+	Code   string `jsonapi:"attr,code" patch:"immutable"`
+	Serial string `jsonapi:"attr,serial" patch:"writeonce"`
+}
+
+func TestValidateScopePatchRules(t *testing.T) {
+	h := prepareHandler(defaultLanguages, &PatchRulesModel{})
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	model := h.ModelHandlers[reflect.TypeOf(PatchRulesModel{})]
+
+	patchScope := func(value *PatchRulesModel, fields ...string) *jsonapi.Scope {
+		scope, err := h.Controller.NewScope(value)
+		assert.NoError(t, err)
+		scope.SetIDFilters(value.ID)
+		scope.Fieldset = make(map[string]*jsonapi.StructField)
+		for _, field := range fields {
+			scope.Fieldset[field] = scope.Struct.GetAttributeField(field)
+		}
+		return scope
+	}
+
+	errorCode := func(rw interface {
+		Result() *http.Response
+	}) string {
+		var doc struct {
+			Errors []struct {
+				Code string `json:"code"`
+			} `json:"errors"`
+		}
+		assert.NoError(t, json.NewDecoder(rw.Result().Body).Decode(&doc))
+		if len(doc.Errors) == 0 {
+			return ""
+		}
+		return doc.Errors[0].Code
+	}
+
+	validateStored := func(scope *jsonapi.Scope) (bool, *httptest.ResponseRecorder) {
+		rw, req := getHttpPair("PATCH", "/patch_rules/1", nil)
+		return h.validateStoredValues(model, scope, scope.Fieldset, mockRepo, rw, req), rw
+	}
+
+	// Case 1:
+	// Immutable field is validated with the stored value
+	rw, req := getHttpPair("PATCH", "/patch_rules/1", nil)
+	assert.True(t, h.ValidateScope(model, Patch, patchScope(&PatchRulesModel{ID: 1, Code: "abc"}, "code"), rw, req))
+
+	// Case 2:
+	// Immutable field changed
+	mockRepo.On("Get", mock.Anything).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*PatchRulesModel).Code = "old"
+		})
+	valid, rw := validateStored(patchScope(&PatchRulesModel{ID: 1, Code: "abc"}, "code"))
+	assert.False(t, valid)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+	assert.Equal(t, ValidationErrorCode(validationTagImmutable), errorCode(rw))
+
+	// Case 3:
+	// Immutable field provided with the stored value
+	mockRepo.On("Get", mock.Anything).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*PatchRulesModel).Code = "abc"
+		})
+	valid, _ = validateStored(patchScope(&PatchRulesModel{ID: 1, Code: "abc"}, "code"))
+	assert.True(t, valid)
+
+	// Case 4:
+	// Not provided immutable field is not validated
+	valid, _ = validateStored(patchScope(&PatchRulesModel{ID: 1, Name: "name"}, "name"))
+	assert.True(t, valid)
+
+	// Case 5:
+	// Write once field not yet set
+	mockRepo.On("Get", mock.Anything).Once().Return(nil)
+	valid, _ = validateStored(patchScope(&PatchRulesModel{ID: 1, Serial: "xyz"}, "serial"))
+	assert.True(t, valid)
+
+	// Case 6:
+	// Write once field already set
+	mockRepo.On("Get", mock.Anything).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*PatchRulesModel).Serial = "abc"
+		})
+	valid, rw = validateStored(patchScope(&PatchRulesModel{ID: 1, Serial: "xyz"}, "serial"))
+	assert.False(t, valid)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+	assert.Equal(t, ValidationErrorCode(validationTagWriteOnce), errorCode(rw))
+
+	// Case 7:
+	// The stored values are read with the prechecked scope's filters
+	scope := patchScope(&PatchRulesModel{ID: 1, Code: "abc"}, "code")
+	filter := &jsonapi.FilterField{StructField: scope.Struct.GetAttributeField("name")}
+	scope.AttributeFilters = append(scope.AttributeFilters, filter)
+	mockRepo.On("Get", mock.Anything).Once().Return(unidb.ErrNoResult.New()).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			assert.Contains(t, arg.AttributeFilters, filter)
+		})
+	valid, _ = validateStored(scope)
+	assert.True(t, valid)
+}
@@ -52,3 +52,16 @@ type Human struct {
 	Name string `jsonapi:"attr,name"`
 	Pets []*Pet `jsonapi:"relation,pets"`
 }
+
+type Essay struct {
+	ID        int        `jsonapi:"primary,essays"`
+	Title     string     `jsonapi:"attr,title"`
+	DeletedAt *time.Time `jsonapi:"attr,deleted_at"`
+	Reviews   []*Review  `jsonapi:"relation,reviews"`
+}
+
+type Review struct {
+	ID        int        `jsonapi:"primary,reviews"`
+	Body      string     `jsonapi:"attr,body"`
+	DeletedAt *time.Time `jsonapi:"attr,deleted_at"`
+}
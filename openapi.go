@@ -0,0 +1,658 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"net/http"
+	"sort"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification of the generated documents.
+const OpenAPIVersion = "3.1.0"
+
+const mediaTypeOpenAPI = "application/vnd.oai.openapi+json"
+
+// OpenAPI is the OpenAPI 3.1 document describing the handler's endpoints.
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       OpenAPIInfo          `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+// OpenAPIInfo is the metadata of the OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem describes the operations available on the single path.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+// Operation describes the single API operation on the path.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes the single operation parameter.
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the operation's request body.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType describes the content of the request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Response describes the single response of the operation.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Components holds the reusable objects of the OpenAPI document.
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas,omitempty"`
+	Parameters map[string]*Parameter `json:"parameters,omitempty"`
+	Responses  map[string]*Response  `json:"responses,omitempty"`
+}
+
+// OpenAPI generates the OpenAPI 3.1 document for the handler's models. The document contains
+// the paths of the models' enabled endpoints, the JSON:API request and response schemas with
+// the attributes constraints derived from the 'create' and 'patch' validation tags, the query
+// parameters and the error responses.
+func (h *JSONAPIHandler) OpenAPI(info OpenAPIInfo) *OpenAPI {
+	g := &openAPIGenerator{
+		h: h,
+		doc: &OpenAPI{
+			OpenAPI: OpenAPIVersion,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+			Components: &Components{
+				Schemas:    make(map[string]*Schema),
+				Parameters: make(map[string]*Parameter),
+				Responses:  make(map[string]*Response),
+			},
+		},
+	}
+	g.addCommonComponents()
+
+	for _, model := range h.sortedModels() {
+		g.addModel(model)
+	}
+	return g.doc
+}
+
+// ServeOpenAPI returns the http.HandlerFunc that responds with the handler's OpenAPI document.
+func (h *JSONAPIHandler) ServeOpenAPI(info OpenAPIInfo) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", mediaTypeOpenAPI)
+		if err := json.NewEncoder(rw).Encode(h.OpenAPI(info)); err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Cannot marshal the OpenAPI document. %v", err)
+		}
+	}
+}
+
+// sortedModels gets the handler's models sorted by their collection names.
+func (h *JSONAPIHandler) sortedModels() []*ModelHandler {
+	models := make([]*ModelHandler, 0, len(h.ModelHandlers))
+	for _, model := range h.ModelHandlers {
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return h.modelLabel(models[i].ModelType) < h.modelLabel(models[j].ModelType)
+	})
+	return models
+}
+
+// The names of the common components.
+const (
+	componentIdentifier       = "resource-identifier"
+	componentLinks            = "links"
+	componentMeta             = "meta"
+	componentError            = "error"
+	componentErrorDocument    = "error-document"
+	componentRelationshipOne  = "relationship-to-one"
+	componentRelationshipMany = "relationship-to-many"
+)
+
+// openAPIGenerator builds the OpenAPI document for the handler's models.
+type openAPIGenerator struct {
+	h   *JSONAPIHandler
+	doc *OpenAPI
+}
+
+func schemaComponent(name string) *Schema {
+	return schemaRef("#/components/schemas/" + name)
+}
+
+func parameterComponent(name string) *Parameter {
+	return &Parameter{Ref: "#/components/parameters/" + name}
+}
+
+func responseComponent(name string) *Response {
+	return &Response{Ref: "#/components/responses/" + name}
+}
+
+func jsonapiContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{jsonapi.MediaType: {Schema: schema}}
+}
+
+func (g *openAPIGenerator) addCommonComponents() {
+	c := g.doc.Components
+	explode := true
+
+	c.Schemas[componentLinks] = &Schema{Type: "object", AdditionalProperties: true}
+	c.Schemas[componentMeta] = &Schema{Type: "object", AdditionalProperties: true}
+	c.Schemas[componentIdentifier] = objectSchema(map[string]*Schema{
+		"type": {Type: "string"},
+		"id":   {Type: "string"},
+	}, "type", "id")
+	c.Schemas[componentRelationshipOne] = objectSchema(map[string]*Schema{
+		"data":  {OneOf: []*Schema{schemaComponent(componentIdentifier), {Type: "null"}}},
+		"links": schemaComponent(componentLinks),
+		"meta":  schemaComponent(componentMeta),
+	})
+	c.Schemas[componentRelationshipMany] = objectSchema(map[string]*Schema{
+		"data":  {Type: "array", Items: schemaComponent(componentIdentifier)},
+		"links": schemaComponent(componentLinks),
+		"meta":  schemaComponent(componentMeta),
+	})
+	c.Schemas[componentError] = objectSchema(map[string]*Schema{
+		"id":     {Type: "string"},
+		"title":  {Type: "string"},
+		"detail": {Type: "string"},
+		"status": {Type: "string"},
+		"code":   {Type: "string"},
+		"source": objectSchema(map[string]*Schema{
+			"pointer":   {Type: "string"},
+			"parameter": {Type: "string"},
+		}),
+		"meta": schemaComponent(componentMeta),
+	})
+	c.Schemas[componentErrorDocument] = objectSchema(map[string]*Schema{
+		"errors": {Type: "array", Items: schemaComponent(componentError)},
+		"meta":   schemaComponent(componentMeta),
+	}, "errors")
+
+	c.Parameters["id"] = &Parameter{
+		Name: "id", In: "path", Required: true,
+		Description: "The resource's id.",
+		Schema:      &Schema{Type: "string"},
+	}
+	c.Parameters["include"] = &Parameter{
+		Name: "include", In: "query",
+		Description: "The comma separated relationship paths of the included resources.",
+		Schema:      &Schema{Type: "string"},
+	}
+	c.Parameters["sort"] = &Parameter{
+		Name: "sort", In: "query",
+		Description: "The comma separated sort fields. The '-' prefix defines the descending order.",
+		Schema:      &Schema{Type: "string"},
+	}
+	c.Parameters["fields"] = &Parameter{
+		Name: "fields", In: "query", Style: "deepObject", Explode: &explode,
+		Description: "The sparse fieldsets i.e. 'fields[collection]=field1,field2'.",
+		Schema:      &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+	}
+	c.Parameters["filter"] = &Parameter{
+		Name: "filter", In: "query", Style: "deepObject", Explode: &explode,
+		Description: "The filters i.e. 'filter[collection][field][operator]=value'.",
+		Schema:      &Schema{Type: "object", AdditionalProperties: true},
+	}
+	c.Parameters["page"] = &Parameter{
+		Name: "page", In: "query", Style: "deepObject", Explode: &explode,
+		Description: "The pagination i.e. 'page[limit]' and 'page[offset]' or 'page[number]' and 'page[size]'.",
+		Schema: objectSchema(map[string]*Schema{
+			"limit":  {Type: "integer"},
+			"offset": {Type: "integer"},
+			"number": {Type: "integer"},
+			"size":   {Type: "integer"},
+		}),
+	}
+	c.Parameters["accept-language"] = &Parameter{
+		Name: headerAcceptLanguage, In: "header",
+		Description: "The preferred languages of the response.",
+		Schema:      &Schema{Type: "string"},
+	}
+	if g.h.LanguageQueryParameter != "" {
+		c.Parameters["language"] = &Parameter{
+			Name: g.h.LanguageQueryParameter, In: "query",
+			Description: "The language of the response.",
+			Schema:      &Schema{Type: "string"},
+		}
+	}
+	if g.h.IdempotencyStore != nil {
+		c.Parameters["idempotency-key"] = &Parameter{
+			Name: headerIdempotencyKey, In: "header",
+			Description: "The key that makes the request idempotent.",
+			Schema:      &Schema{Type: "string", MaxLength: intPtr(maxIdempotencyKeyLength)},
+		}
+	}
+	c.Parameters["if-none-match"] = &Parameter{
+		Name: headerIfNoneMatch, In: "header",
+		Schema: &Schema{Type: "string"},
+	}
+	c.Parameters["if-modified-since"] = &Parameter{
+		Name: headerIfModifiedSince, In: "header",
+		Schema: &Schema{Type: "string"},
+	}
+
+	errorResponses := map[int]string{
+		http.StatusBadRequest:           "The request is invalid.",
+		http.StatusForbidden:            "The operation is forbidden.",
+		http.StatusNotFound:             "The resource is not found.",
+		http.StatusNotAcceptable:        "The language is not supported.",
+		http.StatusConflict:             "The request conflicts with the current state of the resource or the request with the same idempotency key is processed longer than the idempotency wait.",
+		http.StatusUnsupportedMediaType: "The request's media type is not supported.",
+		http.StatusUnprocessableEntity:  "The idempotency key is reused with a different request.",
+		http.StatusInternalServerError:  "Internal server error.",
+	}
+	for status, description := range errorResponses {
+		c.Responses[fmt.Sprintf("%d", status)] = &Response{
+			Description: description,
+			Content:     jsonapiContent(schemaComponent(componentErrorDocument)),
+		}
+	}
+}
+
+// errorResponses adds the error responses with the 'statuses' to the operation.
+func (g *openAPIGenerator) errorResponses(op *Operation, statuses ...int) {
+	statuses = append(statuses, http.StatusInternalServerError)
+	if g.h.IdempotencyStore != nil && op.RequestBody != nil {
+		statuses = append(statuses, http.StatusConflict, http.StatusUnprocessableEntity)
+	}
+	for _, status := range statuses {
+		code := fmt.Sprintf("%d", status)
+		op.Responses[code] = responseComponent(code)
+	}
+}
+
+// readParameters gets the parameters of the operations reading the model's resources.
+func (g *openAPIGenerator) readParameters(endpoint *Endpoint, many bool) []*Parameter {
+	params := []*Parameter{parameterComponent("include"), parameterComponent("fields")}
+	if many {
+		params = append(params,
+			parameterComponent("filter"),
+			parameterComponent("sort"),
+			parameterComponent("page"),
+		)
+	}
+	if endpoint != nil && endpoint.ConditionalGet {
+		params = append(params, parameterComponent("if-none-match"), parameterComponent("if-modified-since"))
+	}
+	return append(params, g.languageParameters()...)
+}
+
+func (g *openAPIGenerator) languageParameters() []*Parameter {
+	params := []*Parameter{parameterComponent("accept-language")}
+	if g.h.LanguageQueryParameter != "" {
+		params = append(params, parameterComponent("language"))
+	}
+	return params
+}
+
+func (g *openAPIGenerator) writeParameters() []*Parameter {
+	params := g.languageParameters()
+	if g.h.IdempotencyStore != nil {
+		params = append(params, parameterComponent("idempotency-key"))
+	}
+	return params
+}
+
+func (g *openAPIGenerator) path(path string) *PathItem {
+	item, ok := g.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		g.doc.Paths[path] = item
+	}
+	return item
+}
+
+// addModel adds the schemas and the paths of the model's enabled endpoints.
+func (g *openAPIGenerator) addModel(model *ModelHandler) {
+	collection := g.h.modelLabel(model.ModelType)
+	g.addModelSchemas(model, collection)
+
+	base := g.h.Controller.APIURLBase + "/" + collection
+	single := base + "/{id}"
+	tags := []string{collection}
+
+	op := func(id, summary string) *Operation {
+		return &Operation{
+			OperationID: collection + "." + id,
+			Summary:     summary,
+			Tags:        tags,
+			Responses:   make(map[string]*Response),
+		}
+	}
+
+	if model.List != nil {
+		o := op("list", fmt.Sprintf("Lists the '%s' resources.", collection))
+		o.Parameters = g.readParameters(model.List, true)
+		o.Responses["200"] = &Response{
+			Description: "The list of resources.",
+			Content:     jsonapiContent(schemaComponent(collection + "-collection-document")),
+		}
+		g.conditionalResponse(o, model.List)
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotAcceptable)
+		g.path(base).Get = o
+	}
+
+	if model.Create != nil {
+		o := op("create", fmt.Sprintf("Creates the '%s' resource.", collection))
+		o.Parameters = g.writeParameters()
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonapiContent(schemaComponent(collection + "-create-document")),
+		}
+		o.Responses["201"] = &Response{
+			Description: "The created resource.",
+			Content:     jsonapiContent(schemaComponent(collection + "-document")),
+		}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusForbidden, http.StatusConflict, http.StatusUnsupportedMediaType)
+		g.path(base).Post = o
+	}
+
+	if model.Get != nil {
+		o := op("get", fmt.Sprintf("Gets the '%s' resource.", collection))
+		o.Parameters = append([]*Parameter{parameterComponent("id")}, g.readParameters(model.Get, false)...)
+		o.Responses["200"] = &Response{
+			Description: "The resource.",
+			Content:     jsonapiContent(schemaComponent(collection + "-document")),
+		}
+		g.conditionalResponse(o, model.Get)
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound, http.StatusNotAcceptable)
+		g.path(single).Get = o
+	}
+
+	if model.Patch != nil {
+		o := op("patch", fmt.Sprintf("Patches the '%s' resource.", collection))
+		o.Parameters = append([]*Parameter{parameterComponent("id")}, g.writeParameters()...)
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonapiContent(schemaComponent(collection + "-patch-document")),
+		}
+		if model.Patch.GetModifiedResult {
+			o.Responses["200"] = &Response{
+				Description: "The patched resource.",
+				Content:     jsonapiContent(schemaComponent(collection + "-document")),
+			}
+		} else {
+			o.Responses["204"] = &Response{Description: "The resource is patched."}
+		}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType)
+		g.path(single).Patch = o
+	}
+
+	if model.Delete != nil {
+		o := op("delete", fmt.Sprintf("Deletes the '%s' resource.", collection))
+		o.Parameters = append([]*Parameter{parameterComponent("id")}, g.writeParameters()...)
+		o.Responses["204"] = &Response{Description: "The resource is deleted."}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound)
+		g.path(single).Delete = o
+	}
+
+	if model.Restore != nil {
+		o := op("restore", fmt.Sprintf("Restores the soft deleted '%s' resource.", collection))
+		o.Parameters = append([]*Parameter{parameterComponent("id")}, g.languageParameters()...)
+		o.Responses["204"] = &Response{Description: "The resource is restored."}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound)
+		g.path(single + "/restore").Post = o
+	}
+
+	if model.Translations {
+		g.addTranslations(model, collection, op)
+	}
+
+	_, rels := g.h.resourceFields(model.ModelType)
+	for _, rel := range rels {
+		g.addRelationship(model, collection, rel, op)
+	}
+}
+
+// conditionalResponse adds the '304' Not Modified response for the conditional endpoints.
+func (g *openAPIGenerator) conditionalResponse(op *Operation, endpoint *Endpoint) {
+	if endpoint.ConditionalGet {
+		op.Responses["304"] = &Response{Description: "The resource is not modified."}
+	}
+}
+
+// addTranslations adds the paths of the model's translations endpoints.
+func (g *openAPIGenerator) addTranslations(
+	model *ModelHandler,
+	collection string,
+	op func(id, summary string) *Operation,
+) {
+	base := g.h.Controller.APIURLBase + "/" + collection + "/{id}/translations"
+	langParam := &Parameter{
+		Name: "language", In: "path", Required: true,
+		Description: "The language tag of the translation.",
+		Schema:      &Schema{Type: "string"},
+	}
+
+	if model.List != nil {
+		o := op("translations.list", fmt.Sprintf("Lists the translations of the '%s' resource.", collection))
+		o.Parameters = []*Parameter{parameterComponent("id")}
+		o.Responses["200"] = &Response{
+			Description: "The translations of the resource.",
+			Content:     jsonapiContent(schemaComponent(collection + "-collection-document")),
+		}
+		g.errorResponses(o, http.StatusNotFound)
+		g.path(base).Get = o
+	}
+
+	if model.Create != nil {
+		o := op("translations.create", fmt.Sprintf("Adds the translation of the '%s' resource.", collection))
+		o.Parameters = []*Parameter{parameterComponent("id")}
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonapiContent(schemaComponent(collection + "-create-document")),
+		}
+		o.Responses["201"] = &Response{
+			Description: "The created translation.",
+			Content:     jsonapiContent(schemaComponent(collection + "-document")),
+		}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound, http.StatusNotAcceptable, http.StatusConflict)
+		g.path(base).Post = o
+	}
+
+	if model.Patch != nil {
+		o := op("translations.patch", fmt.Sprintf("Patches the translation of the '%s' resource.", collection))
+		o.Parameters = []*Parameter{parameterComponent("id"), langParam}
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonapiContent(schemaComponent(collection + "-patch-document")),
+		}
+		o.Responses["204"] = &Response{Description: "The translation is patched."}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound, http.StatusNotAcceptable)
+		g.path(base + "/{language}").Patch = o
+	}
+
+	if model.Delete != nil {
+		o := op("translations.delete", fmt.Sprintf("Deletes the translation of the '%s' resource.", collection))
+		o.Parameters = []*Parameter{parameterComponent("id"), langParam}
+		o.Responses["204"] = &Response{Description: "The translation is deleted."}
+		g.errorResponses(o, http.StatusNotFound, http.StatusNotAcceptable)
+		g.path(base + "/{language}").Delete = o
+	}
+}
+
+// addRelationship adds the paths of the related and relationship endpoints for the 'rel' field.
+func (g *openAPIGenerator) addRelationship(
+	model *ModelHandler,
+	collection string,
+	rel *resourceField,
+	op func(id, summary string) *Operation,
+) {
+	base := g.h.Controller.APIURLBase + "/" + collection + "/{id}"
+
+	if model.GetRelated != nil {
+		related := g.h.modelLabel(rel.relatedType())
+		schema := &Schema{Type: "object", AdditionalProperties: true}
+		if _, ok := g.h.ModelHandlers[rel.relatedType()]; ok {
+			if rel.isMany() {
+				schema = schemaComponent(related + "-collection-document")
+			} else {
+				schema = schemaComponent(related + "-document")
+			}
+		}
+
+		o := op(rel.name+".related", fmt.Sprintf("Gets the '%s' related to the '%s' resource.", rel.name, collection))
+		o.Parameters = append([]*Parameter{parameterComponent("id")}, g.readParameters(nil, false)...)
+		o.Responses["200"] = &Response{Description: "The related resources.", Content: jsonapiContent(schema)}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound)
+		g.path(base + "/" + rel.name).Get = o
+	}
+
+	if model.GetRelationship != nil {
+		schema := schemaComponent(componentRelationshipOne)
+		if rel.isMany() {
+			schema = schemaComponent(componentRelationshipMany)
+		}
+
+		o := op(rel.name+".relationship", fmt.Sprintf("Gets the '%s' relationship of the '%s' resource.", rel.name, collection))
+		o.Parameters = []*Parameter{parameterComponent("id")}
+		o.Responses["200"] = &Response{Description: "The relationship.", Content: jsonapiContent(schema)}
+		g.errorResponses(o, http.StatusBadRequest, http.StatusNotFound)
+		g.path(base + "/relationships/" + rel.name).Get = o
+	}
+}
+
+// addModelSchemas adds the resource, the request and the document schemas of the model.
+func (g *openAPIGenerator) addModelSchemas(model *ModelHandler, collection string) {
+	s := g.doc.Components.Schemas
+
+	s[collection] = g.h.resourceSchema(model, "", schemaComponent)
+	s[collection+"-create"] = g.h.resourceSchema(model, createValidatorTag, schemaComponent)
+	s[collection+"-patch"] = g.h.resourceSchema(model, patchValidatorTag, schemaComponent)
+
+	included := &Schema{Type: "array", Items: &Schema{Type: "object"}}
+	s[collection+"-document"] = objectSchema(map[string]*Schema{
+		"data":     schemaComponent(collection),
+		"included": included,
+		"links":    schemaComponent(componentLinks),
+		"meta":     schemaComponent(componentMeta),
+	}, "data")
+	s[collection+"-collection-document"] = objectSchema(map[string]*Schema{
+		"data":     {Type: "array", Items: schemaComponent(collection)},
+		"included": included,
+		"links":    schemaComponent(componentLinks),
+		"meta":     schemaComponent(componentMeta),
+	}, "data")
+	s[collection+"-create-document"] = objectSchema(map[string]*Schema{
+		"data": schemaComponent(collection + "-create"),
+	}, "data")
+	s[collection+"-patch-document"] = objectSchema(map[string]*Schema{
+		"data": schemaComponent(collection + "-patch"),
+	}, "data")
+}
+
+// resourceSchema creates the schema of the model's resource object. If the 'validatorTag' is
+// provided, the schema describes the request's resource object for the Create or Patch endpoint
+// with the constraints derived from the validation tags. The 'ref' references the common
+// schemas i.e. the links and meta.
+func (h *JSONAPIHandler) resourceSchema(model *ModelHandler, validatorTag string, ref func(name string) *Schema) *Schema {
+	collection := h.modelLabel(model.ModelType)
+	attrs, rels := h.resourceFields(model.ModelType)
+
+	attributes := objectSchema(make(map[string]*Schema))
+	for _, attr := range attrs {
+		sField := attr.structField()
+		schema := typeSchema(sField.Type)
+		if validatorTag != "" {
+			tag := sField.Tag.Get(validatorTag)
+			if applyValidationTag(schema, sField.Type, tag) && validatorTag == createValidatorTag {
+				attributes.Required = append(attributes.Required, attr.name)
+			}
+		}
+		attributes.Properties[attr.name] = schema
+	}
+
+	relationships := objectSchema(make(map[string]*Schema))
+	for _, rel := range rels {
+		identifier := objectSchema(map[string]*Schema{
+			"type": {Type: "string", Const: h.modelLabel(rel.relatedType())},
+			"id":   {Type: "string"},
+		}, "type", "id")
+
+		data := &Schema{OneOf: []*Schema{identifier, {Type: "null"}}}
+		if rel.isMany() {
+			data = &Schema{Type: "array", Items: identifier}
+		}
+
+		properties := map[string]*Schema{"data": data}
+		if validatorTag == "" {
+			properties["links"] = ref(componentLinks)
+			properties["meta"] = ref(componentMeta)
+		}
+		relSchema := objectSchema(properties)
+		if validatorTag != "" {
+			relSchema.Required = []string{"data"}
+			if applyValidationTag(data, rel.structField().Type, rel.structField().Tag.Get(validatorTag)) && validatorTag == createValidatorTag {
+				relationships.Required = append(relationships.Required, rel.name)
+			}
+		}
+		relationships.Properties[rel.name] = relSchema
+	}
+
+	properties := map[string]*Schema{
+		"type":          {Type: "string", Const: collection},
+		"id":            {Type: "string"},
+		"attributes":    attributes,
+		"relationships": relationships,
+	}
+	required := []string{"type", "id"}
+
+	switch validatorTag {
+	case "":
+		properties["links"] = ref(componentLinks)
+		properties["meta"] = ref(componentMeta)
+	case createValidatorTag:
+		required = []string{"type"}
+		switch model.ClientIDPolicy {
+		case ClientIDForbidden:
+			delete(properties, "id")
+		case ClientIDRequired:
+			required = append(required, "id")
+		}
+		if len(attributes.Required) > 0 {
+			required = append(required, "attributes")
+		}
+		if len(relationships.Required) > 0 {
+			required = append(required, "relationships")
+		}
+	}
+	return objectSchema(properties, required...)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
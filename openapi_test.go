@@ -0,0 +1,86 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type Article struct {
+	ID     int     `jsonapi:"primary,articles"`
+	Title  string  `jsonapi:"attr,title" create:"required,min=3,max=100" patch:"min=3,max=100"`
+	Status string  `jsonapi:"attr,status" create:"oneof=draft published"`
+	Views  *int    `jsonapi:"attr,views" create:"gte=0"`
+	Rating int     `jsonapi:"attr,rating" create:"oneof=1 2 3"`
+	Secret string  `jsonapi:"attr,secret,hidden"`
+	Author *Author `jsonapi:"relation,author" create:"required"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	h := prepareHandler(defaultLanguages, append(blogModels, &Article{})...)
+	h.ModelHandlers[reflect.TypeOf(Article{})].ClientIDPolicy = ClientIDForbidden
+
+	doc := h.OpenAPI(OpenAPIInfo{Title: "Blogs", Version: "1.0.0"})
+	assert.Equal(t, OpenAPIVersion, doc.OpenAPI)
+
+	if assert.Contains(t, doc.Paths, "/articles") {
+		assert.NotNil(t, doc.Paths["/articles"].Post)
+		assert.NotNil(t, doc.Paths["/articles"].Get)
+	}
+	assert.Contains(t, doc.Paths, "/articles/{id}")
+
+	// Case 1:
+	// The response resource schema
+	article := doc.Components.Schemas["articles"]
+	if assert.NotNil(t, article) {
+		assert.Equal(t, "articles", article.Properties["type"].Const)
+		attributes := article.Properties["attributes"]
+		assert.Contains(t, attributes.Properties, "title")
+		assert.NotContains(t, attributes.Properties, "secret")
+		assert.Equal(t, []string{"integer", "null"}, attributes.Properties["views"].Type)
+	}
+
+	// Case 2:
+	// The create schema with the validation constraints
+	create := doc.Components.Schemas["articles-create"]
+	if assert.NotNil(t, create) {
+		assert.NotContains(t, create.Properties, "id")
+		attributes := create.Properties["attributes"]
+		assert.Equal(t, []string{"title"}, attributes.Required)
+
+		title := attributes.Properties["title"]
+		if assert.NotNil(t, title.MinLength) && assert.NotNil(t, title.MaxLength) {
+			assert.Equal(t, 3, *title.MinLength)
+			assert.Equal(t, 100, *title.MaxLength)
+		}
+		assert.Equal(t, []interface{}{"draft", "published"}, attributes.Properties["status"].Enum)
+		assert.Equal(t, []string{"author"}, create.Properties["relationships"].Required)
+	}
+
+	// Case 3:
+	// The patch schema requires the id
+	patch := doc.Components.Schemas["articles-patch"]
+	if assert.NotNil(t, patch) {
+		assert.Contains(t, patch.Required, "id")
+		assert.Empty(t, patch.Properties["attributes"].Required)
+	}
+
+	_, err := json.Marshal(doc)
+	assert.NoError(t, err)
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	h.ServeOpenAPI(OpenAPIInfo{Title: "Blogs", Version: "1.0.0"})(rw, req)
+	assert.Equal(t, 200, rw.Code)
+	assert.Equal(t, mediaTypeOpenAPI, rw.Header().Get("Content-Type"))
+
+	doc := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	assert.Equal(t, OpenAPIVersion, doc["openapi"])
+}
@@ -0,0 +1,68 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+)
+
+// providedDocument is used to check which resource fields were provided in the request body.
+type providedDocument struct {
+	Data *struct {
+		ID            string                     `json:"id"`
+		Attributes    map[string]json.RawMessage `json:"attributes"`
+		Relationships map[string]json.RawMessage `json:"relationships"`
+	} `json:"data"`
+}
+
+// providedFieldset gets the fields provided within the request 'body' for the scope's model.
+// The returned fieldset has the same keys as the scope's fieldset.
+func providedFieldset(scope *jsonapi.Scope, body []byte) (map[string]*jsonapi.StructField, error) {
+	doc := &providedDocument{}
+	if err := json.Unmarshal(body, doc); err != nil {
+		return nil, err
+	}
+
+	fieldset := make(map[string]*jsonapi.StructField)
+	if doc.Data == nil {
+		return fieldset, nil
+	}
+
+	for name := range doc.Data.Attributes {
+		field := scope.Struct.GetAttributeField(name)
+		if field == nil {
+			return nil, fmt.Errorf("Attribute: '%s' not found within model: '%v'", name, scope.Struct.GetType())
+		}
+		fieldset[name] = field
+	}
+
+	for name := range doc.Data.Relationships {
+		field := scope.Struct.GetRelationshipField(name)
+		if field == nil {
+			return nil, fmt.Errorf("Relationship: '%s' not found within model: '%v'", name, scope.Struct.GetType())
+		}
+		fieldset[name] = field
+	}
+	return fieldset, nil
+}
+
+// addFieldsetField adds the 'field' into the 'fieldset' with the key taken from the 'full'
+// fieldset.
+func addFieldsetField(fieldset, full map[string]*jsonapi.StructField, field *jsonapi.StructField) {
+	for name, fsField := range full {
+		if fsField.GetFieldIndex() == field.GetFieldIndex() {
+			fieldset[name] = fsField
+			return
+		}
+	}
+}
+
+// fieldsetStructNames gets the struct field names of the scope's fieldset. The names are used for
+// the partial validation.
+func fieldsetStructNames(scope *jsonapi.Scope) []string {
+	names := make([]string, 0, len(scope.Fieldset))
+	for _, field := range scope.Fieldset {
+		names = append(names, field.GetFieldName())
+	}
+	return names
+}
@@ -23,6 +23,9 @@ const (
 
 	// Deletes
 	Delete
+
+	// Restore
+	Restore
 )
 
 func (e EndpointType) String() string {
@@ -42,6 +45,8 @@ func (e EndpointType) String() string {
 		op = "PATCH"
 	case Delete:
 		op = "DELETE"
+	case Restore:
+		op = "RESTORE"
 
 	default:
 		op = "UNKNOWN"
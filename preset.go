@@ -15,7 +15,7 @@ func (h *JSONAPIHandler) AddPrecheckFilters(
 	filters ...*jsonapi.PresetFilter,
 ) (ok bool) {
 	for _, filter := range filters {
-		h.log.Debugf("Adding precheck filter: %s", filter.GetFieldName())
+		h.logger(rw, SubsystemFilters).Debugf("Adding precheck filter: %s", filter.GetFieldName())
 
 		value := req.Context().Value(filter.Key)
 		if value == nil {
@@ -23,12 +23,12 @@ func (h *JSONAPIHandler) AddPrecheckFilters(
 		}
 
 		if err := h.SetPresetFilterValues(filter.FilterField, value); err != nil {
-			h.log.Errorf("Error while setting values for filter field. Model: %v, Filterfield: %v. Error: %v", scope.Struct.GetType().Name(), filter.GetFieldName(), err)
+			h.logger(rw, SubsystemFilters).Errorf("Error while setting values for filter field. Model: %v, Filterfield: %v. Error: %v", scope.Struct.GetType().Name(), filter.GetFieldName(), err)
 			h.MarshalInternalError(rw)
 			return false
 		}
 		if err := scope.AddFilterField(filter.FilterField); err != nil {
-			h.log.Errorf("Cannot add filter field to root scope in get related field. %v", err)
+			h.logger(rw, SubsystemFilters).Errorf("Cannot add filter field to root scope in get related field. %v", err)
 			h.MarshalInternalError(rw)
 			return false
 		}
@@ -51,7 +51,7 @@ func (h *JSONAPIHandler) AddPrecheckPairFilters(
 				continue
 			}
 		}
-		values, err := h.GetPresetValues(presetScope, rw)
+		values, err := h.getPresetValues(presetScope, req, rw)
 		if err != nil {
 			if hErr := err.(*HandlerError); hErr != nil {
 				if hErr.Code == ErrNoValues {
@@ -67,7 +67,7 @@ func (h *JSONAPIHandler) AddPrecheckPairFilters(
 					return
 				}
 			} else {
-				h.log.Error(err)
+				h.logger(rw, SubsystemFilters).Error(err)
 				h.MarshalInternalError(rw)
 				return
 			}
@@ -76,13 +76,13 @@ func (h *JSONAPIHandler) AddPrecheckPairFilters(
 		}
 
 		if err := h.SetPresetFilterValues(presetField, values...); err != nil {
-			h.log.Errorf("Error while preseting filter for model: '%s'. '%s'", model.ModelType.Name(), err)
+			h.logger(rw, SubsystemFilters).Errorf("Error while preseting filter for model: '%s'. '%s'", model.ModelType.Name(), err)
 			h.MarshalInternalError(rw)
 			return
 		}
 
 		if err := scope.AddFilterField(presetField); err != nil {
-			h.log.Debugf("Cannot add filter field: %v to the model: %v", presetField.GetFieldName(), scope.Struct.GetType().Name())
+			h.logger(rw, SubsystemFilters).Debugf("Cannot add filter field: %v to the model: %v", presetField.GetFieldName(), scope.Struct.GetType().Name())
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -91,39 +91,49 @@ func (h *JSONAPIHandler) AddPrecheckPairFilters(
 }
 
 // GetPresetValues gets the values from the presetScope
+// As the request is unknown, only the model's interface read hooks are run on the preset scope.
 func (h *JSONAPIHandler) GetPresetValues(
 	presetScope *jsonapi.Scope,
 	rw http.ResponseWriter,
 ) (values []interface{}, err error) {
-	h.log.Debug("------Getting Preset Values-------")
-	h.log.Debug("Preset Fieldset:")
-	for field := range presetScope.Fieldset {
-		h.log.Debug(field)
+	return h.getPresetValues(presetScope, nil, rw)
+}
+
+// getPresetValues gets the values from the presetScope. The read hooks of the preset scope's
+// model are run for the request 'req'.
+func (h *JSONAPIHandler) getPresetValues(
+	presetScope *jsonapi.Scope,
+	req *http.Request,
+	rw http.ResponseWriter,
+) (values []interface{}, err error) {
+	defer h.traceSpan(rw, "jsonapi.preset", h.modelAttributes(presetScope.Struct.GetType())...)()
+
+	if err = h.addTrashedFilter(presetScope, TrashedWithout); err != nil {
+		h.logger(rw, SubsystemFilters).Errorf("Cannot add trashed filter for preset scope: '%v'. %v", presetScope.Struct.GetType(), err)
+		h.MarshalInternalError(rw)
+		err = newHandlerError(ErrAlreadyWritten, err.Error())
+		return
 	}
 
-	repo := h.GetRepositoryByType(presetScope.Struct.GetType())
+	repo := h.repository(rw, presetScope.Struct.GetType())
 
 	presetScope.NewValueMany()
 
-	if errObj := h.HookBeforeReader(presetScope); errObj != nil {
-		h.MarshalErrors(rw)
+	presetModel := h.ModelHandlers[presetScope.Struct.GetType()]
+	if errObj := h.runHooks(presetModel, nil, BeforeRead, req, presetScope); errObj != nil {
+		h.MarshalErrors(rw, errObj)
 		err = newHandlerError(ErrAlreadyWritten, errObj.Error())
 		return
 	}
 
 	dbErr := repo.List(presetScope)
 	if dbErr != nil {
-		h.manageDBError(rw, dbErr)
+		h.manageDBError(rw, presetScope, dbErr)
 		err = newHandlerError(ErrAlreadyWritten, dbErr.Message)
 		return
 	}
-	v := reflect.ValueOf(presetScope.Value)
-	for i := 0; i < v.Len(); i++ {
-		h.log.Debugf("Value of presetscope: %+v at Index: %v", v.Index(i).Interface(), i)
-	}
-
-	if errObj := h.HookAfterReader(presetScope); errObj != nil {
-		h.MarshalErrors(rw)
+	if errObj := h.runHooks(presetModel, nil, AfterRead, req, presetScope); errObj != nil {
+		h.MarshalErrors(rw, errObj)
 		err = newHandlerError(ErrAlreadyWritten, errObj.Error())
 		return
 	}
@@ -162,7 +172,6 @@ func (h *JSONAPIHandler) GetPresetValues(
 			return
 		}
 		if len(missing) == 0 {
-			h.log.Debugf("Missing error")
 			hErr := newHandlerError(ErrNoValues, "")
 			err = hErr
 			return
@@ -172,7 +181,7 @@ func (h *JSONAPIHandler) GetPresetValues(
 		field.Scope.SetIDFilters(missing...)
 
 		if len(field.Scope.IncludedFields) != 0 {
-			values, err = h.GetPresetValues(field.Scope, rw)
+			values, err = h.getPresetValues(field.Scope, req, rw)
 			if err != nil {
 				return
 			}
@@ -194,7 +203,7 @@ func (h *JSONAPIHandler) PresetScopeValue(
 ) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			h.log.Errorf("Panic within preset scope value. %v. %v", r, string(debug.Stack()))
+			h.logger(nil, SubsystemFilters).Errorf("Panic within preset scope value. %v. %v", r, string(debug.Stack()))
 			err = IErrPresetInvalidScope
 		}
 	}()
@@ -246,7 +255,7 @@ func (h *JSONAPIHandler) PresetScopeValue(
 			}
 
 			if len(values) > 1 {
-				h.log.Errorf("Provided values length is greatern than 1 but the field is not of slice type. Presetting only the first value. Field: '%s'.", field.String())
+				h.logger(nil, SubsystemFilters).Errorf("Provided values length is greatern than 1 but the field is not of slice type. Presetting only the first value. Field: '%s'.", field.String())
 			}
 
 		case jsonapi.RelationshipMultiple:
@@ -276,7 +285,7 @@ func (h *JSONAPIHandler) PresetScopeValue(
 				field = reflect.Append(field, relatedField)
 			}
 		default:
-			h.log.Error("Relationship filter is of invalid type. Model: '%s'. Field: '%s'", scope.Struct.GetType().Name(), field.String())
+			h.logger(nil, SubsystemFilters).Errorf("Relationship filter is of invalid type. Model: '%s'. Field: '%s'", scope.Struct.GetType().Name(), field.String())
 			return IErrPresetInvalidScope
 		}
 
@@ -301,7 +310,7 @@ func (h *JSONAPIHandler) PresetScopeValue(
 		}
 
 		if len(values) > 1 {
-			h.log.Warningf("Provided values length is greatern than 1 but the field is not of slice type. Presetting only the first value. Field: '%s'.", field.String())
+			h.logger(nil, SubsystemFilters).Warningf("Provided values length is greatern than 1 but the field is not of slice type. Presetting only the first value. Field: '%s'.", field.String())
 		}
 	}
 	return nil
@@ -317,13 +326,13 @@ func (h *JSONAPIHandler) SetPresetFilters(
 	for _, filter := range filters {
 		if value := req.Context().Value(filter.Key); value != nil {
 			if err := h.SetPresetFilterValues(filter.FilterField, value); err != nil {
-				h.log.Errorf("Cannot set preset filter values. Model: %v, Filterfield: %v, Value: %v, Path: %v. Error: %v", model.ModelType.Name(), filter.GetFieldName(), value, req.URL.Path, err)
+				h.logger(rw, SubsystemFilters).Errorf("Cannot set preset filter values. Model: %v, Filterfield: %v, Value: %v, Path: %v. Error: %v", model.ModelType.Name(), filter.GetFieldName(), value, req.URL.Path, err)
 				h.MarshalInternalError(rw)
 				return
 			}
 		}
 		if err := scope.AddFilterField(filter.FilterField); err != nil {
-			h.log.Errorf("Cannot add filter field. Path: %v, Error: %v", req.URL.Path, err)
+			h.logger(rw, SubsystemFilters).Errorf("Cannot add filter field. Path: %v, Error: %v", req.URL.Path, err)
 			h.MarshalInternalError(rw)
 			return
 		}
@@ -372,10 +381,9 @@ func (h *JSONAPIHandler) getPrecheckFilter(
 	req *http.Request,
 	model *ModelHandler,
 ) (exists bool) {
-	h.log.Debugf("Key value: %+v", key)
 	precheckValue := req.Context().Value(key)
 	if precheckValue == nil {
-		h.log.Warningf("Empty preset value in precheckpair for model: %s", model.ModelType.Name())
+		h.logger(nil, SubsystemFilters).Warningf("Empty preset value in precheckpair for model: %s", model.ModelType.Name())
 		return
 	}
 
@@ -383,7 +391,7 @@ func (h *JSONAPIHandler) getPrecheckFilter(
 	if !ok {
 		presetFilter, ok := precheckValue.(*jsonapi.PresetFilter)
 		if !ok {
-			h.log.Warningf("PrecheckValue is not a jsonapi.FilterField. Model: %v, endpoint: CREATE", model.ModelType.Name())
+			h.logger(nil, SubsystemFilters).Warningf("PrecheckValue is not a jsonapi.FilterField. Model: %v, endpoint: CREATE", model.ModelType.Name())
 			return
 		}
 		precheckFilter = presetFilter.FilterField
@@ -0,0 +1,88 @@
+package jsonapisdk
+
+import (
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"golang.org/x/text/language"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+)
+
+// HandlePanic handles the value recovered from the panic within the handling of the 'req'.
+// The panic is logged with the stack and the request's method and path and the '500' Internal
+// Server Error document with the error's id is written to the 'rw'.
+// If the response headers were already written, only the error document is written. If the
+// response body was partially written, the valid document could not be written anymore, so that
+// the handler is aborted with the http.ErrAbortHandler panic.
+func (h *JSONAPIHandler) HandlePanic(rw http.ResponseWriter, req *http.Request, recovered interface{}) {
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	headerWritten, bodyWritten := responseWritten(rw)
+	if requestOf(rw) == nil && req != nil {
+		rw = newResponseWriter(rw, req)
+	}
+
+	errObj := jsonapi.ErrInternalError.Copy()
+	errObj.ID = newID()
+
+	var method, path string
+	if req != nil {
+		method, path = req.Method, req.URL.Path
+	}
+	h.logger(rw, SubsystemEndpoint).log(LogError, fmt.Sprintf("Panic recovered: %v", recovered),
+		"error_id", errObj.ID,
+		"method", method,
+		"path", strconv.Quote(path),
+		"stack", strconv.Quote(string(debug.Stack())),
+	)
+
+	switch {
+	case bodyWritten:
+		panic(http.ErrAbortHandler)
+	case headerWritten:
+		errors := h.translateErrors(rw, language.English, errObj)
+		setErrorCode(rw, errors...)
+		if err := marshalErrors(rw, errors...); err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Error while marshaling errors: %v", err)
+		}
+	default:
+		h.MarshalErrors(rw, errObj)
+	}
+}
+
+// Recoverer wraps the 'next' handler, so that the panics are recovered by the HandlePanic method.
+// The handlers created by the JSONAPIHandler recover the panics by themselves.
+func (h *JSONAPIHandler) Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if r := recover(); r != nil {
+				h.HandlePanic(rw, req, r)
+			}
+		}()
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// sizeWriter is the http.ResponseWriter that keeps the information about the written response
+// i.e. gin.ResponseWriter.
+type sizeWriter interface {
+	Written() bool
+	Size() int
+}
+
+// responseWritten checks if the headers and the body were already written to the 'rw'.
+// If the 'rw' does not keep such information it is assumed that nothing was written.
+func responseWritten(rw http.ResponseWriter) (header, body bool) {
+	switch w := rw.(type) {
+	case *responseWriter:
+		return w.status != 0, w.size > 0
+	case *responseRecorder:
+		return w.status != 0, w.body.Len() > 0
+	case sizeWriter:
+		return w.Written(), w.Size() > 0
+	}
+	return false, false
+}
@@ -0,0 +1,70 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHandlerPanicRecovery(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	doc := struct {
+		Errors []*jsonapi.ErrorObject `json:"errors"`
+	}{}
+
+	// Case 1:
+	// Panic within the repository
+	rw, req := getHttpPair("GET", "/blogs/1", nil)
+	mockRepo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			panic("repository panic")
+		})
+	assert.NotPanics(t, func() { h.Get(model, model.Get).ServeHTTP(rw, req) })
+	assert.Equal(t, 500, rw.Code)
+	assert.Equal(t, jsonapi.MediaType, rw.Header().Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	if assert.Len(t, doc.Errors, 1) {
+		assert.Equal(t, jsonapi.ErrInternalError.Code, doc.Errors[0].Code)
+		assert.NotEmpty(t, doc.Errors[0].ID)
+	}
+
+	// Case 2:
+	// The headers were already written
+	rw, req = getHttpPair("GET", "/blogs/1", nil)
+	h.wrapHandler(model, Get, func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+		panic("after header")
+	}).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusAccepted, rw.Code)
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	assert.Len(t, doc.Errors, 1)
+
+	// Case 3:
+	// The body was partially written
+	rw, req = getHttpPair("GET", "/blogs/1", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.wrapHandler(model, Get, func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(`{"data":`))
+			panic("after body")
+		}).ServeHTTP(rw, req)
+	})
+}
+
+func TestRecoverer(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+
+	rw, req := getHttpPair("GET", "/custom", nil)
+	h.Recoverer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		panic("custom handler panic")
+	})).ServeHTTP(rw, req)
+	assert.Equal(t, 500, rw.Code)
+	assert.Equal(t, jsonapi.MediaType, rw.Header().Get("Content-Type"))
+}
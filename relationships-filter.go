@@ -7,8 +7,6 @@ import (
 )
 
 func (h *JSONAPIHandler) GetRelationshipFilters(scope *jsonapi.Scope, req *http.Request, rw http.ResponseWriter) error {
-
-	h.log.Debug("-------Getting Relationship Filters--------")
 	// every relationship filter is for different field
 	// replace the filter with the preset values of id field
 	// so that the repository should not handle the relationship filter
@@ -45,7 +43,7 @@ func (h *JSONAPIHandler) GetRelationshipFilters(scope *jsonapi.Scope, req *http.
 						continue
 					}
 				}
-				values, err := h.GetPresetValues(precheckScope, rw)
+				values, err := h.getPresetValues(precheckScope, req, rw)
 				if err != nil {
 					if hErr := err.(*HandlerError); hErr != nil {
 						return hErr
@@ -85,7 +83,7 @@ func (h *JSONAPIHandler) GetRelationshipFilters(scope *jsonapi.Scope, req *http.
 				attrFilter = true
 
 			default:
-				h.log.Warningf("The subfield of the filter cannot be of relationship filter type. Model: '%s',", scope.Struct.GetType().Name(), req.URL.Path)
+				h.logger(rw, SubsystemFilters).Warningf("The subfield of the filter cannot be of relationship filter type. Model: '%s'. Path: '%s'.", scope.Struct.GetType().Name(), req.URL.Path)
 			}
 		}
 
@@ -93,25 +91,32 @@ func (h *JSONAPIHandler) GetRelationshipFilters(scope *jsonapi.Scope, req *http.
 			continue
 		}
 
+		if err = h.addTrashedFilter(relationshipScope, TrashedWithout); err != nil {
+			hErr := newHandlerError(ErrInternal, err.Error())
+			hErr.Model = relationshipScope.Struct
+			return hErr
+		}
+
 		// Get the relationship scope
 		relationshipScope.NewValueMany()
 
-		if errObj := h.HookBeforeReader(relationshipScope); errObj != nil {
+		relationshipModel := h.ModelHandlers[relationshipScope.Struct.GetType()]
+		if errObj := h.runHooks(relationshipModel, nil, BeforeRead, req, relationshipScope); errObj != nil {
 			return errObj
 		}
 
-		dbErr := h.GetRepositoryByType(relationshipScope.Struct.GetType()).List(relationshipScope)
+		dbErr := h.repository(rw, relationshipScope.Struct.GetType()).List(relationshipScope)
 		if dbErr != nil {
 			return dbErr
 		}
 
-		if errObj := h.HookAfterReader(relationshipScope); errObj != nil {
+		if errObj := h.runHooks(relationshipModel, nil, AfterRead, req, relationshipScope); errObj != nil {
 			return errObj
 		}
 
 		values, err := relationshipScope.GetPrimaryFieldValues()
 		if err != nil {
-			h.log.Debugf("GetPrimaryFieldValues error within GetRelationship function. %v", err)
+			h.logger(rw, SubsystemFilters).Debugf("GetPrimaryFieldValues error within GetRelationship function. %v", err)
 			hErr := newHandlerError(ErrBadValues, err.Error())
 			hErr.Model = relFilter.GetRelatedModelStruct()
 			return hErr
@@ -0,0 +1,147 @@
+package gormrepo
+
+import (
+	"github.com/kucjac/jsonapi-sdk"
+	"github.com/kucjac/uni-db"
+	"github.com/kucjac/uni-db/gormconv"
+	"regexp"
+	"strings"
+)
+
+// postgresError is implemented by the postgres driver's '*pq.Error'. The Get method gets the
+// error's fields by their protocol codes.
+type postgresError interface {
+	Get(code byte) string
+}
+
+// postgresFields are the postgres error fields added to the converted error's message, named
+// like within the psql verbose errors. The postgres messages contain only the constraint name.
+var postgresFields = []struct {
+	code byte
+	name string
+}{
+	{'D', "DETAIL"},
+	{'t', "TABLE NAME"},
+	{'c', "COLUMN NAME"},
+	{'n', "CONSTRAINT NAME"},
+}
+
+// errorConverter converts the gorm errors into the *unidb.Error. The postgres errors' messages
+// are extended by their detail, table, column and constraint fields, so that the constraint
+// is resolved with its columns.
+type errorConverter struct {
+	*gormconv.GORMConverter
+}
+
+// Convert converts the 'err' into the *unidb.Error.
+func (c *errorConverter) Convert(err error) *unidb.Error {
+	dbErr := c.GORMConverter.Convert(err)
+	if pgErr, ok := err.(postgresError); ok && dbErr != nil {
+		for _, field := range postgresFields {
+			if value := pgErr.Get(field.code); value != "" {
+				dbErr.Message += "\n" + field.name + ": " + value
+			}
+		}
+	}
+	return dbErr
+}
+
+// constraintPattern matches the database error message and gets the constraint information.
+type constraintPattern struct {
+	re *regexp.Regexp
+
+	// constraint, table and columns are the indexes of the regexp submatches. Zero means
+	// that the message does not contain the value.
+	constraint, table, columns int
+}
+
+var constraintPatterns = []*constraintPattern{
+	// postgres
+	{re: regexp.MustCompile(`violates (?:unique|foreign key|check|exclusion) constraint "([^"]+)"`), constraint: 1},
+	{re: regexp.MustCompile(`null value in column "([^"]+)"(?: of relation "([^"]+)")? violates not-null constraint`), columns: 1, table: 2},
+
+	// mysql
+	{re: regexp.MustCompile(`Duplicate entry '.*' for key '([^']+)'`), constraint: 1},
+	{re: regexp.MustCompile("foreign key constraint fails \\(`[^`]+`\\.`([^`]+)`, CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`\\)"), table: 1, constraint: 2, columns: 3},
+	{re: regexp.MustCompile(`Column '([^']+)' cannot be null`), columns: 1},
+
+	// sqlite
+	{re: regexp.MustCompile(`(?:UNIQUE|NOT NULL) constraint failed: (.+)$`), columns: 1},
+	{re: regexp.MustCompile(`CHECK constraint failed: (.+)$`), constraint: 1},
+}
+
+var (
+	// postgresDetailKey matches the columns within the postgres unique and foreign key violations'
+	// detail i.e. 'Key (email)=(john@example.com) already exists.'
+	postgresDetailKey = regexp.MustCompile(`(?m)^DETAIL: Key \(([^)]+)\)=`)
+	postgresTable     = regexp.MustCompile(`(?m)^TABLE NAME: (.+)$`)
+	postgresColumn    = regexp.MustCompile(`(?m)^COLUMN NAME: (.+)$`)
+)
+
+// ResolveConstraint implements jsonapisdk.ConstraintResolver. It parses the postgres, mysql and
+// sqlite error messages for the constraint, table and column names.
+func (g *GORMRepository) ResolveConstraint(dbErr *unidb.Error) *jsonapisdk.DBConstraint {
+	if dbErr == nil {
+		return nil
+	}
+	return parseConstraint(dbErr.Message)
+}
+
+func parseConstraint(message string) *jsonapisdk.DBConstraint {
+	for _, pattern := range constraintPatterns {
+		matches := pattern.re.FindStringSubmatch(message)
+		if matches == nil {
+			continue
+		}
+
+		constraint := &jsonapisdk.DBConstraint{}
+		if pattern.constraint != 0 {
+			constraint.Name = matches[pattern.constraint]
+			// mysql 8 prefixes the key with the table name
+			if i := strings.LastIndex(constraint.Name, "."); i != -1 {
+				constraint.Table = constraint.Name[:i]
+				constraint.Name = constraint.Name[i+1:]
+			}
+		}
+
+		if pattern.table != 0 && matches[pattern.table] != "" {
+			constraint.Table = matches[pattern.table]
+		}
+
+		if pattern.columns != 0 {
+			for _, column := range strings.Split(matches[pattern.columns], ",") {
+				column = strings.TrimSpace(column)
+				// sqlite provides the columns as 'table.column'
+				if i := strings.Index(column, "."); i != -1 {
+					constraint.Table = column[:i]
+					column = column[i+1:]
+				}
+				constraint.Columns = append(constraint.Columns, column)
+			}
+		}
+		addPostgresFields(constraint, message)
+		return constraint
+	}
+	return nil
+}
+
+// addPostgresFields sets the constraint's table and columns from the postgres error fields
+// added to the 'message' by the errorConverter.
+func addPostgresFields(constraint *jsonapisdk.DBConstraint, message string) {
+	if matches := postgresTable.FindStringSubmatch(message); matches != nil && constraint.Table == "" {
+		constraint.Table = matches[1]
+	}
+	if len(constraint.Columns) != 0 {
+		return
+	}
+
+	if matches := postgresColumn.FindStringSubmatch(message); matches != nil {
+		constraint.Columns = []string{matches[1]}
+		return
+	}
+	if matches := postgresDetailKey.FindStringSubmatch(message); matches != nil {
+		for _, column := range strings.Split(matches[1], ",") {
+			constraint.Columns = append(constraint.Columns, strings.Trim(strings.TrimSpace(column), `"`))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package gormrepo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseConstraint(t *testing.T) {
+	cases := []struct {
+		message    string
+		constraint string
+		table      string
+		columns    []string
+	}{
+		{`pq: duplicate key value violates unique constraint "users_email_key"`, "users_email_key", "", nil},
+		{"pq: duplicate key value violates unique constraint \"users_email_key\"\nDETAIL: Key (email)=(john@example.com) already exists.\nTABLE NAME: users\nCONSTRAINT NAME: users_email_key", "users_email_key", "users", []string{"email"}},
+		{"pq: duplicate key value violates unique constraint \"users_name_surname_key\"\nDETAIL: Key (name, surname)=(John, Doe) already exists.", "users_name_surname_key", "", []string{"name", "surname"}},
+		{"pq: insert or update on table \"pets\" violates foreign key constraint \"pets_owner_id_fkey\"\nDETAIL: Key (owner_id)=(3) is not present in table \"users\".\nTABLE NAME: pets", "pets_owner_id_fkey", "pets", []string{"owner_id"}},
+		{"pq: null value in column \"email\" violates not-null constraint\nTABLE NAME: users\nCOLUMN NAME: email", "", "users", []string{"email"}},
+		{`pq: insert or update on table "pets" violates foreign key constraint "pets_owner_id_fkey"`, "pets_owner_id_fkey", "", nil},
+		{`pq: null value in column "email" violates not-null constraint`, "", "", []string{"email"}},
+		{`Error 1062: Duplicate entry 'john@example.com' for key 'users.idx_email'`, "idx_email", "users", nil},
+		{"Error 1452: Cannot add or update a child row: a foreign key constraint fails (`db`.`pets`, CONSTRAINT `fk_owner` FOREIGN KEY (`owner_id`) REFERENCES `users` (`id`))", "fk_owner", "pets", []string{"owner_id"}},
+		{`Error 1048: Column 'email' cannot be null`, "", "", []string{"email"}},
+		{`UNIQUE constraint failed: users.name, users.surname`, "", "users", []string{"name", "surname"}},
+		{`NOT NULL constraint failed: users.email`, "", "users", []string{"email"}},
+	}
+
+	for _, c := range cases {
+		constraint := parseConstraint(c.message)
+		if assert.NotNil(t, constraint, c.message) {
+			assert.Equal(t, c.constraint, constraint.Name, c.message)
+			assert.Equal(t, c.table, constraint.Table, c.message)
+			assert.Equal(t, c.columns, constraint.Columns, c.message)
+		}
+	}
+
+	assert.Nil(t, parseConstraint("FOREIGN KEY constraint failed"))
+}
@@ -1,13 +1,34 @@
 package gormrepo
 
 import (
+	"fmt"
+	"github.com/jinzhu/gorm"
 	"github.com/kucjac/jsonapi"
 	"github.com/kucjac/jsonapi-sdk/repositories"
 	"github.com/kucjac/uni-db"
 	"reflect"
+	"time"
 )
 
 func (g *GORMRepository) Create(scope *jsonapi.Scope) *unidb.Error {
+	v := reflect.ValueOf(scope.Value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		dbErr := unidb.ErrInternalError.New()
+		dbErr.Message = fmt.Sprintf("Invalid scope value for create: '%T'", scope.Value)
+		return dbErr
+	}
+	v = v.Elem()
+
+	/**
+
+	  CREATE: BEGIN TRANSACTION
+
+	*/
+	tx := g.begin()
+	if err := tx.Error; err != nil {
+		return g.converter.Convert(err)
+	}
+	defer g.rollbackOnPanic(tx)
 
 	/**
 
@@ -15,21 +36,57 @@ func (g *GORMRepository) Create(scope *jsonapi.Scope) *unidb.Error {
 
 	*/
 	if beforeCreate, ok := scope.Value.(repositories.HookRepoBeforeCreate); ok {
-		if err := beforeCreate.RepoBeforeCreate(g.db.New(), scope); err != nil {
+		if err := beforeCreate.RepoBeforeCreate(tx.New(), scope); err != nil {
+			g.rollback(tx)
 			return g.converter.Convert(err)
 		}
 	}
 
+	/**
+
+	  CREATE: SET BELONGS TO FOREIGN KEYS
+
+	*/
+	mStruct := tx.NewScope(scope.Value).GetModelStruct()
+	relationships := createRelationships(scope, v)
+	if err := setBelongsToKeys(v, mStruct, relationships); err != nil {
+		g.rollback(tx)
+		dbErr := unidb.ErrInternalError.New()
+		dbErr.Message = err.Error()
+		return dbErr
+	}
+
 	/**
 
 	  CREATE: DB CREATE
 
 	*/
-	err := g.db.Create(scope.GetValueAddress()).Error
+	err := tx.Set(gormSaveAssociations, false).Create(scope.GetValueAddress()).Error
 	if err != nil {
+		g.rollback(tx)
 		return g.converter.Convert(err)
 	}
 
+	/**
+
+	  CREATE: RELATIONSHIPS LINKAGE
+
+	*/
+	if len(relationships) > 0 {
+		ownerKey, err := ownerPrimary(scope, v)
+		if err != nil {
+			g.rollback(tx)
+			dbErr := unidb.ErrInternalError.New()
+			dbErr.Message = err.Error()
+			return dbErr
+		}
+
+		if err = syncRelationships(tx, v, mStruct, relationships, ownerKey); err != nil {
+			g.rollback(tx)
+			return g.syncError(err)
+		}
+	}
+
 	/**
 
 	  CREATE: HOOK AFTER CREATE
@@ -37,11 +94,21 @@ func (g *GORMRepository) Create(scope *jsonapi.Scope) *unidb.Error {
 	*/
 
 	if afterCreate, ok := scope.Value.(repositories.HookRepoAfterCreate); ok {
-		if err := afterCreate.RepoAfterCreate(g.db.New(), scope); err != nil {
+		if err := afterCreate.RepoAfterCreate(tx.New(), scope); err != nil {
+			g.rollback(tx)
 			return g.converter.Convert(err)
 		}
 	}
 
+	/**
+
+	  CREATE: COMMIT TRANSACTION
+
+	*/
+	if err := g.commit(tx); err != nil {
+		return g.converter.Convert(err)
+	}
+
 	return nil
 
 }
@@ -102,6 +169,67 @@ func (g *GORMRepository) Get(scope *jsonapi.Scope) *unidb.Error {
 	return nil
 }
 
+// LastModified gets the latest value of the model's 'field' for the rows matching the scope's
+// filters. The 'field' is the name of the model's time struct field i.e. 'UpdatedAt'.
+// It does not query any other column than the 'field' and does not get the relationships.
+func (g *GORMRepository) LastModified(scope *jsonapi.Scope, field string) (time.Time, *unidb.Error) {
+	/**
+
+	  LAST MODIFIED: PREPARE GORM SCOPE
+
+	*/
+	single := reflect.New(scope.Struct.GetType())
+	gormScope := g.db.NewScope(single.Interface())
+	mStruct := gormScope.GetModelStruct()
+
+	var gormField *gorm.StructField
+	for _, gField := range mStruct.StructFields {
+		if gField.Name == field {
+			gormField = gField
+			break
+		}
+	}
+
+	if gormField == nil || gormField.IsIgnored {
+		errObj := unidb.ErrInternalError.New()
+		errObj.Message = fmt.Sprintf("LastModified field: '%s' not found within model: '%v'", field, mStruct.ModelType)
+		return time.Time{}, errObj
+	}
+
+	db := gormScope.DB()
+	if err := buildFilters(db, mStruct, scope); err != nil {
+		errObj := unidb.ErrInternalError.New()
+		errObj.Message = err.Error()
+		return time.Time{}, errObj
+	}
+
+	/**
+
+	  LAST MODIFIED: GET FROM DB
+
+	*/
+	err := db.Select(gormField.DBName).Order(gormField.DBName + " DESC").First(single.Interface()).Error
+	if err != nil {
+		return time.Time{}, g.converter.Convert(err)
+	}
+
+	fieldValue := single.Elem().FieldByIndex(gormField.Struct.Index)
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return time.Time{}, nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	lastModified, ok := fieldValue.Interface().(time.Time)
+	if !ok {
+		errObj := unidb.ErrInternalError.New()
+		errObj.Message = fmt.Sprintf("LastModified field: '%s' is not a time.Time field. Model: '%v'", field, mStruct.ModelType)
+		return time.Time{}, errObj
+	}
+	return lastModified, nil
+}
+
 func (g *GORMRepository) List(scope *jsonapi.Scope) *unidb.Error {
 	if scope.Value == nil {
 		scope.NewValueMany()
@@ -119,7 +247,11 @@ func (g *GORMRepository) List(scope *jsonapi.Scope) *unidb.Error {
 		errObj.Message = err.Error()
 		return errObj
 	}
+	return g.find(scope, gormScope)
+}
 
+// find finds the listed resources with the built 'gormScope' and gets their relationships.
+func (g *GORMRepository) find(scope *jsonapi.Scope, gormScope *gorm.Scope) *unidb.Error {
 	db := gormScope.DB()
 
 	/**
@@ -128,7 +260,7 @@ func (g *GORMRepository) List(scope *jsonapi.Scope) *unidb.Error {
 
 	*/
 
-	err = db.Find(scope.GetValueAddress()).Error
+	err := db.Find(scope.GetValueAddress()).Error
 	if err != nil {
 		return g.converter.Convert(err)
 	}
@@ -185,13 +317,25 @@ func (g *GORMRepository) Patch(scope *jsonapi.Scope) *unidb.Error {
 		return dbErr
 	}
 
+	/**
+
+	  PATCH: BEGIN TRANSACTION
+
+	*/
+	tx := g.begin()
+	if err := tx.Error; err != nil {
+		return g.converter.Convert(err)
+	}
+	defer g.rollbackOnPanic(tx)
+
 	/**
 
 	  PATCH: PREPARE GORM SCOPE
 
 	*/
-	gormScope := g.db.NewScope(scope.Value)
+	gormScope := tx.Set(gormSaveAssociations, false).NewScope(scope.Value)
 	if err := buildFilters(gormScope.DB(), gormScope.GetModelStruct(), scope); err != nil {
+		g.rollback(tx)
 		return g.converter.Convert(err)
 	}
 
@@ -201,7 +345,8 @@ func (g *GORMRepository) Patch(scope *jsonapi.Scope) *unidb.Error {
 
 	*/
 	if beforePatcher, ok := scope.Value.(repositories.HookRepoBeforePatch); ok {
-		if err := beforePatcher.RepoBeforePatch(g.db.New(), scope); err != nil {
+		if err := beforePatcher.RepoBeforePatch(tx.New(), scope); err != nil {
+			g.rollback(tx)
 			return g.converter.Convert(err)
 		}
 	}
@@ -210,14 +355,62 @@ func (g *GORMRepository) Patch(scope *jsonapi.Scope) *unidb.Error {
 
 	  PATCH: UPDATE RECORD WITIHN DATABASE
 
+	  Only the columns for the fields within the scope's fieldset are updated. The map is used
+	  so that the zero and nil values are also written.
 	*/
-	db := gormScope.DB().Update(scope.GetValueAddress())
-	if err := db.Error; err != nil {
-		return g.converter.Convert(err)
+	updates, err := patchColumns(scope, gormScope.GetModelStruct())
+	if err != nil {
+		g.rollback(tx)
+		dbErr := unidb.ErrInternalError.New()
+		dbErr.Message = err.Error()
+		return dbErr
 	}
 
-	if db.RowsAffected == 0 {
-		return unidb.ErrNoResult.New()
+	if len(updates) == 0 {
+		// nothing to update, check if the record exists
+		var count int
+		if err := gormScope.DB().Model(scope.Value).Count(&count).Error; err != nil {
+			g.rollback(tx)
+			return g.converter.Convert(err)
+		}
+
+		if count == 0 {
+			g.rollback(tx)
+			return unidb.ErrNoResult.New()
+		}
+	} else {
+		db := gormScope.DB().Model(scope.Value).Updates(updates)
+		if err := db.Error; err != nil {
+			g.rollback(tx)
+			return g.converter.Convert(err)
+		}
+
+		if db.RowsAffected == 0 {
+			g.rollback(tx)
+			return unidb.ErrNoResult.New()
+		}
+	}
+
+	/**
+
+	  PATCH: RELATIONSHIPS LINKAGE
+
+	  The belongs to relationships are already patched within the foreign key columns.
+	*/
+	if relationships := patchRelationships(scope); len(relationships) > 0 {
+		v := reflect.ValueOf(scope.Value).Elem()
+		ownerKey, err := ownerPrimary(scope, v)
+		if err != nil {
+			g.rollback(tx)
+			dbErr := unidb.ErrInternalError.New()
+			dbErr.Message = err.Error()
+			return dbErr
+		}
+
+		if err = syncRelationships(tx, v, gormScope.GetModelStruct(), relationships, ownerKey); err != nil {
+			g.rollback(tx)
+			return g.syncError(err)
+		}
 	}
 
 	/**
@@ -226,11 +419,21 @@ func (g *GORMRepository) Patch(scope *jsonapi.Scope) *unidb.Error {
 
 	*/
 	if afterPatcher, ok := scope.Value.(repositories.HookRepoAfterPatch); ok {
-		if err := afterPatcher.RepoAfterPatch(g.db.New(), scope); err != nil {
+		if err := afterPatcher.RepoAfterPatch(tx.New(), scope); err != nil {
+			g.rollback(tx)
 			return g.converter.Convert(err)
 		}
 	}
 
+	/**
+
+	  PATCH: COMMIT TRANSACTION
+
+	*/
+	if err := g.commit(tx); err != nil {
+		return g.converter.Convert(err)
+	}
+
 	return nil
 }
 
@@ -288,3 +491,55 @@ func (g *GORMRepository) Delete(scope *jsonapi.Scope) *unidb.Error {
 
 	return nil
 }
+
+// patchColumns gets the column - value map for the fields within the scope's fieldset.
+// The relationship fields are represented by their belongs to foreign keys.
+func patchColumns(scope *jsonapi.Scope, mStruct *gorm.ModelStruct) (map[string]interface{}, error) {
+	v := reflect.ValueOf(scope.Value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, fmt.Errorf("Invalid scope value for patch: '%v'", v.Type())
+	}
+	v = v.Elem()
+
+	updates := make(map[string]interface{})
+	for _, field := range scope.Fieldset {
+		var gormField *gorm.StructField
+		for _, gField := range mStruct.StructFields {
+			if gField.Struct.Index[0] == field.GetFieldIndex() {
+				gormField = gField
+				break
+			}
+		}
+
+		if gormField == nil || gormField.IsIgnored {
+			continue
+		}
+
+		if !field.IsRelationship() {
+			if gormField.IsPrimaryKey {
+				continue
+			}
+			updates[gormField.DBName] = v.Field(field.GetFieldIndex()).Interface()
+			continue
+		}
+
+		rel := gormField.Relationship
+		if rel == nil || rel.Kind != annotationBelongsTo || field.GetFieldKind() != jsonapi.RelationshipSingle {
+			continue
+		}
+
+		// set the foreign key from the related primary value or NULL.
+		related := v.Field(field.GetFieldIndex())
+		if related.IsNil() {
+			updates[rel.ForeignDBNames[0]] = nil
+			continue
+		}
+
+		relatedPrim := related.Elem().FieldByName(rel.AssociationForeignFieldNames[0])
+		if !relatedPrim.IsValid() {
+			return nil, fmt.Errorf("Association foreign field: '%s' not found for field: '%s'", rel.AssociationForeignFieldNames[0], field.GetFieldName())
+		}
+		updates[rel.ForeignDBNames[0]] = relatedPrim.Interface()
+	}
+	return updates, nil
+}
@@ -27,6 +27,18 @@ func addWhere(db *gorm.DB, columnName string, filter *jsonapi.FilterField) error
 		if len(fv.Values) == 0 {
 			return IErrNoValuesProvided
 		}
+		// nil value for the equal operators stands for the 'IS NULL' and 'IS NOT NULL' conditions.
+		if len(fv.Values) == 1 && fv.Values[0] == nil {
+			switch fv.Operator {
+			case jsonapi.OpEqual:
+				*db = *db.Where(fmt.Sprintf("%s IS NULL", columnName))
+				continue
+			case jsonapi.OpNotEqual:
+				*db = *db.Where(fmt.Sprintf("%s IS NOT NULL", columnName))
+				continue
+			}
+		}
+
 		op := sqlizeOperator(fv.Operator)
 		var valueMark string
 		if fv.Operator == jsonapi.OpIn || fv.Operator == jsonapi.OpNotIn {
@@ -23,7 +23,10 @@ var (
 
 type GORMRepository struct {
 	db        *gorm.DB
-	converter *gormconv.GORMConverter
+	converter *errorConverter
+
+	// inTx defines if the repository is bound to the transaction started by the Begin method.
+	inTx bool
 }
 
 func New(db *gorm.DB) (*GORMRepository, error) {
@@ -43,10 +46,11 @@ func (g *GORMRepository) initialize(db *gorm.DB) (err error) {
 	g.db = db
 
 	// Get Error converter
-	g.converter, err = gormconv.New(db)
+	converter, err := gormconv.New(db)
 	if err != nil {
 		return err
 	}
+	g.converter = &errorConverter{GORMConverter: converter}
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/kucjac/jsonapi"
 	"github.com/kucjac/jsonapi-sdk"
+	"github.com/kucjac/uni-db"
 	"github.com/kucjac/uni-logger"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/text/language"
@@ -146,6 +147,122 @@ func TestGORMRepositoryList(t *testing.T) {
 
 }
 
+func TestGORMRepositoryPatch(t *testing.T) {
+	c, err := prepareJSONAPI(&UserGORM{}, &PetGORM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer clearDB()
+	repo, err := prepareGORMRepo(&UserGORM{}, &PetGORM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, settleUsers(db))
+
+	// Case 1:
+	// Zero value within the fieldset is patched, other fields are not changed
+	scope, err := c.NewScope(&UserGORM{ID: 2})
+	assert.NoError(t, err)
+	scope.SetIDFilters(2)
+	scope.Fieldset = map[string]*jsonapi.StructField{"surname": scope.Struct.GetAttributeField("surname")}
+
+	dbErr := repo.Patch(scope)
+	assert.Nil(t, dbErr)
+
+	user := &UserGORM{}
+	assert.NoError(t, db.First(user, 2).Error)
+	assert.Equal(t, "", user.Surname)
+	assert.Equal(t, "Mathew", user.Name)
+
+	// Case 2:
+	// Not existing record
+	scope, err = c.NewScope(&UserGORM{ID: 10})
+	assert.NoError(t, err)
+	scope.SetIDFilters(10)
+	scope.Fieldset = map[string]*jsonapi.StructField{"name": scope.Struct.GetAttributeField("name")}
+
+	dbErr = repo.Patch(scope)
+	if assert.NotNil(t, dbErr) {
+		assert.True(t, dbErr.Compare(unidb.ErrNoResult))
+	}
+
+	// Case 3:
+	// Has many relationship is replaced
+	scope, err = c.NewScope(&UserGORM{ID: 2, Pets: []*PetGORM{{ID: 1}}})
+	assert.NoError(t, err)
+	scope.SetIDFilters(2)
+	scope.Fieldset = map[string]*jsonapi.StructField{"pets": scope.Struct.GetRelationshipField("pets")}
+
+	dbErr = repo.Patch(scope)
+	assert.Nil(t, dbErr)
+
+	pet := &PetGORM{}
+	assert.NoError(t, db.First(pet, 1).Error)
+	assert.Equal(t, uint(2), pet.OwnerID)
+	assert.Equal(t, "Maniek", pet.Name)
+
+	// Case 4:
+	// Empty has many relationship clears the linkage
+	scope, err = c.NewScope(&UserGORM{ID: 3})
+	assert.NoError(t, err)
+	scope.SetIDFilters(3)
+	scope.Fieldset = map[string]*jsonapi.StructField{"pets": scope.Struct.GetRelationshipField("pets")}
+
+	dbErr = repo.Patch(scope)
+	assert.Nil(t, dbErr)
+
+	var count int
+	assert.NoError(t, db.Model(&PetGORM{}).Where("owner_id = ?", 3).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
+
+func TestGORMRepositoryCreate(t *testing.T) {
+	c, err := prepareJSONAPI(&UserGORM{}, &PetGORM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer clearDB()
+	repo, err := prepareGORMRepo(&UserGORM{}, &PetGORM{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, settleUsers(db))
+
+	// Case 1:
+	// Has many relationship is linked without overwriting the related record
+	scope, err := c.NewScope(&UserGORM{Name: "Adam", Pets: []*PetGORM{{ID: 2}}})
+	assert.NoError(t, err)
+
+	dbErr := repo.Create(scope)
+	assert.Nil(t, dbErr)
+
+	user := scope.Value.(*UserGORM)
+	assert.NotZero(t, user.ID)
+
+	pet := &PetGORM{}
+	assert.NoError(t, db.First(pet, 2).Error)
+	assert.Equal(t, user.ID, pet.OwnerID)
+	assert.Equal(t, "Cerberus", pet.Name)
+
+	// Case 2:
+	// Belongs to relationship sets the foreign key
+	scope, err = c.NewScope(&PetGORM{Name: "Burek", Owner: &UserGORM{ID: 1}})
+	assert.NoError(t, err)
+
+	dbErr = repo.Create(scope)
+	assert.Nil(t, dbErr)
+
+	pet = &PetGORM{}
+	assert.NoError(t, db.First(pet, scope.Value.(*PetGORM).ID).Error)
+	assert.Equal(t, uint(1), pet.OwnerID)
+
+	user = &UserGORM{}
+	assert.NoError(t, db.First(user, 1).Error)
+	assert.Equal(t, "Zygmunt", user.Name)
+}
+
 func prepareJSONAPI(models ...interface{}) (*jsonapi.Controller, error) {
 	c := jsonapi.New()
 	err := c.PrecomputeModels(models...)
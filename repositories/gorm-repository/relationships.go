@@ -0,0 +1,320 @@
+package gormrepo
+
+import (
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"reflect"
+	"strings"
+)
+
+// gormSaveAssociations is the gorm's setting that enables saving the associations on create or
+// update. The repository disables it and sets the relationship linkage on its own, so that
+// the related records are not overwritten by the relationship values that contains only
+// the primary keys.
+const gormSaveAssociations = "gorm:save_associations"
+
+// createRelationships gets the relationship fields that should be linked on the create.
+// Only the relationships with non zero values are returned.
+func createRelationships(scope *jsonapi.Scope, v reflect.Value) []*jsonapi.StructField {
+	var fields []*jsonapi.StructField
+	for _, name := range scope.Struct.ListRelationshipNames() {
+		field := scope.Struct.GetRelationshipField(name)
+		if field == nil {
+			continue
+		}
+
+		relValue := v.Field(field.GetFieldIndex())
+		switch relValue.Kind() {
+		case reflect.Ptr:
+			if relValue.IsNil() {
+				continue
+			}
+		case reflect.Slice:
+			if relValue.Len() == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// patchRelationships gets the relationship fields within the scope's fieldset.
+// The nil or empty relationships are also returned, so that their linkage would be cleared.
+func patchRelationships(scope *jsonapi.Scope) []*jsonapi.StructField {
+	var fields []*jsonapi.StructField
+	for _, field := range scope.Fieldset {
+		if field.IsRelationship() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// setBelongsToKeys sets the foreign keys of the belongs to relationships for the provided 'fields'
+// from the related values primaries.
+func setBelongsToKeys(v reflect.Value, mStruct *gorm.ModelStruct, fields []*jsonapi.StructField) error {
+	for _, field := range fields {
+		gormField := gormRelationshipField(mStruct, field)
+		if gormField == nil || gormField.Relationship.Kind != annotationBelongsTo {
+			continue
+		}
+
+		related := v.Field(field.GetFieldIndex())
+		if related.Kind() != reflect.Ptr || related.IsNil() {
+			continue
+		}
+
+		rel := gormField.Relationship
+		relatedPrim := related.Elem().FieldByName(rel.AssociationForeignFieldNames[0])
+		if !relatedPrim.IsValid() {
+			return fmt.Errorf("Association foreign field: '%s' not found for field: '%s'", rel.AssociationForeignFieldNames[0], field.GetFieldName())
+		}
+
+		fk := v.FieldByName(rel.ForeignFieldNames[0])
+		if !fk.IsValid() {
+			return fmt.Errorf("Foreign field: '%s' not found for field: '%s'", rel.ForeignFieldNames[0], field.GetFieldName())
+		}
+
+		if fk.Kind() == reflect.Ptr && relatedPrim.Kind() != reflect.Ptr {
+			ptr := reflect.New(fk.Type().Elem())
+			ptr.Elem().Set(relatedPrim)
+			fk.Set(ptr)
+		} else {
+			fk.Set(relatedPrim)
+		}
+	}
+	return nil
+}
+
+// syncRelationships replaces the linkage of the has one, has many and many to many
+// relationships for the provided 'fields' using the 'db' transaction. The belongs to
+// relationships are stored within the model's foreign key column and are not handled here.
+// The 'ownerKey' is the value of the scope's primary field.
+func syncRelationships(
+	db *gorm.DB,
+	v reflect.Value,
+	mStruct *gorm.ModelStruct,
+	fields []*jsonapi.StructField,
+	ownerKey interface{},
+) error {
+	for _, field := range fields {
+		gormField := gormRelationshipField(mStruct, field)
+		if gormField == nil {
+			continue
+		}
+
+		relValue := v.Field(field.GetFieldIndex())
+		var err error
+		switch gormField.Relationship.Kind {
+		case annotationHasOne, annotationHasMany:
+			err = syncHasRelationship(db, gormField, relValue, ownerKey)
+		case annotationManyToMany:
+			err = syncManyToMany(db, gormField, v, relValue)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notNullForeignKeyError is returned if the related records could not be unlinked from the owner
+// as their foreign key column is NOT NULL.
+type notNullForeignKeyError struct {
+	table, column string
+	count         int
+}
+
+// Error implements error interface.
+func (e *notNullForeignKeyError) Error() string {
+	return fmt.Sprintf("Cannot unlink: '%d' records of the table: '%s'. The foreign key column: '%s' is NOT NULL.", e.count, e.table, e.column)
+}
+
+// syncHasRelationship sets the foreign key of the related records to the 'ownerKey'.
+// The records that were previously related to the owner and are not within the 'relValue'
+// have their foreign key set to NULL. If the foreign key is tagged as NOT NULL and there are
+// records to unlink, the notNullForeignKeyError is returned.
+func syncHasRelationship(
+	db *gorm.DB,
+	gormField *gorm.StructField,
+	relValue reflect.Value,
+	ownerKey interface{},
+) error {
+	rel := gormField.Relationship
+	relatedScope := db.NewScope(reflect.New(relatedModelType(gormField)).Interface())
+	relatedPrim := relatedScope.PrimaryField()
+	if relatedPrim == nil {
+		return fmt.Errorf("No primary field found for the related model: '%v'", relatedModelType(gormField))
+	}
+
+	ids := relatedPrimaries(relValue, relatedPrim)
+	table := relatedScope.TableName()
+	fk := rel.ForeignDBNames[0]
+
+	unlink := db.Table(table).Where(fmt.Sprintf("%s = ?", fk), ownerKey)
+	if len(ids) > 0 {
+		unlink = unlink.Where(fmt.Sprintf("%s NOT IN (?)", relatedPrim.DBName), ids)
+	}
+
+	if notNullForeignKey(gormField) {
+		var count int
+		if err := unlink.Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return &notNullForeignKeyError{table: table, column: fk, count: count}
+		}
+	} else if err := unlink.UpdateColumn(fk, gorm.Expr("NULL")).Error; err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return db.Table(table).
+		Where(fmt.Sprintf("%s IN (?)", relatedPrim.DBName), ids).
+		UpdateColumn(fk, ownerKey).Error
+}
+
+// notNullForeignKey checks if the related model's foreign key field has the 'NOT NULL' gorm or
+// sql tag setting.
+func notNullForeignKey(gormField *gorm.StructField) bool {
+	field, ok := relatedModelType(gormField).FieldByName(gormField.Relationship.ForeignFieldNames[0])
+	if !ok {
+		return false
+	}
+
+	for _, tag := range []string{field.Tag.Get("gorm"), field.Tag.Get("sql")} {
+		for _, setting := range strings.Split(tag, ";") {
+			if strings.ToUpper(strings.TrimSpace(setting)) == "NOT NULL" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// syncError converts the error of the relationships linkage. The notNullForeignKeyError is
+// the NOT NULL violation.
+func (g *GORMRepository) syncError(err error) *unidb.Error {
+	if fkErr, ok := err.(*notNullForeignKeyError); ok {
+		dbErr := unidb.ErrNotNullViolation.New()
+		dbErr.Message = fkErr.Error()
+		return dbErr
+	}
+	return g.converter.Convert(err)
+}
+
+// syncManyToMany replaces the join table rows for the owner with the ones for the related
+// values.
+func syncManyToMany(
+	db *gorm.DB,
+	gormField *gorm.StructField,
+	owner, relValue reflect.Value,
+) error {
+	handler := gormField.Relationship.JoinTableHandler
+	if handler == nil {
+		return fmt.Errorf("No join table handler for the field: '%s'", gormField.Name)
+	}
+
+	source := owner.Addr().Interface()
+	if err := handler.Delete(handler, db, source); err != nil {
+		return err
+	}
+
+	for i := 0; i < relValue.Len(); i++ {
+		related := relValue.Index(i)
+		if related.Kind() == reflect.Ptr {
+			if related.IsNil() {
+				continue
+			}
+		} else {
+			related = related.Addr()
+		}
+
+		if err := handler.Add(handler, db, source, related.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relatedPrimaries gets the non zero primary values of the relationship value.
+func relatedPrimaries(relValue reflect.Value, relatedPrim *gorm.Field) []interface{} {
+	var ids []interface{}
+	primaryOf := func(related reflect.Value) {
+		if related.Kind() == reflect.Ptr {
+			if related.IsNil() {
+				return
+			}
+			related = related.Elem()
+		}
+
+		prim := related.FieldByIndex(relatedPrim.Struct.Index)
+		if reflect.DeepEqual(prim.Interface(), reflect.Zero(prim.Type()).Interface()) {
+			return
+		}
+		ids = append(ids, prim.Interface())
+	}
+
+	switch relValue.Kind() {
+	case reflect.Ptr:
+		primaryOf(relValue)
+	case reflect.Slice:
+		for i := 0; i < relValue.Len(); i++ {
+			primaryOf(relValue.Index(i))
+		}
+	}
+	return ids
+}
+
+// ownerPrimary gets the primary value of the scope's value. If the value has zero primary,
+// the single primary filter value is used and set within the value.
+func ownerPrimary(scope *jsonapi.Scope, v reflect.Value) (interface{}, error) {
+	prim := v.Field(scope.Struct.GetPrimaryField().GetFieldIndex())
+	if !reflect.DeepEqual(prim.Interface(), reflect.Zero(prim.Type()).Interface()) {
+		return prim.Interface(), nil
+	}
+
+	if len(scope.PrimaryFilters) == 1 && len(scope.PrimaryFilters[0].Values) == 1 {
+		fv := scope.PrimaryFilters[0].Values[0]
+		if fv.Operator == jsonapi.OpEqual && len(fv.Values) == 1 {
+			filterValue := reflect.ValueOf(fv.Values[0])
+			if filterValue.Type().ConvertibleTo(prim.Type()) {
+				prim.Set(filterValue.Convert(prim.Type()))
+				return prim.Interface(), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("No primary value provided for the relationships of model: '%v'", scope.Struct.GetType())
+}
+
+// gormRelationshipField gets the gorm's struct field with the relationship for the jsonapi field.
+func gormRelationshipField(mStruct *gorm.ModelStruct, field *jsonapi.StructField) *gorm.StructField {
+	for _, gField := range mStruct.StructFields {
+		if gField.Struct.Index[0] == field.GetFieldIndex() {
+			if gField.IsIgnored || gField.Relationship == nil {
+				return nil
+			}
+			return gField
+		}
+	}
+	return nil
+}
+
+func relatedModelType(gormField *gorm.StructField) reflect.Type {
+	t := gormField.Struct.Type
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,48 @@
+package gormrepo
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type Shelf struct {
+	ID    int     `jsonapi:"primary,shelves"`
+	Books []*Book `jsonapi:"relation,books" gorm:"foreignkey:ShelfID"`
+}
+
+type Book struct {
+	ID      int `jsonapi:"primary,books"`
+	ShelfID int `gorm:"not null"`
+}
+
+func TestNotNullForeignKey(t *testing.T) {
+	repo, err := prepareGORMRepo(&Shelf{}, &Book{}, &User{}, &Blog{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer clearDB()
+
+	fieldByName := func(model interface{}, name string) *gorm.StructField {
+		for _, field := range repo.db.NewScope(model).GetModelStruct().StructFields {
+			if field.Name == name {
+				return field
+			}
+		}
+		return nil
+	}
+
+	// Case 1:
+	// The foreign key tagged as NOT NULL
+	assert.True(t, notNullForeignKey(fieldByName(&Shelf{}, "Books")))
+
+	// Case 2:
+	// Nullable foreign key
+	assert.False(t, notNullForeignKey(fieldByName(&User{}, "Blogs")))
+
+	// Case 3:
+	// The NOT NULL foreign key error is the not null violation
+	dbErr := repo.syncError(&notNullForeignKeyError{table: "books", column: "shelf_id", count: 1})
+	assert.True(t, dbErr.Compare(unidb.ErrNotNullViolation))
+}
@@ -0,0 +1,80 @@
+package gormrepo
+
+import (
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/jsonapi-sdk"
+	"github.com/kucjac/uni-db"
+	"time"
+)
+
+// SoftDelete sets the current time on the provided 'field' for the resources matching the scope's
+// filters. The 'field' should be a nullable time field.
+func (g *GORMRepository) SoftDelete(scope *jsonapi.Scope, field *jsonapi.StructField) *unidb.Error {
+	return g.setDeletedAt(g.db, scope, field, time.Now())
+}
+
+// Restore sets the NULL value on the provided 'field' for the resources matching the scope's
+// filters. The restore operation is not scoped, so that it would work also on the models that
+// uses gorm's 'DeletedAt' field.
+func (g *GORMRepository) Restore(scope *jsonapi.Scope, field *jsonapi.StructField) *unidb.Error {
+	return g.setDeletedAt(g.db.Unscoped(), scope, field, gorm.Expr("NULL"))
+}
+
+// Unscoped returns the GORMRepository that does not exclude the soft deleted records.
+func (g *GORMRepository) Unscoped() jsonapisdk.Repository {
+	return &GORMRepository{db: g.db.Unscoped(), converter: g.converter}
+}
+
+func (g *GORMRepository) setDeletedAt(
+	db *gorm.DB,
+	scope *jsonapi.Scope,
+	field *jsonapi.StructField,
+	value interface{},
+) *unidb.Error {
+	if scope.Value == nil {
+		scope.NewValueSingle()
+	}
+
+	/**
+
+	  SOFT DELETE: PREPARE GORM SCOPE
+
+	*/
+	gormScope := db.NewScope(scope.Value)
+	mStruct := gormScope.GetModelStruct()
+
+	var gormField *gorm.StructField
+	for _, gField := range mStruct.StructFields {
+		if gField.Struct.Index[0] == field.GetFieldIndex() {
+			gormField = gField
+			break
+		}
+	}
+
+	if gormField == nil || gormField.IsIgnored {
+		dbErr := unidb.ErrInternalError.New()
+		dbErr.Message = fmt.Sprintf("Soft delete field: '%s' not found within model: '%v'", field.GetFieldName(), mStruct.ModelType)
+		return dbErr
+	}
+
+	if err := buildFilters(gormScope.DB(), mStruct, scope); err != nil {
+		return g.converter.Convert(err)
+	}
+
+	/**
+
+	  SOFT DELETE: UPDATE COLUMN
+
+	*/
+	result := gormScope.DB().Model(scope.Value).UpdateColumn(gormField.DBName, value)
+	if err := result.Error; err != nil {
+		return g.converter.Convert(err)
+	}
+
+	if result.RowsAffected == 0 {
+		return unidb.ErrNoResult.New()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package gormrepo
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"github.com/kucjac/jsonapi-sdk"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/kucjac/jsonapi-sdk/repositories/gorm-repository"
+
+const (
+	// contextSetting is the gorm.DB setting that keeps the context of the repository's queries.
+	contextSetting = "jsonapi:context"
+
+	// spanSetting is the gorm.Scope setting that keeps the span of the traced query.
+	spanSetting = "jsonapi:span"
+)
+
+// WithContext implements jsonapisdk.ContextRepository. The returned repository binds its queries
+// to the 'ctx'. If the tracing callbacks are registered with RegisterTracing, the queries are
+// traced as the children of the context's span. If the 'ctx' carries the transaction started
+// by the Begin method, the queries are run within the transaction.
+func (g *GORMRepository) WithContext(ctx context.Context) jsonapisdk.Repository {
+	db, inTx := g.db, g.inTx
+	if tx, ok := ctx.Value(txCtxKey{}).(*gorm.DB); ok {
+		db, inTx = tx, true
+	}
+	return &GORMRepository{db: db.Set(contextSetting, ctx), converter: g.converter, inTx: inTx}
+}
+
+// RegisterTracing registers the gorm callbacks that trace the queries of the repositories bound
+// to the context by the WithContext method. The queries without the context are not traced.
+// If the 'provider' is nil, the global tracer provider is used.
+func RegisterTracing(db *gorm.DB, provider trace.TracerProvider) {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	t := &queryTracer{tracer: provider.Tracer(tracerName)}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("jsonapi:trace_before_create", t.before("create"))
+	callback.Create().After("gorm:create").Register("jsonapi:trace_after_create", t.after)
+	callback.Query().Before("gorm:query").Register("jsonapi:trace_before_query", t.before("query"))
+	callback.Query().After("gorm:query").Register("jsonapi:trace_after_query", t.after)
+	callback.RowQuery().Before("gorm:row_query").Register("jsonapi:trace_before_row_query", t.before("row_query"))
+	callback.RowQuery().After("gorm:row_query").Register("jsonapi:trace_after_row_query", t.after)
+	callback.Update().Before("gorm:update").Register("jsonapi:trace_before_update", t.before("update"))
+	callback.Update().After("gorm:update").Register("jsonapi:trace_after_update", t.after)
+	callback.Delete().Before("gorm:delete").Register("jsonapi:trace_before_delete", t.before("delete"))
+	callback.Delete().After("gorm:delete").Register("jsonapi:trace_after_delete", t.after)
+}
+
+// queryTracer traces the gorm queries within the context set by the WithContext method.
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+func (t *queryTracer) before(operation string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		v, ok := scope.Get(contextSetting)
+		if !ok {
+			return
+		}
+
+		ctx, ok := v.(context.Context)
+		if !ok || ctx == nil {
+			return
+		}
+
+		_, span := t.tracer.Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.operation", operation),
+				attribute.String("db.sql.table", scope.TableName()),
+			),
+		)
+		scope.Set(spanSetting, span)
+	}
+}
+
+func (t *queryTracer) after(scope *gorm.Scope) {
+	v, ok := scope.Get(spanSetting)
+	if !ok {
+		return
+	}
+
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.statement", scope.SQL),
+		attribute.Int64("db.rows_affected", scope.DB().RowsAffected),
+	)
+
+	if err := scope.DB().Error; err != nil && err != gorm.ErrRecordNotFound {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,79 @@
+package gormrepo
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"github.com/kucjac/jsonapi-sdk"
+	"github.com/kucjac/uni-db"
+)
+
+// txCtxKey is the context key of the transaction started by the Begin method.
+type txCtxKey struct{}
+
+// Begin implements jsonapisdk.TransactionalRepository. The repositories bound to the returned
+// context by the WithContext method run their queries within the transaction. Their Create and
+// Patch methods do not start nor finish their own transactions then.
+func (g *GORMRepository) Begin(ctx context.Context) (context.Context, jsonapisdk.Transaction, *unidb.Error) {
+	tx := g.db.Begin()
+	if err := tx.Error; err != nil {
+		return ctx, nil, g.converter.Convert(err)
+	}
+	return context.WithValue(ctx, txCtxKey{}, tx), &gormTransaction{db: tx, converter: g.converter}, nil
+}
+
+// gormTransaction is the jsonapisdk.Transaction started by the GORMRepository.
+type gormTransaction struct {
+	db        *gorm.DB
+	converter *errorConverter
+}
+
+// Commit implements jsonapisdk.Transaction.
+func (t *gormTransaction) Commit() *unidb.Error {
+	if err := t.db.Commit().Error; err != nil {
+		return t.converter.Convert(err)
+	}
+	return nil
+}
+
+// Rollback implements jsonapisdk.Transaction.
+func (t *gormTransaction) Rollback() *unidb.Error {
+	if err := t.db.Rollback().Error; err != nil {
+		return t.converter.Convert(err)
+	}
+	return nil
+}
+
+// begin begins the transaction of the repository's method. If the repository is bound to the
+// transaction started by the Begin method, that transaction is used.
+func (g *GORMRepository) begin() *gorm.DB {
+	if g.inTx {
+		return g.db
+	}
+	return g.db.Begin()
+}
+
+// commit commits the method's transaction. The transaction started by the Begin method is
+// committed by its jsonapisdk.Transaction.
+func (g *GORMRepository) commit(tx *gorm.DB) error {
+	if g.inTx {
+		return nil
+	}
+	return tx.Commit().Error
+}
+
+// rollback rolls back the method's transaction. The transaction started by the Begin method is
+// rolled back by its jsonapisdk.Transaction.
+func (g *GORMRepository) rollback(tx *gorm.DB) {
+	if !g.inTx {
+		tx.Rollback()
+	}
+}
+
+// rollbackOnPanic rolls back the method's transaction if the method panics. The panic is not
+// recovered.
+func (g *GORMRepository) rollbackOnPanic(tx *gorm.DB) {
+	if r := recover(); r != nil {
+		g.rollback(tx)
+		panic(r)
+	}
+}
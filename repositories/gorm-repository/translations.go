@@ -0,0 +1,76 @@
+package gormrepo
+
+import (
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"strings"
+)
+
+// ListTranslations implements jsonapisdk.TranslationRepository. The translations are ranked
+// within the window partitioned by the primary key in the order of the 'languages'. The listed
+// resources are joined with their first ranked translation, so that the filters, sorts and
+// pagination apply to the resources.
+func (g *GORMRepository) ListTranslations(scope *jsonapi.Scope, languages []string) *unidb.Error {
+	if scope.Value == nil {
+		scope.NewValueMany()
+	}
+
+	if scope.LanguageFilters == nil || len(languages) == 0 {
+		return g.List(scope)
+	}
+
+	gormScope, err := g.buildScopeList(scope)
+	if err != nil {
+		errObj := unidb.ErrInternalError.New()
+		errObj.Message = err.Error()
+		return errObj
+	}
+	mStruct := gormScope.GetModelStruct()
+
+	primField, err := getGormField(scope.Struct.GetPrimaryField(), mStruct, true)
+	if err != nil {
+		errObj := unidb.ErrInternalError.New()
+		errObj.Message = err.Error()
+		return errObj
+	}
+
+	langField, err := getGormField(scope.LanguageFilters, mStruct, false)
+	if err != nil {
+		errObj := unidb.ErrInternalError.New()
+		errObj.Message = err.Error()
+		return errObj
+	}
+
+	// the ranked translations match the same filters as the listed ones
+	ranked := g.db.New().Table(gormScope.TableName())
+	if err = buildFilters(ranked, mStruct, scope); err != nil {
+		errObj := unidb.ErrInternalError.New()
+		errObj.Message = err.Error()
+		return errObj
+	}
+
+	var (
+		cases = make([]string, len(languages))
+		args  = make([]interface{}, len(languages))
+	)
+	for i, lang := range languages {
+		cases[i] = fmt.Sprintf("WHEN ? THEN %d", i)
+		args[i] = lang
+	}
+
+	prim, lang := gormScope.Quote(primField.DBName), gormScope.Quote(langField.DBName)
+	ranked = ranked.Select(fmt.Sprintf(
+		"%s AS ranked_id, %s AS ranked_language, ROW_NUMBER() OVER (PARTITION BY %s ORDER BY CASE %s %s ELSE %d END) AS translation_rank",
+		prim, lang, prim, lang, strings.Join(cases, " "), len(languages),
+	), args...)
+
+	table := gormScope.QuotedTableName()
+	db := gormScope.DB().Joins(fmt.Sprintf(
+		"JOIN (?) AS ranked_translations ON ranked_translations.ranked_id = %s.%s AND ranked_translations.ranked_language = %s.%s AND ranked_translations.translation_rank = 1",
+		table, prim, table, lang,
+	), ranked.QueryExpr())
+	*gormScope.DB() = *db
+
+	return g.find(scope, gormScope)
+}
@@ -0,0 +1,51 @@
+package gormrepo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+type Note struct {
+	ID    int    `gorm:"primary_key;auto_increment:false" jsonapi:"primary,notes"`
+	Lang  string `gorm:"primary_key" jsonapi:"attr,language,langtag"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func TestListTranslations(t *testing.T) {
+	c, err := prepareJSONAPI(&Note{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	repo, err := prepareGORMRepo(&Note{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer clearDB()
+
+	for _, note := range []*Note{
+		{ID: 1, Lang: "en", Title: "First"},
+		{ID: 1, Lang: "de", Title: "Erste"},
+		{ID: 2, Lang: "en", Title: "Second"},
+		{ID: 3, Lang: "de", Title: "Dritte"},
+		{ID: 3, Lang: "pl", Title: "Trzecia"},
+	} {
+		assert.NoError(t, repo.db.Create(note).Error)
+	}
+
+	req := httptest.NewRequest("GET", "/notes?sort=id&page[limit]=2&page[offset]=0", nil)
+	scope, errs, err := c.BuildScopeList(req, &Note{})
+	if !assert.NoError(t, err) || !assert.Empty(t, errs) {
+		return
+	}
+	scope.SetLanguageFilter("de", "en")
+
+	// The pagination applies to the resources with their first ranked translation
+	if assert.Nil(t, repo.ListTranslations(scope, []string{"de", "en"})) {
+		notes, ok := scope.Value.([]*Note)
+		if assert.True(t, ok) && assert.Len(t, notes, 2) {
+			assert.Equal(t, "Erste", notes[0].Title)
+			assert.Equal(t, "Second", notes[1].Title)
+		}
+	}
+}
@@ -1,36 +1,250 @@
 package jsonapirepo
 
 import (
+	"context"
+	"fmt"
 	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/jsonapi-sdk"
+	"github.com/kucjac/jsonapi-sdk/client"
+	"github.com/kucjac/uni-db"
 	"net/http"
+	"reflect"
 )
 
+// JSONAPIRepository is the repository that keeps the resources within the remote API built with
+// the jsonapi-sdk. The scopes are translated into the requests of the jsonapiclient.Client.
 type JSONAPIRepository struct {
-	URL        string
-	HTTPClient *http.Client
+	Client *jsonapiclient.Client
+	ctx    context.Context
 }
 
-func New(URL string, Client *http.Client) *JSONAPIRepository {
-	return &JSONAPIRepository{URL: URL, HTTPClient: Client}
+// New creates the repository for the API at the 'URL'. The 'c' controller contains the API's
+// models. If the 'Client' is nil the http.DefaultClient is used.
+func New(URL string, c *jsonapi.Controller, Client *http.Client) *JSONAPIRepository {
+	return &JSONAPIRepository{Client: jsonapiclient.New(URL, c, Client), ctx: context.Background()}
 }
 
-func (j *JSONAPIRepository) Create(scope *jsonapi.Scope) error {
-	j.HTTPClient
+// WithContext implements jsonapisdk.ContextRepository. The returned repository sends its
+// requests with the 'ctx'.
+func (j *JSONAPIRepository) WithContext(ctx context.Context) jsonapisdk.Repository {
+	return &JSONAPIRepository{Client: j.Client, ctx: ctx}
+}
+
+func (j *JSONAPIRepository) Create(scope *jsonapi.Scope) *unidb.Error {
+	if err := j.Client.Create(j.ctx, scope.Value); err != nil {
+		return dbError(err)
+	}
 	return nil
 }
 
-func (j *JSONAPIRepository) Get(scope *jsonapi.Scope) error {
+func (j *JSONAPIRepository) Get(scope *jsonapi.Scope) *unidb.Error {
+	ids, dbErr := primaryIDs(scope)
+	if dbErr != nil {
+		return dbErr
+	}
+	if len(ids) != 1 {
+		dbErr = unidb.ErrInternalError.New()
+		dbErr.Message = fmt.Sprintf("Get requires single primary filter value. Provided: '%v'", ids)
+		return dbErr
+	}
+
+	value := reflect.New(scope.Struct.GetType()).Interface()
+	if err := j.Client.Get(j.ctx, value, ids[0], j.buildQuery(scope, false)); err != nil {
+		return dbError(err)
+	}
+	scope.Value = value
 	return nil
 }
 
-func (j *JSONAPIRepository) List(scope *jsonapi.Scope) error {
+func (j *JSONAPIRepository) List(scope *jsonapi.Scope) *unidb.Error {
+	values := reflect.New(reflect.SliceOf(reflect.PtrTo(scope.Struct.GetType())))
+
+	var err error
+	if scope.Pagination != nil {
+		err = j.Client.List(j.ctx, values.Interface(), j.buildQuery(scope, true))
+	} else {
+		err = j.Client.ListAll(j.ctx, values.Interface(), j.buildQuery(scope, true))
+	}
+	if err != nil {
+		return dbError(err)
+	}
+	scope.Value = values.Elem().Interface()
 	return nil
 }
 
-func (j *JSONAPIRepository) Patch(scope *jsonapi.Scope) error {
+func (j *JSONAPIRepository) Patch(scope *jsonapi.Scope) *unidb.Error {
+	fields := make([]string, 0, len(scope.Fieldset))
+	for name := range scope.Fieldset {
+		fields = append(fields, name)
+	}
+
+	if err := j.Client.Patch(j.ctx, scope.Value, fields...); err != nil {
+		return dbError(err)
+	}
 	return nil
 }
 
-func (j *JSONAPIRepository) Delete(scope *jsonapi.Scope) error {
+func (j *JSONAPIRepository) Delete(scope *jsonapi.Scope) *unidb.Error {
+	ids, dbErr := primaryIDs(scope)
+	if dbErr != nil {
+		return dbErr
+	}
+	if len(ids) == 0 {
+		dbErr = unidb.ErrInternalError.New()
+		dbErr.Message = "Delete requires the primary filter."
+		return dbErr
+	}
+
+	value := reflect.New(scope.Struct.GetType()).Interface()
+	for _, id := range ids {
+		if err := j.Client.Delete(j.ctx, value, id); err != nil {
+			return dbError(err)
+		}
+	}
 	return nil
 }
+
+// operators maps the jsonapi filter operators into the query operators.
+var operators = map[jsonapi.FilterOperator]string{
+	jsonapi.OpEqual:        jsonapiclient.OpEqual,
+	jsonapi.OpNotEqual:     jsonapiclient.OpNotEqual,
+	jsonapi.OpIn:           jsonapiclient.OpIn,
+	jsonapi.OpNotIn:        jsonapiclient.OpNotIn,
+	jsonapi.OpLessThan:     jsonapiclient.OpLessThan,
+	jsonapi.OpLessEqual:    jsonapiclient.OpLessEqual,
+	jsonapi.OpGreaterThan:  jsonapiclient.OpGreaterThan,
+	jsonapi.OpGreaterEqual: jsonapiclient.OpGreaterEqual,
+	jsonapi.OpContains:     jsonapiclient.OpContains,
+	jsonapi.OpStartsWith:   jsonapiclient.OpStartsWith,
+	jsonapi.OpEndsWith:     jsonapiclient.OpEndsWith,
+}
+
+// buildQuery builds the query of the scope's fieldset, filters, sorts and pagination.
+// The primary filters are added only if 'primaries' is true.
+func (j *JSONAPIRepository) buildQuery(scope *jsonapi.Scope, primaries bool) *jsonapiclient.Query {
+	q := jsonapiclient.NewQuery()
+	collection := scope.Struct.GetCollectionType()
+
+	if len(scope.Fieldset) > 0 {
+		fields := make([]string, 0, len(scope.Fieldset))
+		for name := range scope.Fieldset {
+			fields = append(fields, name)
+		}
+		q.Fields(collection, fields...)
+	}
+
+	if primaries {
+		for _, filter := range scope.PrimaryFilters {
+			addFilter(q, "id", filter)
+		}
+	}
+
+	for _, filter := range scope.AttributeFilters {
+		addFilter(q, j.fieldName(scope.Struct, filter.GetFieldIndex()), filter)
+	}
+
+	for _, filter := range scope.RelationshipFilters {
+		name := j.fieldName(scope.Struct, filter.GetFieldIndex())
+		related := j.Client.Controller.Models.Get(filter.GetRelatedModelType())
+		for _, sub := range filter.Relationships {
+			addFilter(q, name+"."+j.fieldName(related, sub.GetFieldIndex()), sub)
+		}
+	}
+
+	if lang := scope.LanguageFilters; lang != nil {
+		for _, fv := range lang.Values {
+			if fv.Operator == jsonapi.OpEqual && len(fv.Values) == 1 {
+				q.Language(fmt.Sprint(fv.Values[0]))
+			}
+		}
+	}
+
+	for _, sort := range scope.Sorts {
+		name := j.fieldName(scope.Struct, sort.GetFieldIndex())
+		if sort.Order == jsonapi.DescendingOrder {
+			name = "-" + name
+		}
+		q.Sort(name)
+	}
+
+	if scope.Pagination != nil {
+		limit, offset := scope.Pagination.GetLimitOffset()
+		q.Page(limit, offset)
+	}
+	return q
+}
+
+func addFilter(q *jsonapiclient.Query, name string, filter *jsonapi.FilterField) {
+	for _, fv := range filter.Values {
+		q.Filter(name, operators[fv.Operator], fv.Values...)
+	}
+}
+
+// primaryIDs gets the values of the scope's primary filters with the equal or in operators.
+func primaryIDs(scope *jsonapi.Scope) ([]string, *unidb.Error) {
+	var ids []string
+	for _, filter := range scope.PrimaryFilters {
+		for _, fv := range filter.Values {
+			if fv.Operator != jsonapi.OpEqual && fv.Operator != jsonapi.OpIn {
+				dbErr := unidb.ErrInternalError.New()
+				dbErr.Message = fmt.Sprintf("Unsupported primary filter operator: '%v'", fv.Operator)
+				return nil, dbErr
+			}
+			for _, value := range fv.Values {
+				ids = append(ids, fmt.Sprint(value))
+			}
+		}
+	}
+	return ids, nil
+}
+
+// fieldName gets the jsonapi name of the 'mStruct's field with the struct field 'index'. The
+// names are taken from the fieldset of the model's scope.
+func (j *JSONAPIRepository) fieldName(mStruct *jsonapi.ModelStruct, index int) string {
+	if mStruct == nil {
+		return ""
+	}
+	if index == mStruct.GetPrimaryField().GetFieldIndex() {
+		return "id"
+	}
+
+	scope, err := j.Client.Controller.NewScope(reflect.New(mStruct.GetType()).Interface())
+	if err == nil {
+		for name, field := range scope.Fieldset {
+			if field.GetFieldIndex() == index {
+				return name
+			}
+		}
+	}
+	return mStruct.GetType().Field(index).Name
+}
+
+// dbError converts the client's error into the *unidb.Error. The API's error responses are
+// mapped by their status codes.
+func dbError(err error) *unidb.Error {
+	var dbErr *unidb.Error
+
+	apiErr, ok := err.(*jsonapiclient.Error)
+	if !ok {
+		dbErr = unidb.ErrConnExc.New()
+		dbErr.Message = err.Error()
+		return dbErr
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusNotFound:
+		dbErr = unidb.ErrNoResult.New()
+	case http.StatusConflict:
+		dbErr = unidb.ErrUniqueViolation.New()
+	case http.StatusUnauthorized:
+		dbErr = unidb.ErrInvalidAuthorization.New()
+	case http.StatusForbidden:
+		dbErr = unidb.ErrInsufficientPrivilege.New()
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		dbErr = unidb.ErrCheckViolation.New()
+	default:
+		dbErr = unidb.ErrInternalError.New()
+	}
+	dbErr.Message = apiErr.Error()
+	return dbErr
+}
@@ -0,0 +1,136 @@
+package jsonapirepo
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type Blog struct {
+	ID    int     `jsonapi:"primary,blogs"`
+	Title string  `jsonapi:"attr,title"`
+	Posts []*Post `jsonapi:"relation,posts"`
+}
+
+type Post struct {
+	ID    int    `jsonapi:"primary,posts"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func prepareRepository(t *testing.T, handler http.HandlerFunc) (*JSONAPIRepository, *jsonapi.Controller, *httptest.Server) {
+	c := jsonapi.New()
+	assert.NoError(t, c.PrecomputeModels(&Blog{}, &Post{}))
+
+	server := httptest.NewServer(handler)
+	return New(server.URL, c, server.Client()), c, server
+}
+
+func listScope(t *testing.T, c *jsonapi.Controller, target string) *jsonapi.Scope {
+	req := httptest.NewRequest("GET", target, nil)
+	scope, errs, err := c.BuildScopeList(req, &Blog{})
+	assert.NoError(t, err)
+	assert.Empty(t, errs)
+	return scope
+}
+
+func TestBuildQuery(t *testing.T) {
+	repo, c, server := prepareRepository(t, func(rw http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		assert.Equal(t, "/blogs", req.URL.Path)
+		assert.Equal(t, "1,2", q.Get("filter[blogs][id][in]"))
+		assert.Equal(t, "First", q.Get("filter[blogs][title][eq]"))
+		assert.Equal(t, "go", q.Get("filter[blogs][posts][title][startswith]"))
+		assert.Equal(t, "-title", q.Get("sort"))
+		assert.Equal(t, "title", q.Get("fields[blogs]"))
+		assert.Equal(t, "5", q.Get("page[limit]"))
+		assert.Equal(t, "10", q.Get("page[offset]"))
+
+		rw.Header().Set("Content-Type", jsonapi.MediaType)
+		rw.Write([]byte(`{"data":[]}`))
+	})
+	defer server.Close()
+
+	scope := listScope(t, c, "/blogs?filter[blogs][id][in]=1,2&filter[blogs][title][eq]=First&filter[blogs][posts][title][startswith]=go&sort=-title&fields[blogs]=title&page[limit]=5&page[offset]=10")
+	assert.Nil(t, repo.List(scope))
+}
+
+func TestDBError(t *testing.T) {
+	var status int
+	repo, c, server := prepareRepository(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", jsonapi.MediaType)
+		rw.WriteHeader(status)
+		rw.Write([]byte(`{"errors":[{"status":"` + strconv.Itoa(status) + `","title":"Failed"}]}`))
+	})
+
+	getScope := func() *jsonapi.Scope {
+		req := httptest.NewRequest("GET", "/blogs/1", nil)
+		scope, errs, err := c.BuildScopeSingle(req, &Blog{})
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		return scope
+	}
+
+	cases := map[int]unidb.Error{
+		http.StatusNotFound:            unidb.ErrNoResult,
+		http.StatusConflict:            unidb.ErrUniqueViolation,
+		http.StatusUnauthorized:        unidb.ErrInvalidAuthorization,
+		http.StatusForbidden:           unidb.ErrInsufficientPrivilege,
+		http.StatusBadRequest:          unidb.ErrCheckViolation,
+		http.StatusUnprocessableEntity: unidb.ErrCheckViolation,
+		http.StatusInternalServerError: unidb.ErrInternalError,
+	}
+	for status = range cases {
+		dbErr := repo.Get(getScope())
+		if assert.NotNil(t, dbErr, "status: %d", status) {
+			assert.True(t, dbErr.Compare(cases[status]), "status: %d", status)
+		}
+	}
+
+	// The unreachable API results in the connection exception
+	server.Close()
+	dbErr := repo.Get(getScope())
+	if assert.NotNil(t, dbErr) {
+		assert.True(t, dbErr.Compare(unidb.ErrConnExc))
+	}
+}
+
+func TestList(t *testing.T) {
+	var requests int
+	repo, c, server := prepareRepository(t, func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.Header().Set("Content-Type", jsonapi.MediaType)
+		if req.URL.Query().Get("page[offset]") == "1" {
+			rw.Write([]byte(`{"data":[{"type":"blogs","id":"2","attributes":{"title":"Second"}}]}`))
+			return
+		}
+		rw.Write([]byte(`{"data":[{"type":"blogs","id":"1","attributes":{"title":"First"}}],"links":{"next":"/blogs?page[limit]=1&page[offset]=1"}}`))
+	})
+	defer server.Close()
+
+	// Case 1:
+	// The paginated scope lists a single page
+	scope := listScope(t, c, "/blogs?page[limit]=1&page[offset]=0")
+	if assert.Nil(t, repo.List(scope)) {
+		blogs, ok := scope.Value.([]*Blog)
+		if assert.True(t, ok) && assert.Len(t, blogs, 1) {
+			assert.Equal(t, 1, blogs[0].ID)
+		}
+	}
+	assert.Equal(t, 1, requests)
+
+	// Case 2:
+	// The scope without the pagination lists all the pages
+	requests = 0
+	scope = listScope(t, c, "/blogs")
+	if assert.Nil(t, repo.List(scope)) {
+		blogs, ok := scope.Value.([]*Blog)
+		if assert.True(t, ok) && assert.Len(t, blogs, 2) {
+			assert.Equal(t, 2, blogs[1].ID)
+		}
+	}
+	assert.Equal(t, 2, requests)
+}
@@ -1,8 +1,10 @@
 package jsonapisdk
 
 import (
+	"context"
 	"github.com/kucjac/jsonapi"
 	"github.com/kucjac/uni-db"
+	"time"
 )
 
 // Repository is an interface that specifies
@@ -13,3 +15,52 @@ type Repository interface {
 	Patch(scope *jsonapi.Scope) *unidb.Error
 	Delete(scope *jsonapi.Scope) *unidb.Error
 }
+
+// LastModifiedRepository is the repository that allows to get the last modification time of the
+// resources without querying whole values. The 'field' is the name of the model's struct field
+// containing the modification time.
+// It is used by the endpoints with the ConditionalGet flag for the 'If-Modified-Since' checks.
+type LastModifiedRepository interface {
+	LastModified(scope *jsonapi.Scope, field string) (time.Time, *unidb.Error)
+}
+
+// TranslationRepository is the repository of the i18n models that lists a single translation of
+// each resource. The translation in the language that comes first in the 'languages' is listed.
+// The scope's filters, sorts and pagination apply to the resources, not their translations.
+// It is used for the language fallback chains of the listed and included resources.
+type TranslationRepository interface {
+	ListTranslations(scope *jsonapi.Scope, languages []string) *unidb.Error
+}
+
+// SoftDeleteRepository is the repository that supports the soft deletes of the model's resources.
+// The 'field' is the model's time attribute that contains the deletion time.
+// The Unscoped method should return the repository that does not exclude the trashed resources
+// by itself, so that the handler could decide which resources should be taken.
+type SoftDeleteRepository interface {
+	SoftDelete(scope *jsonapi.Scope, field *jsonapi.StructField) *unidb.Error
+	Restore(scope *jsonapi.Scope, field *jsonapi.StructField) *unidb.Error
+	Unscoped() Repository
+}
+
+// ContextRepository is the repository that could be bound to the context of the handled request.
+// The handler binds the repository before its calls, so that i.e. the trace context could be
+// propagated into the repository's queries.
+type ContextRepository interface {
+	WithContext(ctx context.Context) Repository
+}
+
+// TransactionalRepository is the repository that could run the calls for multiple scopes within
+// a single transaction. The Begin method starts the transaction and returns the context that
+// carries it. The repository bound to that context by the ContextRepository's WithContext method
+// runs its calls within the transaction, thus the TransactionalRepository should implement the
+// ContextRepository as well.
+// It is used by the Create endpoint to sidepost the included resources atomically.
+type TransactionalRepository interface {
+	Begin(ctx context.Context) (context.Context, Transaction, *unidb.Error)
+}
+
+// Transaction is the transaction started by the TransactionalRepository.
+type Transaction interface {
+	Commit() *unidb.Error
+	Rollback() *unidb.Error
+}
@@ -0,0 +1,192 @@
+package jsonapisdk
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+)
+
+// requestWriter is the http.ResponseWriter that is bound to the handled request.
+// It allows the handler's helpers like MarshalErrors to get the request for the writer.
+type requestWriter interface {
+	http.ResponseWriter
+	request() *http.Request
+}
+
+// requestOf gets the request bound to the 'rw'. If the 'rw' is not bound to any request,
+// it returns nil.
+func requestOf(rw http.ResponseWriter) *http.Request {
+	if w, ok := rw.(requestWriter); ok {
+		return w.request()
+	}
+	return nil
+}
+
+// responseWriter wraps the http.ResponseWriter of the handler's endpoints. It keeps the written
+// status and the error code for the metrics and the current trace context of the request.
+type responseWriter struct {
+	http.ResponseWriter
+	req       *http.Request
+	ctx       context.Context
+	status    int
+	size      int
+	errorCode string
+}
+
+func newResponseWriter(rw http.ResponseWriter, req *http.Request) *responseWriter {
+	return &responseWriter{ResponseWriter: rw, req: req}
+}
+
+func (w *responseWriter) request() *http.Request {
+	return w.req
+}
+
+func (w *responseWriter) traceContext() context.Context {
+	if w.ctx == nil {
+		return w.req.Context()
+	}
+	return w.ctx
+}
+
+func (w *responseWriter) setTraceContext(ctx context.Context) {
+	w.ctx = ctx
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Status gets the written response status.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) setErrorCode(code string) {
+	if w.errorCode == "" {
+		w.errorCode = code
+	}
+}
+
+// wrapHandler wraps the model's endpoint handler function, so that it writes to the handler's
+// response writer. The handlers called by the other wrapped handler are not wrapped again.
+// The panics within the handler function are recovered by the HandlePanic method.
+func (h *JSONAPIHandler) wrapHandler(
+	model *ModelHandler,
+	endpoint EndpointType,
+	fn http.HandlerFunc,
+) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if _, ok := rw.(*responseWriter); ok {
+			fn(rw, req)
+			return
+		}
+		start := time.Now()
+		req = h.withRequestLogger(model, endpoint, rw, h.withLanguageQuery(req))
+		req, span := h.startEndpointSpan(model, endpoint, req)
+		w := newResponseWriter(rw, req)
+		defer endEndpointSpan(span, w)
+		defer h.observeRequest(model, endpoint, w, start)
+		defer func() {
+			if r := recover(); r != nil {
+				h.HandlePanic(w, req, r)
+			}
+		}()
+
+		fn(w, req)
+	}
+}
+
+// responseRecorder is the http.ResponseWriter that keeps the response in memory, so that it could
+// be inspected before it is written to the client.
+type responseRecorder struct {
+	req       *http.Request
+	ctx       context.Context
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	errorCode string
+}
+
+// newResponseRecorder creates the recorder for the 'req'. The recorder continues the current
+// trace context of the 'rw'.
+func newResponseRecorder(rw http.ResponseWriter, req *http.Request) *responseRecorder {
+	return &responseRecorder{req: req, ctx: traceContext(rw), header: make(http.Header)}
+}
+
+func (r *responseRecorder) request() *http.Request {
+	return r.req
+}
+
+func (r *responseRecorder) traceContext() context.Context {
+	if r.ctx == nil {
+		return r.req.Context()
+	}
+	return r.ctx
+}
+
+func (r *responseRecorder) setTraceContext(ctx context.Context) {
+	r.ctx = ctx
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// Status gets the recorded response status.
+func (r *responseRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func (r *responseRecorder) setErrorCode(code string) {
+	if r.errorCode == "" {
+		r.errorCode = code
+	}
+}
+
+// writeTo writes the recorded headers, status and body into the 'rw'.
+func (r *responseRecorder) writeTo(rw http.ResponseWriter) {
+	if w, ok := rw.(errorCodeWriter); ok && r.errorCode != "" {
+		w.setErrorCode(r.errorCode)
+	}
+	copyHeader(rw.Header(), r.header)
+	rw.WriteHeader(r.Status())
+	rw.Write(r.body.Bytes())
+}
+
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[key] = append([]string(nil), values...)
+	}
+}
@@ -9,6 +9,8 @@ import (
 )
 
 func RouteHandler(router *gin.Engine, handler *jsonapisdk.JSONAPIHandler) error {
+	router.GET(handler.Controller.APIURLBase+"/languages", gin.WrapF(handler.ListLanguages()))
+
 	for _, model := range handler.ModelHandlers {
 		// mStruct := handler.Controller.Models.Get(model.ModelType)
 		mStruct := handler.Controller.Models.Get(model.ModelType)
@@ -19,8 +21,11 @@ func RouteHandler(router *gin.Engine, handler *jsonapisdk.JSONAPIHandler) error
 
 		base := handler.Controller.APIURLBase + "/" + mStruct.GetCollectionType()
 
+		// JSON SCHEMA
+		router.GET(handler.JSONSchemaPath(model), gin.WrapF(handler.ServeJSONSchema(model)))
+
 		getMiddlewares := func(middlewares ...jsonapisdk.MiddlewareFunc) gin.HandlersChain {
-			ginMiddlewares := []gin.HandlerFunc{}
+			ginMiddlewares := []gin.HandlerFunc{Recovery(handler)}
 			for _, middleware := range middlewares {
 				ginMiddlewares = append(ginMiddlewares, adapter.Wrap(middleware))
 			}
@@ -112,6 +117,56 @@ func RouteHandler(router *gin.Engine, handler *jsonapisdk.JSONAPIHandler) error
 			router.DELETE(base+"/:id", gin.WrapF(handler.EndpointForbidden(model, jsonapisdk.Delete)))
 		}
 
+		// RESTORE
+		if model.Restore != nil {
+			if model.Restore.CustomHandlerFunc != nil {
+				handlerFunc = model.Restore.CustomHandlerFunc
+			} else {
+				handlerFunc = handler.Restore(model, model.Restore)
+			}
+			ginHandlerFunc = gin.WrapF(handlerFunc)
+			handlers = getMiddlewares(model.Restore.Middlewares...)
+			handlers = append(handlers, ginHandlerFunc)
+			router.POST(base+"/:id/restore", handlers...)
+		} else if model.SoftDelete != nil {
+			router.POST(base+"/:id/restore", gin.WrapF(handler.EndpointForbidden(model, jsonapisdk.Restore)))
+		}
+
+		// TRANSLATIONS
+		if model.Translations {
+			if model.List != nil {
+				handlers = getMiddlewares(model.List.Middlewares...)
+				handlers = append(handlers, gin.WrapF(handler.ListTranslations(model, model.List)))
+				router.GET(base+"/:id/translations", handlers...)
+			} else {
+				router.GET(base+"/:id/translations", gin.WrapF(handler.EndpointForbidden(model, jsonapisdk.List)))
+			}
+
+			if model.Create != nil {
+				handlers = getMiddlewares(model.Create.Middlewares...)
+				handlers = append(handlers, gin.WrapF(handler.CreateTranslation(model, model.Create)))
+				router.POST(base+"/:id/translations", handlers...)
+			} else {
+				router.POST(base+"/:id/translations", gin.WrapF(handler.EndpointForbidden(model, jsonapisdk.Create)))
+			}
+
+			if model.Patch != nil {
+				handlers = getMiddlewares(model.Patch.Middlewares...)
+				handlers = append(handlers, gin.WrapF(handler.PatchTranslation(model, model.Patch)))
+				router.PATCH(base+"/:id/translations/:language", handlers...)
+			} else {
+				router.PATCH(base+"/:id/translations/:language", gin.WrapF(handler.EndpointForbidden(model, jsonapisdk.Patch)))
+			}
+
+			if model.Delete != nil {
+				handlers = getMiddlewares(model.Delete.Middlewares...)
+				handlers = append(handlers, gin.WrapF(handler.DeleteTranslation(model, model.Delete)))
+				router.DELETE(base+"/:id/translations/:language", handlers...)
+			} else {
+				router.DELETE(base+"/:id/translations/:language", gin.WrapF(handler.EndpointForbidden(model, jsonapisdk.Delete)))
+			}
+		}
+
 		for _, rel := range mStruct.ListRelationshipNames() {
 			if model.GetRelated != nil {
 				if model.GetRelated.CustomHandlerFunc != nil {
@@ -174,3 +229,30 @@ func RouteHandler(router *gin.Engine, handler *jsonapisdk.JSONAPIHandler) error
 	}
 	return nil
 }
+
+// RouteMetrics mounts the metrics 'handler' at the provided 'path' i.e. the handler of the
+// prometheus collector at '/metrics'.
+func RouteMetrics(router *gin.Engine, path string, handler http.Handler) {
+	router.GET(path, gin.WrapH(handler))
+}
+
+// Recovery returns the gin middleware that recovers the panics within the following handlers
+// and writes the JSON:API '500' Internal Server Error document by the handler's HandlePanic
+// method. The middleware is used by all the routes with the model's endpoint middlewares.
+func Recovery(handler *jsonapisdk.JSONAPIHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				handler.HandlePanic(c.Writer, c.Request, r)
+				c.Abort()
+			}
+		}()
+		c.Next()
+	}
+}
+
+// RouteOpenAPI mounts the handler's OpenAPI document at the provided 'path'
+// i.e. '/openapi.json'.
+func RouteOpenAPI(router *gin.Engine, path string, handler *jsonapisdk.JSONAPIHandler, info jsonapisdk.OpenAPIInfo) {
+	router.GET(path, gin.WrapF(handler.ServeOpenAPI(info)))
+}
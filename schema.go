@@ -0,0 +1,260 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON Schema (draft 2020-12) object. It is used by the OpenAPI 3.1 document
+// and by the resources' JSON Schemas.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+
+	// Type is the string or the slice of strings for the nullable types.
+	Type   interface{}   `json:"type,omitempty"`
+	Format string        `json:"format,omitempty"`
+	Const  interface{}   `json:"const,omitempty"`
+	Enum   []interface{} `json:"enum,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+	ExclMin   *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclMax   *float64 `json:"exclusiveMaximum,omitempty"`
+	MinItems  *int     `json:"minItems,omitempty"`
+	MaxItems  *int     `json:"maxItems,omitempty"`
+
+	ReadOnly  bool `json:"readOnly,omitempty"`
+	WriteOnly bool `json:"writeOnly,omitempty"`
+}
+
+// schemaRef creates the schema referencing the 'ref'.
+func schemaRef(ref string) *Schema {
+	return &Schema{Ref: ref}
+}
+
+// objectSchema creates the object schema with the 'properties'.
+func objectSchema(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// typeSchema creates the schema of the attribute's Go type. The pointers are nullable.
+func typeSchema(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := typeSchema(t.Elem())
+		if typ, ok := s.Type.(string); ok {
+			s.Type = []string{typ, "null"}
+		}
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := float64(0)
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Struct:
+		return &Schema{Type: "object"}
+	}
+	return &Schema{}
+}
+
+// applyValidationTag applies the constraints of the struct field's validation tag i.e. 'create'
+// or 'patch' on the schema. It returns true if the field is required by the tag.
+func applyValidationTag(s *Schema, t reflect.Type, tag string) (required bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, option := range strings.Split(tag, ",") {
+		name, param := option, ""
+		if i := strings.Index(option, "="); i != -1 {
+			name, param = option[:i], option[i+1:]
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "ip", "ipv4":
+			s.Format = "ipv4"
+		case "ipv6":
+			s.Format = "ipv6"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(t.Kind(), value))
+			}
+		case validationTagImmutable:
+			s.ReadOnly = true
+		case "len":
+			setLengthBound(s, t, param, true, true)
+		case "min", "gte":
+			setLengthBound(s, t, param, true, false)
+		case "max", "lte":
+			setLengthBound(s, t, param, false, true)
+		case "gt", "lt":
+			if n, err := strconv.ParseFloat(param, 64); err == nil && isNumberKind(t.Kind()) {
+				if name == "gt" {
+					s.ExclMin = &n
+				} else {
+					s.ExclMax = &n
+				}
+			}
+		}
+	}
+	return required
+}
+
+// setLengthBound sets the minimum and/or maximum bound of the length for the strings and the
+// slices or the value for the numbers.
+func setLengthBound(s *Schema, t reflect.Type, param string, min, max bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case isNumberKind(t.Kind()):
+		if min {
+			s.Minimum = &n
+		}
+		if max {
+			s.Maximum = &n
+		}
+	case t.Kind() == reflect.String:
+		l := int(n)
+		if min {
+			s.MinLength = &l
+		}
+		if max {
+			s.MaxLength = &l
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map:
+		l := int(n)
+		if min {
+			s.MinItems = &l
+		}
+		if max {
+			s.MaxItems = &l
+		}
+	}
+}
+
+// enumValue parses the 'oneof' tag's value with the field's 'kind'. The values that are not
+// valid for the kind are kept as strings.
+func enumValue(kind reflect.Kind, value string) interface{} {
+	switch {
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case kind >= reflect.Uint && kind <= reflect.Uintptr:
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return n
+		}
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// resourceField is the model's field exposed within the jsonapi resource object.
+type resourceField struct {
+	name  string
+	field *jsonapi.StructField
+}
+
+// structField gets the reflect struct field with the field's validation tags.
+func (f *resourceField) structField() reflect.StructField {
+	return f.field.GetReflectStructField()
+}
+
+// isMany checks if the relationship field is a to-many relationship.
+func (f *resourceField) isMany() bool {
+	return f.field.GetFieldKind() == jsonapi.RelationshipMultiple
+}
+
+// relatedType gets the model type of the relationship field.
+func (f *resourceField) relatedType() reflect.Type {
+	return f.field.GetRelatedModelType()
+}
+
+// resourceFields gets the model's fields exposed within the jsonapi resource object. The fields
+// are taken from the model's jsonapi.ModelStruct. The hidden fields are omitted. The attributes
+// and relationships are sorted by their names.
+func (h *JSONAPIHandler) resourceFields(model reflect.Type) (attrs, rels []*resourceField) {
+	scope, err := h.Controller.NewScope(reflect.New(model).Interface())
+	if err != nil {
+		return nil, nil
+	}
+
+	for name, field := range scope.Fieldset {
+		if JSONAPITagFunc(field.GetReflectStructField()) == "" {
+			continue
+		}
+
+		f := &resourceField{name: name, field: field}
+		switch field.GetFieldKind() {
+		case jsonapi.Attribute:
+			attrs = append(attrs, f)
+		case jsonapi.RelationshipSingle, jsonapi.RelationshipMultiple:
+			rels = append(rels, f)
+		}
+	}
+
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].name < attrs[j].name })
+	sort.Slice(rels, func(i, j int) bool { return rels[i].name < rels[j].name })
+	return attrs, rels
+}
@@ -0,0 +1,504 @@
+package jsonapisdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	memberLocalID  = "lid"
+	memberIncluded = "included"
+)
+
+// sidepostDocument is the Create request document with the resources to create within the
+// 'included' member.
+type sidepostDocument struct {
+	Data     map[string]interface{}   `json:"data"`
+	Included []map[string]interface{} `json:"included"`
+}
+
+// sideposted is the resource created by the sidepost.
+type sideposted struct {
+	model *ModelHandler
+	data  map[string]interface{}
+}
+
+// sidepost wraps the model's 'create' handler, so that the resources within the 'included'
+// member of the request document are created before the primary resource.
+// The included resources are created by their models' Create pipelines in the order of the
+// 'included' member, so that the resource might reference the ones preceding it. The local ids
+// are replaced with the created resources ids.
+// If the primary and all the included models are stored within the same repository that
+// implements the TransactionalRepository, all the resources are created within a single
+// transaction. Otherwise, if any of the resources could not be created, the already created
+// ones are deleted. If they could not be deleted, the internal error with the rollback failures
+// is written instead of the creation error.
+func (h *JSONAPIHandler) sidepost(model *ModelHandler, create http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Error while reading body for path: '%s' and method: %s. Error: %s.", req.URL.Path, req.Method, err)
+			h.MarshalErrors(rw, jsonapi.ErrInvalidInput.Copy())
+			return
+		}
+
+		doc := &sidepostDocument{}
+		if err = json.Unmarshal(body, doc); err != nil || doc.Data == nil || len(doc.Included) == 0 {
+			// the document is validated by the create pipeline
+			create(rw, requestWithBody(req, body))
+			return
+		}
+
+		/**
+
+		  SIDEPOST: BEGIN TRANSACTION
+
+		*/
+		st.next("SIDEPOST: BEGIN TRANSACTION")
+		// the response is recorded, so that it is written after the commit or rollback
+		out := newResponseRecorder(rw, req)
+		tx, ok := h.beginSidepost(model, doc, out)
+		if !ok {
+			out.writeTo(rw)
+			return
+		}
+		if tx != nil {
+			defer func() {
+				if r := recover(); r != nil {
+					tx.Rollback()
+					panic(r)
+				}
+			}()
+		}
+
+		/**
+
+		  SIDEPOST: CREATE RESOURCES
+
+		*/
+		st.next("SIDEPOST: CREATE RESOURCES")
+		created, ok := h.sidepostResources(model, create, doc, out, req)
+
+		/**
+
+		  SIDEPOST: FINISH TRANSACTION
+
+		*/
+		st.next("SIDEPOST: FINISH TRANSACTION")
+		if ok && tx != nil {
+			if dbErr := tx.Commit(); dbErr != nil {
+				h.logger(rw, SubsystemEndpoint).Errorf("Cannot commit sideposted resources for model: '%v'. %v", model.ModelType, dbErr)
+				h.MarshalInternalError(rw)
+				return
+			}
+		}
+
+		if !ok {
+			if failures := h.rollbackSideposted(rw, tx, created); len(failures) > 0 {
+				h.MarshalErrors(rw, failures...)
+				return
+			}
+		}
+		out.writeTo(rw)
+	}
+}
+
+// beginSidepost begins the transaction of the sideposted resources if the primary and all the
+// included models are stored within the same TransactionalRepository. The transaction's context
+// is set as the context of the 'out', so that the repositories bound to it run their calls
+// within the transaction. If the repositories are not transactional, the returned transaction
+// is nil. On error it writes internal error into the 'out'.
+func (h *JSONAPIHandler) beginSidepost(
+	model *ModelHandler,
+	doc *sidepostDocument,
+	out *responseRecorder,
+) (tx Transaction, ok bool) {
+	repo := unwrapRepository(h.getModelRepositoryByType(model.ModelType))
+	txRepo, isTx := repo.(TransactionalRepository)
+	if !isTx {
+		return nil, true
+	}
+
+	if _, isCtx := repo.(ContextRepository); !isCtx {
+		h.logger(out, SubsystemEndpoint).Warningf("The TransactionalRepository for model: '%v' does not implement ContextRepository. The resources would not be sideposted within a transaction.", model.ModelType)
+		return nil, true
+	}
+
+	for _, resource := range doc.Included {
+		collection, _ := resource["type"].(string)
+		included := h.modelHandlerByCollection(collection)
+		if included == nil {
+			// the error is written while creating the resource
+			continue
+		}
+
+		if unwrapRepository(h.getModelRepositoryByType(included.ModelType)) != repo {
+			return nil, true
+		}
+	}
+
+	ctx, tx, dbErr := txRepo.Begin(out.traceContext())
+	if dbErr != nil {
+		h.logger(out, SubsystemDatabase).Errorf("Cannot begin sidepost transaction for model: '%v'. %v", model.ModelType, dbErr)
+		h.MarshalInternalError(out)
+		return nil, false
+	}
+	out.setTraceContext(ctx)
+	return tx, true
+}
+
+// sidepostResources creates the included and the primary resources and writes the compound
+// document into the 'out'. It returns the created resources and false if any of the resources
+// could not be created. Then the error is written into the 'out'.
+func (h *JSONAPIHandler) sidepostResources(
+	model *ModelHandler,
+	create http.HandlerFunc,
+	doc *sidepostDocument,
+	out *responseRecorder,
+	req *http.Request,
+) (order []*sideposted, ok bool) {
+	st := h.traceStages(out)
+	defer st.done()
+
+	/**
+
+	  SIDEPOST: CREATE INCLUDED
+
+	*/
+	st.next("SIDEPOST: CREATE INCLUDED")
+	created := make(map[string]*sideposted)
+	for i, resource := range doc.Included {
+		pointer := fmt.Sprintf("/included/%d", i)
+		s, ok := h.sidepostIncluded(resource, pointer, created, out, req)
+		if !ok {
+			return order, false
+		}
+		order = append(order, s)
+
+		if lid, ok := resource[memberLocalID].(string); ok && lid != "" {
+			created[localIDKey(resource["type"], lid)] = s
+		}
+	}
+
+	/**
+
+	  SIDEPOST: RESOLVE LOCAL IDS
+
+	*/
+	st.next("SIDEPOST: RESOLVE LOCAL IDS")
+	if errObj := resolveLocalIDs(doc.Data, created, pointerData); errObj != nil {
+		h.MarshalErrors(out, errObj)
+		return order, false
+	}
+
+	mainBody, err := json.Marshal(map[string]interface{}{"data": doc.Data})
+	if err != nil {
+		h.logger(out, SubsystemEndpoint).Errorf("Cannot marshal sideposted document for model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(out)
+		return order, false
+	}
+
+	/**
+
+	  SIDEPOST: CREATE PRIMARY
+
+	*/
+	st.next("SIDEPOST: CREATE PRIMARY")
+	rec := newResponseRecorder(out, req)
+	create(rec, requestWithBody(req, mainBody))
+	if rec.Status() != http.StatusCreated {
+		rec.writeTo(out)
+		return order, false
+	}
+
+	/**
+
+	  SIDEPOST: MARSHAL COMPOUND DOCUMENT
+
+	*/
+	st.next("SIDEPOST: MARSHAL COMPOUND DOCUMENT")
+	payload := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(rec.body.Bytes(), &payload); err != nil {
+		h.logger(out, SubsystemEndpoint).Errorf("Cannot unmarshal the created resource for model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(out)
+		return order, false
+	}
+
+	primary := &sideposted{model: model}
+	if err = json.Unmarshal(payload["data"], &primary.data); err != nil || primary.data == nil {
+		h.logger(out, SubsystemEndpoint).Errorf("Cannot unmarshal the created resource data for model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(out)
+		return order, false
+	}
+	order = append(order, primary)
+
+	var included []json.RawMessage
+	if raw, ok := payload[memberIncluded]; ok {
+		if err = json.Unmarshal(raw, &included); err != nil {
+			h.logger(out, SubsystemEndpoint).Errorf("Cannot unmarshal the included resources for model: '%v'. %v", model.ModelType, err)
+			h.MarshalInternalError(out)
+			return order, false
+		}
+	}
+
+	for _, s := range order[:len(order)-1] {
+		raw, err := json.Marshal(s.data)
+		if err != nil {
+			h.logger(out, SubsystemEndpoint).Errorf("Cannot marshal the sideposted resource for model: '%v'. %v", s.model.ModelType, err)
+			h.MarshalInternalError(out)
+			return order, false
+		}
+		included = append(included, raw)
+	}
+
+	if payload[memberIncluded], err = json.Marshal(included); err != nil {
+		h.logger(out, SubsystemEndpoint).Errorf("Cannot marshal the included resources for model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(out)
+		return order, false
+	}
+
+	copyHeader(out.Header(), rec.Header())
+	out.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(out).Encode(payload); err != nil {
+		h.logger(out, SubsystemEndpoint).Errorf("Error while encoding sideposted response for model: '%v'. %v", model.ModelType, err)
+		return order, false
+	}
+	return order, true
+}
+
+// sidepostIncluded creates the included 'resource' using its model's Create pipeline.
+// If the resource could not be created the error is written to the 'rw' and the function
+// returns false.
+func (h *JSONAPIHandler) sidepostIncluded(
+	resource map[string]interface{},
+	pointer string,
+	created map[string]*sideposted,
+	rw http.ResponseWriter,
+	req *http.Request,
+) (*sideposted, bool) {
+	collection, _ := resource["type"].(string)
+	model := h.modelHandlerByCollection(collection)
+	if model == nil {
+		errObj := jsonapi.ErrInvalidResourceName.Copy()
+		errObj.Detail = fmt.Sprintf("Provided included resource type: '%s' is not supported.", collection)
+		SetErrorSource(errObj, &ErrorSource{Pointer: pointer + "/type"})
+		h.MarshalErrors(rw, errObj)
+		return nil, false
+	}
+
+	if model.Create == nil {
+		errObj := jsonapi.ErrEndpointForbidden.Copy()
+		errObj.Detail = fmt.Sprintf("Server does not allow to create the resources of the collection: '%s'.", collection)
+		SetErrorSource(errObj, &ErrorSource{Pointer: pointer})
+		h.MarshalErrors(rw, errObj)
+		return nil, false
+	}
+
+	if errObj := resolveLocalIDs(resource, created, pointer); errObj != nil {
+		h.MarshalErrors(rw, errObj)
+		return nil, false
+	}
+
+	lid, _ := resource[memberLocalID].(string)
+	delete(resource, memberLocalID)
+
+	body, err := json.Marshal(map[string]interface{}{"data": resource})
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Cannot marshal included resource for collection: '%s'. %v", collection, err)
+		h.MarshalInternalError(rw)
+		return nil, false
+	}
+
+	rec := newResponseRecorder(rw, req)
+	h.create(model, model.Create)(rec, requestWithBody(req, body))
+	if rec.Status() != http.StatusCreated {
+		writeIncludedErrors(rw, rec, pointer)
+		return nil, false
+	}
+
+	s := &sideposted{model: model}
+	doc := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	if err = json.Unmarshal(rec.body.Bytes(), &doc); err != nil || doc.Data == nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Cannot unmarshal the created included resource for collection: '%s'. %v", collection, err)
+		h.MarshalInternalError(rw)
+		return nil, false
+	}
+	s.data = doc.Data
+	if lid != "" {
+		s.data[memberLocalID] = lid
+	}
+	return s, true
+}
+
+// rollbackSideposted rolls back the transaction or, if the resources were not created within
+// the transaction, deletes the 'created' resources in the reverse order. It returns the
+// internal errors describing the resources that could not be rolled back.
+func (h *JSONAPIHandler) rollbackSideposted(
+	rw http.ResponseWriter,
+	tx Transaction,
+	created []*sideposted,
+) (failures []*jsonapi.ErrorObject) {
+	if tx != nil {
+		if dbErr := tx.Rollback(); dbErr != nil {
+			h.logger(rw, SubsystemDatabase).Errorf("Cannot rollback sidepost transaction. %v", dbErr)
+			errObj := jsonapi.ErrInternalError.Copy()
+			errObj.Detail = "The sideposted resources could not be rolled back."
+			failures = append(failures, errObj)
+		}
+		return failures
+	}
+
+	for i := len(created) - 1; i >= 0; i-- {
+		s := created[i]
+		if err := h.deleteSideposted(rw, s); err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Cannot rollback sideposted resource: '%v' for model: '%v'. %v", s.data["id"], s.model.ModelType, err)
+			errObj := jsonapi.ErrInternalError.Copy()
+			errObj.Detail = fmt.Sprintf("The sideposted resource: '%v' of type: '%v' could not be rolled back.", s.data["id"], s.data["type"])
+			failures = append(failures, errObj)
+		}
+	}
+	return failures
+}
+
+// deleteSideposted deletes the created resource by its id.
+func (h *JSONAPIHandler) deleteSideposted(rw http.ResponseWriter, s *sideposted) error {
+	// only the identifier is unmarshaled, so that the primary value is of the model's type
+	identifier, err := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{"type": s.data["type"], "id": s.data["id"]},
+	})
+	if err != nil {
+		return err
+	}
+
+	idScope, errObj, err := jsonapi.UnmarshalScopeOne(bytes.NewReader(identifier), h.Controller)
+	if err != nil {
+		return err
+	}
+	if errObj != nil {
+		return errObj
+	}
+
+	v := reflect.ValueOf(idScope.Value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return IErrValueNotValid
+	}
+	primary := v.Elem().Field(idScope.Struct.GetPrimaryField().GetFieldIndex()).Interface()
+
+	scope, err := h.Controller.NewScope(reflect.New(s.model.ModelType).Interface())
+	if err != nil {
+		return err
+	}
+	scope.SetIDFilters(primary)
+
+	if dbErr := h.repository(rw, s.model.ModelType).Delete(scope); dbErr != nil {
+		return errors.New(dbErr.Message)
+	}
+	return nil
+}
+
+// modelHandlerByCollection gets the model handler for the provided collection name.
+func (h *JSONAPIHandler) modelHandlerByCollection(collection string) *ModelHandler {
+	for t, model := range h.ModelHandlers {
+		if mStruct := h.Controller.Models.Get(t); mStruct != nil && mStruct.GetCollectionType() == collection {
+			return model
+		}
+	}
+	return nil
+}
+
+// resolveLocalIDs replaces the local ids within the resource's relationships with the ids of
+// the 'created' resources.
+func resolveLocalIDs(
+	resource map[string]interface{},
+	created map[string]*sideposted,
+	pointer string,
+) *jsonapi.ErrorObject {
+	relationships, _ := resource["relationships"].(map[string]interface{})
+	for name, rel := range relationships {
+		relationship, ok := rel.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var identifiers []interface{}
+		switch data := relationship["data"].(type) {
+		case map[string]interface{}:
+			identifiers = []interface{}{data}
+		case []interface{}:
+			identifiers = data
+		}
+
+		for _, identifier := range identifiers {
+			ident, ok := identifier.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			lid, ok := ident[memberLocalID].(string)
+			if !ok {
+				continue
+			}
+
+			s, found := created[localIDKey(ident["type"], lid)]
+			if !found {
+				errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
+				errObj.Detail = fmt.Sprintf("The local id: '%s' for the relationship: '%s' does not match any included resource.", lid, name)
+				SetErrorSource(errObj, &ErrorSource{Pointer: pointer + "/relationships/" + name})
+				return errObj
+			}
+			ident["id"] = s.data["id"]
+			delete(ident, memberLocalID)
+		}
+	}
+	return nil
+}
+
+// writeIncludedErrors writes the errors recorded while creating the included resource.
+// The source pointers are changed to point into the included resource.
+func writeIncludedErrors(rw http.ResponseWriter, rec *responseRecorder, pointer string) {
+	doc := struct {
+		Errors []map[string]interface{} `json:"errors"`
+	}{}
+	if err := json.Unmarshal(rec.body.Bytes(), &doc); err != nil {
+		rec.writeTo(rw)
+		return
+	}
+
+	for _, errObj := range doc.Errors {
+		source, ok := errObj["source"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if sourcePointer, ok := source["pointer"].(string); ok && strings.HasPrefix(sourcePointer, pointerData) {
+			source["pointer"] = pointer + strings.TrimPrefix(sourcePointer, pointerData)
+		}
+	}
+
+	copyHeader(rw.Header(), rec.Header())
+	rw.WriteHeader(rec.Status())
+	json.NewEncoder(rw).Encode(doc)
+}
+
+func localIDKey(collection interface{}, lid string) string {
+	return fmt.Sprintf("%v/%s", collection, lid)
+}
+
+// requestWithBody gets the shallow copy of the request with the provided 'body'.
+func requestWithBody(req *http.Request, body []byte) *http.Request {
+	r := req.WithContext(req.Context())
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return r
+}
@@ -0,0 +1,191 @@
+package jsonapisdk
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSidepost(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	postModel := h.ModelHandlers[reflect.TypeOf(Post{})]
+	postModel.Create.Sideposting = true
+
+	body := `{"data":{"type":"posts","attributes":{"title":"Sideposted"},"relationships":{"comments":{"data":[{"type":"comments","lid":"c1"}]}}},"included":[{"type":"comments","lid":"c1","attributes":{"body":"First"}}]}`
+
+	// Case 1:
+	// The included resource is created and linked with the primary resource.
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*Comment).ID = 5
+		})
+
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Post{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			post := arg.Value.(*Post)
+			if assert.Len(t, post.Comments, 1) {
+				assert.Equal(t, 5, post.Comments[0].ID)
+			}
+			post.ID = 3
+		})
+
+	rw, req := getHttpPair("POST", "/posts", strings.NewReader(body))
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	if assert.Equal(t, 201, rw.Result().StatusCode, rw.Body.String()) {
+		payload := struct {
+			Included []map[string]interface{} `json:"included"`
+		}{}
+		assert.NoError(t, json.Unmarshal(rw.Body.Bytes(), &payload))
+		if assert.Len(t, payload.Included, 1) {
+			assert.Equal(t, "5", payload.Included[0]["id"])
+			assert.Equal(t, "c1", payload.Included[0]["lid"])
+		}
+	}
+
+	// Case 2:
+	// The primary resource could not be created, the included one is deleted.
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*Comment).ID = 6
+		})
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Post{}))).Once().Return(unidb.ErrUniqueViolation.New())
+	mockRepo.On("Delete", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil)
+
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(body))
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 409, rw.Result().StatusCode)
+	mockRepo.AssertCalled(t, "Delete", mock.MatchedBy(matchScopeByType(Comment{})))
+
+	// Case 3:
+	// Unknown local id
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(`{"data":{"type":"posts","relationships":{"comments":{"data":[{"type":"comments","lid":"c2"}]}}},"included":[{"type":"comments","lid":"c1","attributes":{"body":"First"}}]}`))
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*Comment).ID = 7
+		})
+	mockRepo.On("Delete", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil)
+
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	// Case 4:
+	// Unknown included resource type
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(`{"data":{"type":"posts"},"included":[{"type":"unknown","lid":"u1"}]}`))
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	// Case 5:
+	// The included resource could not be deleted, the rollback failure is returned.
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			arg.Value.(*Comment).ID = 8
+		})
+	mockRepo.On("Create", mock.MatchedBy(matchScopeByType(Post{}))).Once().Return(unidb.ErrUniqueViolation.New())
+	mockRepo.On("Delete", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(unidb.ErrConnExc.New())
+
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(body))
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 500, rw.Result().StatusCode)
+	assert.Contains(t, rw.Body.String(), "could not be rolled back")
+}
+
+type mockTxCtxKey struct{}
+
+// mockTransactionalRepository is the MockRepository that implements the
+// TransactionalRepository. It records if the calls were bound to the transaction.
+type mockTransactionalRepository struct {
+	MockRepository
+	tx       *mockTransaction
+	unbound  int
+	bindings int
+}
+
+func (m *mockTransactionalRepository) Begin(ctx context.Context) (context.Context, Transaction, *unidb.Error) {
+	m.tx = &mockTransaction{}
+	return context.WithValue(ctx, mockTxCtxKey{}, m.tx), m.tx, nil
+}
+
+func (m *mockTransactionalRepository) WithContext(ctx context.Context) Repository {
+	m.bindings++
+	if ctx.Value(mockTxCtxKey{}) != m.tx {
+		m.unbound++
+	}
+	return m
+}
+
+type mockTransaction struct {
+	committed, rolledBack bool
+}
+
+func (t *mockTransaction) Commit() *unidb.Error {
+	t.committed = true
+	return nil
+}
+
+func (t *mockTransaction) Rollback() *unidb.Error {
+	t.rolledBack = true
+	return nil
+}
+
+func TestSidepostTransaction(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	repo := &mockTransactionalRepository{}
+	h.SetDefaultRepo(repo)
+
+	postModel := h.ModelHandlers[reflect.TypeOf(Post{})]
+	postModel.Create.Sideposting = true
+
+	body := `{"data":{"type":"posts","attributes":{"title":"Sideposted"},"relationships":{"comments":{"data":[{"type":"comments","lid":"c1"}]}}},"included":[{"type":"comments","lid":"c1","attributes":{"body":"First"}}]}`
+
+	// Case 1:
+	// All the resources are created within the committed transaction.
+	repo.On("Create", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value.(*Comment).ID = 5
+		})
+	repo.On("Create", mock.MatchedBy(matchScopeByType(Post{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value.(*Post).ID = 3
+		})
+
+	rw, req := getHttpPair("POST", "/posts", strings.NewReader(body))
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode, rw.Body.String())
+	if assert.NotNil(t, repo.tx) {
+		assert.True(t, repo.tx.committed)
+		assert.False(t, repo.tx.rolledBack)
+	}
+	assert.NotZero(t, repo.bindings)
+	assert.Zero(t, repo.unbound)
+
+	// Case 2:
+	// The primary resource could not be created, the transaction is rolled back.
+	repo.On("Create", mock.MatchedBy(matchScopeByType(Comment{}))).Once().Return(nil).Run(
+		func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value.(*Comment).ID = 6
+		})
+	repo.On("Create", mock.MatchedBy(matchScopeByType(Post{}))).Once().Return(unidb.ErrUniqueViolation.New())
+
+	rw, req = getHttpPair("POST", "/posts", strings.NewReader(body))
+	h.Create(postModel, postModel.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 409, rw.Result().StatusCode)
+	if assert.NotNil(t, repo.tx) {
+		assert.False(t, repo.tx.committed)
+		assert.True(t, repo.tx.rolledBack)
+	}
+	repo.AssertNotCalled(t, "Delete", mock.Anything)
+}
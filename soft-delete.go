@@ -0,0 +1,357 @@
+package jsonapisdk
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"net/http"
+	"reflect"
+)
+
+const (
+	queryFilterTrashed = "filter[trashed]"
+	trashedWith        = "with"
+	trashedOnly        = "only"
+)
+
+var (
+	IErrSoftDeleteField     = errors.New("Soft delete field not found within the model.")
+	IErrSoftDeleteRepo      = errors.New("The model's repository does not support soft deletes.")
+	IErrSoftDeleteNoHandler = errors.New("The model does not support soft deletes.")
+)
+
+// TrashedFilter defines which resources are taken for the soft deleted models.
+type TrashedFilter int
+
+const (
+	// TrashedWithout excludes trashed resources. It is the default filter.
+	TrashedWithout TrashedFilter = iota
+
+	// TrashedWith takes both trashed and not trashed resources.
+	TrashedWith
+
+	// TrashedOnly takes only the trashed resources.
+	TrashedOnly
+)
+
+// SoftDelete defines the soft delete settings for the model.
+type SoftDelete struct {
+	// Field is the jsonapi name of the model's time attribute that contains the deletion time.
+	// The attribute should be nullable i.e. of *time.Time type.
+	Field string
+
+	// TrashedAllowed defines if the request is permitted to use the 'filter[trashed]' query
+	// parameter with values: 'with' and 'only'. If nil the parameter is not allowed.
+	TrashedAllowed func(req *http.Request) bool
+}
+
+// getTrashedFilter gets the 'filter[trashed]' query parameter and removes it from the request's
+// query, so that it would not be parsed by the controller.
+// If the model does not support soft deletes or the request is not permitted to use the filter
+// an error is written to the response.
+func (h *JSONAPIHandler) getTrashedFilter(
+	model *ModelHandler,
+	rw http.ResponseWriter,
+	req *http.Request,
+) (trashed TrashedFilter, ok bool) {
+	query := req.URL.Query()
+	values, found := query[queryFilterTrashed]
+	if !found {
+		return TrashedWithout, true
+	}
+
+	query.Del(queryFilterTrashed)
+	req.URL.RawQuery = query.Encode()
+
+	if model.SoftDelete == nil {
+		errObj := jsonapi.ErrInvalidInput.Copy()
+		errObj.Detail = fmt.Sprintf("The '%s' query parameter is not supported for this collection.", queryFilterTrashed)
+		h.MarshalErrors(rw, errObj)
+		return
+	}
+
+	if len(values) != 1 {
+		errObj := jsonapi.ErrInvalidInput.Copy()
+		errObj.Detail = fmt.Sprintf("The '%s' query parameter may be provided only once.", queryFilterTrashed)
+		h.MarshalErrors(rw, errObj)
+		return
+	}
+
+	switch values[0] {
+	case trashedWith:
+		trashed = TrashedWith
+	case trashedOnly:
+		trashed = TrashedOnly
+	default:
+		errObj := jsonapi.ErrInvalidInput.Copy()
+		errObj.Detail = fmt.Sprintf("Invalid '%s' query parameter value: '%s'. Allowed values are: '%s' and '%s'.", queryFilterTrashed, values[0], trashedWith, trashedOnly)
+		h.MarshalErrors(rw, errObj)
+		return
+	}
+
+	if model.SoftDelete.TrashedAllowed == nil || !model.SoftDelete.TrashedAllowed(req) {
+		errObj := jsonapi.ErrInsufficientAccPerm.Copy()
+		errObj.Detail = "Not allowed to get the trashed resources."
+		h.MarshalErrors(rw, errObj)
+		return
+	}
+	return trashed, true
+}
+
+// addTrashedFilter adds the soft delete filter to the scope if the scope's model supports soft
+// deletes. The TrashedWithout adds the 'IS NULL' filter, the TrashedOnly adds 'IS NOT NULL' one.
+func (h *JSONAPIHandler) addTrashedFilter(scope *jsonapi.Scope, trashed TrashedFilter) error {
+	model, ok := h.ModelHandlers[scope.Struct.GetType()]
+	if !ok || model.SoftDelete == nil || trashed == TrashedWith {
+		return nil
+	}
+
+	field, err := h.softDeleteField(model, scope)
+	if err != nil {
+		return err
+	}
+
+	operator := jsonapi.OpEqual
+	if trashed == TrashedOnly {
+		operator = jsonapi.OpNotEqual
+	}
+
+	filter := &jsonapi.FilterField{
+		StructField: field,
+		Values:      []*jsonapi.FilterValues{{Operator: operator, Values: []interface{}{nil}}},
+	}
+	scope.AttributeFilters = append(scope.AttributeFilters, filter)
+	return nil
+}
+
+// trashedRepository gets the repository for the scope's model with respect to the trashed filter.
+// If the trashed resources should be taken, the soft delete repository is unscoped.
+func (h *JSONAPIHandler) trashedRepository(
+	scope *jsonapi.Scope,
+	trashed TrashedFilter,
+	rw http.ResponseWriter,
+) (Repository, error) {
+	repo := h.repository(rw, scope.Struct.GetType())
+	if trashed == TrashedWithout {
+		return repo, nil
+	}
+
+	softRepo, ok := asSoftDeleteRepository(repo)
+	if !ok {
+		return nil, IErrSoftDeleteRepo
+	}
+	return softRepo.Unscoped(), nil
+}
+
+// setTrashedScope adds the trashed filter and gets the repository for the scope.
+// On error it writes internal error to the response.
+func (h *JSONAPIHandler) setTrashedScope(
+	scope *jsonapi.Scope,
+	trashed TrashedFilter,
+	rw http.ResponseWriter,
+) (repo Repository, ok bool) {
+	if err := h.addTrashedFilter(scope, trashed); err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Cannot add trashed filter for the model: '%v'. %v", scope.Struct.GetType(), err)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	repo, err := h.trashedRepository(scope, trashed, rw)
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Cannot get trashed repository for the model: '%v'. %v", scope.Struct.GetType(), err)
+		h.MarshalInternalError(rw)
+		return
+	}
+	return repo, true
+}
+
+func (h *JSONAPIHandler) softDeleteField(
+	model *ModelHandler,
+	scope *jsonapi.Scope,
+) (*jsonapi.StructField, error) {
+	if model.SoftDelete == nil {
+		return nil, IErrSoftDeleteNoHandler
+	}
+
+	field := scope.Struct.GetAttributeField(model.SoftDelete.Field)
+	if field == nil {
+		return nil, IErrSoftDeleteField
+	}
+	return field, nil
+}
+
+// softDelete marks the resource defined by the scope as deleted using the SoftDeleteRepository.
+// The already trashed resources are not taken into account.
+func (h *JSONAPIHandler) softDelete(
+	model *ModelHandler,
+	scope *jsonapi.Scope,
+	repo Repository,
+	rw http.ResponseWriter,
+) (dbErr *unidb.Error, ok bool) {
+	softRepo, isSoft := asSoftDeleteRepository(repo)
+	if !isSoft {
+		h.logger(rw, SubsystemEndpoint).Errorf("Delete endpoint for model: '%v' error: %v", model.ModelType, IErrSoftDeleteRepo)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	field, err := h.softDeleteField(model, scope)
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Delete endpoint for model: '%v' error: %v", model.ModelType, err)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	if err = h.addTrashedFilter(scope, TrashedWithout); err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Cannot add trashed filter for model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(rw)
+		return
+	}
+	return softRepo.SoftDelete(scope, field), true
+}
+
+// Restore returns a http.HandlerFunc that restores the soft deleted resource.
+// The model must define the SoftDelete and its repository must implement SoftDeleteRepository.
+// Correctly Response with status '204' No Content.
+func (h *JSONAPIHandler) Restore(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
+	return h.wrapHandler(model, Restore, func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
+		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
+			h.MarshalInternalError(rw)
+			return
+		}
+		SetContentType(rw)
+
+		/**
+
+		  RESTORE: BUILD SCOPE
+
+		*/
+		st.next("RESTORE: BUILD SCOPE")
+		scope, err := h.Controller.NewScope(reflect.New(model.ModelType).Interface())
+		if err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Error while creating scope: '%v' for model: '%v'", err, model.ModelType)
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		/**
+
+		  RESTORE: GET ID FILTER
+
+		*/
+		st.next("RESTORE: GET ID FILTER")
+		errs, err := h.Controller.GetSetCheckIDFilter(req, scope)
+		if err != nil {
+			h.errSetIDFilter(scope, err, rw, req)
+			return
+		}
+
+		if len(errs) > 0 {
+			h.MarshalErrors(rw, errs...)
+			return
+		}
+
+		/**
+
+		  RESTORE: LANGUAGE
+
+		*/
+		st.next("RESTORE: LANGUAGE")
+		tag, ok := h.getModelLanguage(model, req, rw)
+		if !ok {
+			return
+		}
+		if scope.UseI18n() {
+			scope.SetLanguageFilter(tag.String())
+		}
+
+		/**
+
+		  RESTORE: PRECHECK PAIRS
+
+		*/
+		st.next("RESTORE: PRECHECK PAIRS")
+		if !h.AddPrecheckPairFilters(scope, model, endpoint, req, rw, endpoint.PrecheckPairs...) {
+			return
+		}
+
+		/**
+
+		  RESTORE: PRECHECK FILTERS
+
+		*/
+		st.next("RESTORE: PRECHECK FILTERS")
+		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
+			return
+		}
+
+		/**
+
+		  RESTORE: GET RELATIONSHIP FILTERS
+
+		*/
+		st.next("RESTORE: GET RELATIONSHIP FILTERS")
+		err = h.GetRelationshipFilters(scope, req, rw)
+		if err != nil {
+			if hErr := err.(*HandlerError); hErr != nil {
+				if !h.handleHandlerError(hErr, rw) {
+					return
+				}
+			} else {
+				h.logger(rw, SubsystemEndpoint).Error(err)
+				h.MarshalInternalError(rw)
+				return
+			}
+		}
+
+		/**
+
+		  RESTORE: TRASHED ONLY
+
+		*/
+		st.next("RESTORE: TRASHED ONLY")
+		field, err := h.softDeleteField(model, scope)
+		if err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Restore endpoint for model: '%v' error: %v", model.ModelType, err)
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		if err = h.addTrashedFilter(scope, TrashedOnly); err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Cannot add trashed filter for model: '%v'. %v", model.ModelType, err)
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		repo, ok := asSoftDeleteRepository(h.repository(rw, model.ModelType))
+		if !ok {
+			h.logger(rw, SubsystemEndpoint).Errorf("Restore endpoint for model: '%v' error: %v", model.ModelType, IErrSoftDeleteRepo)
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		/**
+
+		  RESTORE: REPOSITORY RESTORE
+
+		*/
+		st.next("RESTORE: REPOSITORY RESTORE")
+		scope.NewValueSingle()
+		if dbErr := repo.Restore(scope, field); dbErr != nil {
+			if dbErr.Compare(unidb.ErrNoResult) && endpoint.HasPrechecks() {
+				errObj := jsonapi.ErrInsufficientAccPerm.Copy()
+				errObj.Detail = "Given object is not available for this account or it does not exists."
+				h.MarshalErrors(rw, errObj)
+				return
+			}
+			h.manageDBError(rw, scope, dbErr)
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,194 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// mockSoftDeleteRepository is the MockRepository that implements the SoftDeleteRepository.
+type mockSoftDeleteRepository struct {
+	MockRepository
+}
+
+func (_m *mockSoftDeleteRepository) SoftDelete(scope *jsonapi.Scope, field *jsonapi.StructField) *unidb.Error {
+	dbErr, _ := _m.Called(scope, field).Get(0).(*unidb.Error)
+	return dbErr
+}
+
+func (_m *mockSoftDeleteRepository) Restore(scope *jsonapi.Scope, field *jsonapi.StructField) *unidb.Error {
+	dbErr, _ := _m.Called(scope, field).Get(0).(*unidb.Error)
+	return dbErr
+}
+
+func (_m *mockSoftDeleteRepository) Unscoped() Repository {
+	return _m
+}
+
+func prepareSoftDeleteHandler() (*JSONAPIHandler, *mockSoftDeleteRepository) {
+	h := prepareHandler(defaultLanguages, &Essay{}, &Review{})
+	repo := &mockSoftDeleteRepository{}
+	h.SetDefaultRepo(repo)
+
+	for _, model := range h.ModelHandlers {
+		model.SoftDelete = &SoftDelete{Field: "deleted_at"}
+	}
+	return h, repo
+}
+
+// trashedFilterOperator gets the operator of the scope's 'deleted_at' filter.
+func trashedFilterOperator(scope *jsonapi.Scope) (jsonapi.FilterOperator, bool) {
+	for _, filter := range scope.AttributeFilters {
+		if filter.GetFieldName() == "DeletedAt" && len(filter.Values) == 1 {
+			return filter.Values[0].Operator, true
+		}
+	}
+	return 0, false
+}
+
+func TestGetTrashedFilter(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	blogModel := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	// Case 1:
+	// No trashed filter provided
+	rw, req := getHttpPair("GET", "/blogs?include=current_post", nil)
+	trashed, ok := h.getTrashedFilter(blogModel, rw, req)
+	assert.True(t, ok)
+	assert.Equal(t, TrashedWithout, trashed)
+
+	// Case 2:
+	// The model does not support soft deletes
+	rw, req = getHttpPair("GET", "/blogs?filter[trashed]=with", nil)
+	_, ok = h.getTrashedFilter(blogModel, rw, req)
+	assert.False(t, ok)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	blogModel.SoftDelete = &SoftDelete{Field: "deleted_at"}
+
+	// Case 3:
+	// Trashed filter not allowed
+	rw, req = getHttpPair("GET", "/blogs?filter[trashed]=only", nil)
+	_, ok = h.getTrashedFilter(blogModel, rw, req)
+	assert.False(t, ok)
+	assert.Equal(t, 403, rw.Result().StatusCode)
+
+	blogModel.SoftDelete.TrashedAllowed = func(req *http.Request) bool { return true }
+
+	// Case 4:
+	// Invalid value
+	rw, req = getHttpPair("GET", "/blogs?filter[trashed]=all", nil)
+	_, ok = h.getTrashedFilter(blogModel, rw, req)
+	assert.False(t, ok)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	// Case 5:
+	// Allowed filter is removed from the query
+	rw, req = getHttpPair("GET", "/blogs?filter[trashed]=only&include=current_post", nil)
+	trashed, ok = h.getTrashedFilter(blogModel, rw, req)
+	assert.True(t, ok)
+	assert.Equal(t, TrashedOnly, trashed)
+	assert.Equal(t, "include=current_post", req.URL.RawQuery)
+}
+
+func TestHandlerSoftDelete(t *testing.T) {
+	h, repo := prepareSoftDeleteHandler()
+	essayModel := h.ModelHandlers[reflect.TypeOf(Essay{})]
+
+	// Case 1:
+	// The Delete endpoint marks not trashed resource as deleted
+	repo.On("SoftDelete", mock.AnythingOfType("*jsonapi.Scope"), mock.AnythingOfType("*jsonapi.StructField")).
+		Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			scope := args.Get(0).(*jsonapi.Scope)
+			op, ok := trashedFilterOperator(scope)
+			assert.True(t, ok)
+			assert.Equal(t, jsonapi.OpEqual, op)
+			assert.Equal(t, "DeletedAt", args.Get(1).(*jsonapi.StructField).GetFieldName())
+		})
+
+	rw, req := getHttpPair("DELETE", "/essays/1", nil)
+	h.Delete(essayModel, essayModel.Delete).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusNoContent, rw.Result().StatusCode)
+	repo.AssertNotCalled(t, "Delete", mock.Anything)
+
+	// Case 2:
+	// Already trashed resource is not found
+	repo.On("SoftDelete", mock.AnythingOfType("*jsonapi.Scope"), mock.AnythingOfType("*jsonapi.StructField")).
+		Once().Return(unidb.ErrNoResult.New())
+
+	rw, req = getHttpPair("DELETE", "/essays/1", nil)
+	h.Delete(essayModel, essayModel.Delete).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusNotFound, rw.Result().StatusCode)
+}
+
+func TestHandlerRestore(t *testing.T) {
+	h, repo := prepareSoftDeleteHandler()
+	essayModel := h.ModelHandlers[reflect.TypeOf(Essay{})]
+	endpoint := &Endpoint{Type: Restore}
+
+	// Case 1:
+	// Only the trashed resource is restored
+	repo.On("Restore", mock.AnythingOfType("*jsonapi.Scope"), mock.AnythingOfType("*jsonapi.StructField")).
+		Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			op, ok := trashedFilterOperator(args.Get(0).(*jsonapi.Scope))
+			assert.True(t, ok)
+			assert.Equal(t, jsonapi.OpNotEqual, op)
+		})
+
+	rw, req := getHttpPair("POST", "/essays/1/restore", nil)
+	h.Restore(essayModel, endpoint).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusNoContent, rw.Result().StatusCode)
+
+	// Case 2:
+	// Not trashed resource
+	repo.On("Restore", mock.AnythingOfType("*jsonapi.Scope"), mock.AnythingOfType("*jsonapi.StructField")).
+		Once().Return(unidb.ErrNoResult.New())
+
+	rw, req = getHttpPair("POST", "/essays/1/restore", nil)
+	h.Restore(essayModel, endpoint).ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusNotFound, rw.Result().StatusCode)
+
+	// Case 3:
+	// The model without soft deletes
+	essayModel.SoftDelete = nil
+	rw, req = getHttpPair("POST", "/essays/1/restore", nil)
+	h.Restore(essayModel, endpoint).ServeHTTP(rw, req)
+	assert.Equal(t, 500, rw.Result().StatusCode)
+}
+
+func TestTrashedExcludedFromIncludes(t *testing.T) {
+	h, repo := prepareSoftDeleteHandler()
+	essayModel := h.ModelHandlers[reflect.TypeOf(Essay{})]
+
+	repo.On("Get", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			scope := args.Get(0).(*jsonapi.Scope)
+			_, ok := trashedFilterOperator(scope)
+			assert.True(t, ok)
+			scope.Value = &Essay{ID: 1, Reviews: []*Review{{ID: 1}, {ID: 2}}}
+		})
+
+	repo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			scope := args.Get(0).(*jsonapi.Scope)
+			assert.Equal(t, reflect.TypeOf(Review{}), scope.Struct.GetType())
+			op, ok := trashedFilterOperator(scope)
+			assert.True(t, ok)
+			assert.Equal(t, jsonapi.OpEqual, op)
+
+			// the review with id: 2 is trashed
+			scope.Value = []*Review{{ID: 1, Body: "Fine"}}
+		})
+
+	rw, req := getHttpPair("GET", "/essays/1?include=reviews", nil)
+	h.Get(essayModel, essayModel.Get).ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Result().StatusCode)
+	assert.Contains(t, rw.Body.String(), `"Fine"`)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,221 @@
+package jsonapisdk
+
+import (
+	"context"
+	"github.com/kucjac/jsonapi"
+	"github.com/kucjac/uni-db"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"reflect"
+)
+
+const tracerName = "github.com/kucjac/jsonapi-sdk"
+
+// The attributes of the handler's spans.
+const (
+	attrModel               = attribute.Key("jsonapi.model")
+	attrCollection          = attribute.Key("jsonapi.collection")
+	attrEndpoint            = attribute.Key("jsonapi.endpoint")
+	attrStage               = attribute.Key("jsonapi.stage")
+	attrPrimaryFilters      = attribute.Key("jsonapi.filters.primary")
+	attrAttributeFilters    = attribute.Key("jsonapi.filters.attribute")
+	attrRelationshipFilters = attribute.Key("jsonapi.filters.relationship")
+	attrRows                = attribute.Key("jsonapi.rows")
+	attrErrorCode           = attribute.Key("jsonapi.error_code")
+	attrStatus              = attribute.Key("http.status_code")
+)
+
+// tracer gets the tracer of the handler's TracerProvider or the global tracer provider.
+func (h *JSONAPIHandler) tracer() trace.Tracer {
+	if h.TracerProvider != nil {
+		return h.TracerProvider.Tracer(tracerName)
+	}
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// startEndpointSpan starts the span of the model's endpoint. The returned request contains
+// the span's context.
+func (h *JSONAPIHandler) startEndpointSpan(
+	model *ModelHandler,
+	endpoint EndpointType,
+	req *http.Request,
+) (*http.Request, trace.Span) {
+	attrs := []attribute.KeyValue{attrEndpoint.String(endpoint.String())}
+	if model != nil {
+		attrs = append(attrs, h.modelAttributes(model.ModelType)...)
+	}
+
+	ctx, span := h.tracer().Start(req.Context(), "jsonapi."+endpoint.String(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+	return req.WithContext(ctx), span
+}
+
+// endEndpointSpan ends the endpoint's span with the written response status and error code.
+func endEndpointSpan(span trace.Span, w *responseWriter) {
+	span.SetAttributes(attrStatus.Int(w.Status()))
+	if w.errorCode != "" {
+		span.SetAttributes(attrErrorCode.String(w.errorCode))
+	}
+
+	if w.Status() >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(w.Status()))
+	}
+	span.End()
+}
+
+// modelAttributes gets the span attributes of the model.
+func (h *JSONAPIHandler) modelAttributes(model reflect.Type) []attribute.KeyValue {
+	return []attribute.KeyValue{attrModel.String(model.Name()), attrCollection.String(h.modelLabel(model))}
+}
+
+// traceWriter is the http.ResponseWriter that keeps the trace context of the handled request.
+// The context is changed by the traced stages, so that the spans started within the stage are
+// its children.
+type traceWriter interface {
+	traceContext() context.Context
+	setTraceContext(ctx context.Context)
+}
+
+// traceContext gets the current trace context of the 'rw'. If the 'rw' does not keep the trace
+// context, it returns nil.
+func traceContext(rw http.ResponseWriter) context.Context {
+	if w, ok := rw.(traceWriter); ok {
+		return w.traceContext()
+	}
+	return nil
+}
+
+// traceSpan starts the span within the current trace context of the 'rw'. The spans started
+// before the returned end function is called are the children of the span.
+func (h *JSONAPIHandler) traceSpan(
+	rw http.ResponseWriter,
+	name string,
+	attrs ...attribute.KeyValue,
+) (end func()) {
+	w, ok := rw.(traceWriter)
+	if !ok {
+		return func() {}
+	}
+
+	parent := w.traceContext()
+	ctx, span := h.tracer().Start(parent, name, trace.WithAttributes(attrs...))
+	w.setTraceContext(ctx)
+	return func() {
+		span.End()
+		w.setTraceContext(parent)
+	}
+}
+
+// stageTracer traces the stages of the endpoint's pipeline. The stage's span is started by the
+// next method and ended by the following stage or by the done method.
+type stageTracer struct {
+	h   *JSONAPIHandler
+	rw  http.ResponseWriter
+	end func()
+}
+
+// traceStages creates the stageTracer for the pipeline writing to the 'rw'.
+func (h *JSONAPIHandler) traceStages(rw http.ResponseWriter) *stageTracer {
+	return &stageTracer{h: h, rw: rw}
+}
+
+// next ends the current stage and starts the span of the next 'stage'.
+func (s *stageTracer) next(stage string) {
+	s.done()
+	s.end = s.h.traceSpan(s.rw, stage, attrStage.String(stage))
+}
+
+// done ends the current stage.
+func (s *stageTracer) done() {
+	if s.end != nil {
+		s.end()
+		s.end = nil
+	}
+}
+
+// repository gets the model's repository for the request bound to the 'rw'. The repository's
+// calls are traced within the current trace context of the 'rw' and observed by the handler's
+// metrics collector. If the repository implements ContextRepository, it is bound to the trace
+// context before each call.
+func (h *JSONAPIHandler) repository(rw http.ResponseWriter, model reflect.Type) Repository {
+	if traceContext(rw) == nil {
+		return h.GetRepositoryByType(model)
+	}
+
+	repo := h.getModelRepositoryByType(model)
+	if repo == nil {
+		return nil
+	}
+	return &instrumentedRepository{
+		repo:    repo,
+		model:   h.modelLabel(model),
+		metrics: h.Metrics,
+		tracer:  h.tracer(),
+		rw:      rw,
+	}
+}
+
+// startRepositorySpan starts the span of the repository 'method' call for the 'scope'.
+func startRepositorySpan(
+	ctx context.Context,
+	tracer trace.Tracer,
+	method string,
+	scope *jsonapi.Scope,
+) trace.Span {
+	attrs := []attribute.KeyValue{attribute.String("jsonapi.repository.method", method)}
+	if scope != nil && scope.Struct != nil {
+		attrs = append(attrs,
+			attrModel.String(scope.Struct.GetType().Name()),
+			attrCollection.String(scope.Struct.GetCollectionType()),
+			attrPrimaryFilters.Int(len(scope.PrimaryFilters)),
+			attrAttributeFilters.Int(len(scope.AttributeFilters)),
+			attrRelationshipFilters.Int(len(scope.RelationshipFilters)),
+		)
+	}
+
+	_, span := tracer.Start(ctx, "repository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return span
+}
+
+// endRepositorySpan ends the repository call's span with the number of the scope's rows and
+// the database error.
+func endRepositorySpan(span trace.Span, scope *jsonapi.Scope, dbErr *unidb.Error) {
+	if dbErr != nil {
+		span.SetAttributes(attribute.String("jsonapi.db_error", dbErrorLabel(dbErr)))
+		if !dbErr.Compare(unidb.ErrNoResult) {
+			span.SetStatus(codes.Error, dbErr.Message)
+		}
+	} else if scope != nil {
+		span.SetAttributes(attrRows.Int(scopeRows(scope)))
+	}
+	span.End()
+}
+
+// scopeRows gets the number of the resources within the scope's value.
+func scopeRows(scope *jsonapi.Scope) int {
+	if scope.Value == nil {
+		return 0
+	}
+
+	v := reflect.ValueOf(scope.Value)
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Len()
+	case reflect.Ptr:
+		if v.IsNil() {
+			return 0
+		}
+		if v.Elem().Kind() == reflect.Slice {
+			return v.Elem().Len()
+		}
+	}
+	return 1
+}
@@ -0,0 +1,74 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"reflect"
+	"testing"
+)
+
+func findSpan(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestHandlerTracing(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+
+	recorder := tracetest.NewSpanRecorder()
+	h.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	rw, req := getHttpPair("GET", "/blogs?filter[blogs][id][in]=1,2", nil)
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value = []*Blog{{ID: 1, Lang: "en"}, {ID: 2, Lang: "en"}}
+		})
+	h.List(model, model.List).ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Code)
+
+	spans := recorder.Ended()
+	endpointSpan := findSpan(spans, "jsonapi."+List.String())
+	if assert.NotNil(t, endpointSpan) {
+		collection, _ := spanAttribute(endpointSpan, attrCollection)
+		assert.Equal(t, "blogs", collection.AsString())
+
+		status, _ := spanAttribute(endpointSpan, attrStatus)
+		assert.Equal(t, int64(200), status.AsInt64())
+	}
+
+	stageSpan := findSpan(spans, "LIST: LIST FROM REPOSITORY")
+	if assert.NotNil(t, stageSpan) && endpointSpan != nil {
+		assert.Equal(t, endpointSpan.SpanContext().SpanID(), stageSpan.Parent().SpanID())
+	}
+
+	repoSpan := findSpan(spans, "repository.list")
+	if assert.NotNil(t, repoSpan) && stageSpan != nil {
+		assert.Equal(t, stageSpan.SpanContext().SpanID(), repoSpan.Parent().SpanID())
+
+		filters, _ := spanAttribute(repoSpan, attrPrimaryFilters)
+		assert.Equal(t, int64(1), filters.AsInt64())
+
+		rows, _ := spanAttribute(repoSpan, attrRows)
+		assert.Equal(t, int64(2), rows.AsInt64())
+	}
+}
@@ -0,0 +1,406 @@
+package jsonapisdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kucjac/jsonapi"
+	"golang.org/x/text/language"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// pathTranslations is the path segment of the i18n resource's translations endpoints
+// i.e. '/blogs/1/translations' or '/blogs/1/translations/de'.
+const pathTranslations = "translations"
+
+type translationCtxKey struct{}
+
+// translation is the translation endpoint's request information, kept within the request's
+// context.
+type translation struct {
+	id   string
+	lang string
+}
+
+// withTranslation gets the shallow copy of the request with the translation's context value.
+func withTranslation(req *http.Request, t *translation) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), translationCtxKey{}, t))
+}
+
+// translationOf gets the translation for the request. If the request was not made by the
+// translations endpoint it returns nil.
+func translationOf(req *http.Request) *translation {
+	t, _ := req.Context().Value(translationCtxKey{}).(*translation)
+	return t
+}
+
+// ListTranslations returns a http.HandlerFunc that lists all the translations of the i18n
+// resource. The endpoint's precheck pairs and precheck filters are applied.
+// Responds with the '404' Not Found status if the resource has no translations.
+func (h *JSONAPIHandler) ListTranslations(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
+	return h.wrapHandler(model, List, func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
+		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: BUILD SCOPE
+
+		*/
+		st.next("TRANSLATIONS: BUILD SCOPE")
+		scope, ok := h.translationsScope(model, rw, req)
+		if !ok {
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: PRECHECK PAIRS
+
+		*/
+		st.next("TRANSLATIONS: PRECHECK PAIRS")
+		if !h.AddPrecheckPairFilters(scope, model, endpoint, req, rw, endpoint.PrecheckPairs...) {
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: PRECHECK FILTERS
+
+		*/
+		st.next("TRANSLATIONS: PRECHECK FILTERS")
+		if !h.AddPrecheckFilters(scope, req, rw, endpoint.PrecheckFilters...) {
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: HOOK BEFORE READ
+
+		*/
+		st.next("TRANSLATIONS: HOOK BEFORE READ")
+		if errObj := h.runHooks(model, endpoint, BeforeRead, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: LIST FROM REPOSITORY
+
+		*/
+		st.next("TRANSLATIONS: LIST FROM REPOSITORY")
+		if dbErr := h.repository(rw, model.ModelType).List(scope); dbErr != nil {
+			h.manageDBError(rw, scope, dbErr)
+			return
+		}
+
+		if v := reflect.ValueOf(scope.Value); v.Kind() == reflect.Slice && v.Len() == 0 {
+			h.MarshalErrors(rw, jsonapi.ErrResourceNotFound.Copy())
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: HOOK AFTER READ
+
+		*/
+		st.next("TRANSLATIONS: HOOK AFTER READ")
+		if errObj := h.runHooks(model, endpoint, AfterRead, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+
+		h.MarshalScope(scope, rw, req)
+	})
+}
+
+// CreateTranslation returns a http.HandlerFunc that adds the translation for the existing i18n
+// resource. The language of the translation is taken from the resource's language field and the
+// id from the path. The translation is created by the model's Create pipeline, regardless of
+// the model's ClientIDPolicy.
+// Responds with the '404' Not Found status if the resource does not exists in any language,
+// is trashed or is excluded by the model's List endpoint prechecks.
+func (h *JSONAPIHandler) CreateTranslation(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
+	create := h.Create(model, endpoint)
+	return h.wrapHandler(model, Create, func(rw http.ResponseWriter, req *http.Request) {
+		st := h.traceStages(rw)
+		defer st.done()
+
+		if _, ok := h.ModelHandlers[model.ModelType]; !ok {
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: CHECK RESOURCE EXISTS
+
+		*/
+		st.next("TRANSLATIONS: CHECK RESOURCE EXISTS")
+		scope, ok := h.translationsScope(model, rw, req)
+		if !ok {
+			return
+		}
+
+		// the resource is checked with the List endpoint's prechecks and read hooks, so that
+		// the resource that could not be listed by the client is not found as well
+		list := model.List
+		if list != nil {
+			if !h.AddPrecheckPairFilters(scope, model, list, req, rw, list.PrecheckPairs...) {
+				return
+			}
+
+			if !h.AddPrecheckFilters(scope, req, rw, list.PrecheckFilters...) {
+				return
+			}
+		}
+
+		if errObj := h.runHooks(model, list, BeforeRead, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+
+		if dbErr := h.repository(rw, model.ModelType).List(scope); dbErr != nil {
+			h.manageDBError(rw, scope, dbErr)
+			return
+		}
+
+		if errObj := h.runHooks(model, list, AfterRead, req, scope); errObj != nil {
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+
+		if v := reflect.ValueOf(scope.Value); v.Kind() == reflect.Slice && v.Len() == 0 {
+			h.MarshalErrors(rw, jsonapi.ErrResourceNotFound.Copy())
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: SET ID
+
+		*/
+		st.next("TRANSLATIONS: SET ID")
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Error while reading body for path: '%s' and method: %s. Error: %s.", req.URL.Path, req.Method, err)
+			h.MarshalErrors(rw, jsonapi.ErrInvalidInput.Copy())
+			return
+		}
+
+		t := translationFromPath(req)
+		doc := map[string]interface{}{}
+		if err = json.Unmarshal(body, &doc); err != nil {
+			errObj := jsonapi.ErrInvalidInput.Copy()
+			errObj.Detail = "Invalid request document."
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+
+		data, ok := doc["data"].(map[string]interface{})
+		if !ok {
+			errObj := jsonapi.ErrInvalidInput.Copy()
+			errObj.Detail = "No primary data provided within the request document."
+			SetErrorSource(errObj, &ErrorSource{Pointer: pointerData})
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+
+		if id, ok := data["id"]; ok && fmt.Sprint(id) != t.id {
+			errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
+			errObj.Detail = fmt.Sprintf("The provided id: '%v' does not match the translated resource id: '%s'.", id, t.id)
+			SetErrorSource(errObj, &ErrorSource{Pointer: pointerID})
+			h.MarshalErrors(rw, errObj)
+			return
+		}
+		data["id"] = t.id
+
+		if body, err = json.Marshal(doc); err != nil {
+			h.logger(rw, SubsystemEndpoint).Errorf("Cannot marshal translation document for model: '%v'. %v", model.ModelType, err)
+			h.MarshalInternalError(rw)
+			return
+		}
+
+		/**
+
+		  TRANSLATIONS: CREATE
+
+		*/
+		st.next("TRANSLATIONS: CREATE")
+		create(rw, withTranslation(requestWithBody(req, body), t))
+	})
+}
+
+// PatchTranslation returns a http.HandlerFunc that patches the i18n resource's translation in
+// the language provided in the path. The translation is patched by the model's Patch pipeline.
+func (h *JSONAPIHandler) PatchTranslation(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
+	patch := h.Patch(model, endpoint)
+	return h.wrapHandler(model, Patch, func(rw http.ResponseWriter, req *http.Request) {
+		t, ok := h.checkTranslationLanguage(model, rw, req)
+		if !ok {
+			return
+		}
+		patch(rw, withTranslation(req, t))
+	})
+}
+
+// DeleteTranslation returns a http.HandlerFunc that deletes the i18n resource's translation in
+// the language provided in the path. The translation is deleted by the model's Delete pipeline.
+func (h *JSONAPIHandler) DeleteTranslation(model *ModelHandler, endpoint *Endpoint) http.HandlerFunc {
+	del := h.Delete(model, endpoint)
+	return h.wrapHandler(model, Delete, func(rw http.ResponseWriter, req *http.Request) {
+		t, ok := h.checkTranslationLanguage(model, rw, req)
+		if !ok {
+			return
+		}
+		del(rw, withTranslation(req, t))
+	})
+}
+
+// translationsScope creates the scope with the id filter for all the resource's translations.
+// The translations of the trashed resource are excluded.
+func (h *JSONAPIHandler) translationsScope(
+	model *ModelHandler,
+	rw http.ResponseWriter,
+	req *http.Request,
+) (*jsonapi.Scope, bool) {
+	scope, err := h.Controller.NewScope(reflect.New(model.ModelType).Interface())
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while creating scope: '%v' for model: '%v'", err, model.ModelType)
+		h.MarshalInternalError(rw)
+		return nil, false
+	}
+
+	if !scope.UseI18n() {
+		errObj := jsonapi.ErrEndpointForbidden.Copy()
+		errObj.Detail = fmt.Sprintf("The collection: '%s' is not translatable.", scope.Struct.GetCollectionType())
+		h.MarshalErrors(rw, errObj)
+		return nil, false
+	}
+
+	errs, err := h.Controller.GetSetCheckIDFilter(req, scope)
+	if err != nil {
+		h.errSetIDFilter(scope, err, rw, req)
+		return nil, false
+	}
+
+	if len(errs) > 0 {
+		h.MarshalErrors(rw, errs...)
+		return nil, false
+	}
+
+	// the translations of the trashed resource are not available
+	if err = h.addTrashedFilter(scope, TrashedWithout); err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Cannot add trashed filter for model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(rw)
+		return nil, false
+	}
+
+	scope.NewValueMany()
+	return scope, true
+}
+
+// checkTranslationLanguage gets the translation from the request's path and checks if its
+// language is supported by the model.
+func (h *JSONAPIHandler) checkTranslationLanguage(
+	model *ModelHandler,
+	rw http.ResponseWriter,
+	req *http.Request,
+) (*translation, bool) {
+	t := translationFromPath(req)
+	tag, err := language.Parse(t.lang)
+	if err != nil {
+		errObj := jsonapi.ErrInvalidInput.Copy()
+		errObj.Detail = fmt.Sprintf("Provided invalid language tag: '%s'.", t.lang)
+		h.MarshalErrors(rw, errObj)
+		return nil, false
+	}
+
+	supported := h.SupportedLanguages
+	if len(model.Languages) > 0 {
+		supported = model.Languages
+	}
+
+	if !containsTag(supported, tag) {
+		errObj := jsonapi.ErrLanguageNotAcceptable.Copy()
+		errObj.Detail = fmt.Sprintf("The language: '%s' is not supported by this collection.", t.lang)
+		h.MarshalErrors(rw, errObj)
+		return nil, false
+	}
+	t.lang = tag.String()
+	return t, true
+}
+
+// translationLanguage gets the language of the translation endpoint's request. If the request
+// was not made by the translations endpoint, the language negotiated from the request's headers
+// is returned.
+func (h *JSONAPIHandler) translationLanguage(
+	model *ModelHandler,
+	rw http.ResponseWriter,
+	req *http.Request,
+) (language.Tag, bool) {
+	if t := translationOf(req); t != nil && t.lang != "" {
+		return language.Make(t.lang), true
+	}
+	return h.getModelLanguage(model, req, rw)
+}
+
+// setTranslationLanguage sets the translation endpoint's language as the scope's language value.
+// If the value was provided with the different language, the error is written to the response.
+func (h *JSONAPIHandler) setTranslationLanguage(
+	scope *jsonapi.Scope,
+	rw http.ResponseWriter,
+	req *http.Request,
+) bool {
+	t := translationOf(req)
+	if t == nil || t.lang == "" {
+		return true
+	}
+
+	lang, err := scope.GetLangtagValue()
+	if err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while getting langtag from scope: '%v', Error: %v", scope.Struct.GetType(), err)
+		h.MarshalInternalError(rw)
+		return false
+	}
+
+	if lang != "" && language.Make(lang) != language.Make(t.lang) {
+		errObj := jsonapi.ErrInvalidJSONFieldValue.Copy()
+		errObj.Detail = fmt.Sprintf("The provided language: '%s' does not match the translation language: '%s'.", lang, t.lang)
+		h.MarshalErrors(rw, errObj)
+		return false
+	}
+
+	if err = scope.SetLangtagValue(t.lang); err != nil {
+		h.logger(rw, SubsystemEndpoint).Errorf("Error while setting langtag for scope: '%v', Error: %v", scope.Struct.GetType(), err)
+		h.MarshalInternalError(rw)
+		return false
+	}
+	return true
+}
+
+// translationFromPath gets the resource id and the language from the translations endpoint
+// path i.e. '/blogs/1/translations/de'.
+func translationFromPath(req *http.Request) *translation {
+	t := &translation{}
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment != pathTranslations || i == 0 {
+			continue
+		}
+		t.id = segments[i-1]
+		if i+1 < len(segments) {
+			t.lang = segments[i+1]
+		}
+	}
+	return t
+}
@@ -0,0 +1,127 @@
+package jsonapisdk
+
+import (
+	"github.com/kucjac/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTranslationFromPath(t *testing.T) {
+	_, req := getHttpPair("PATCH", "/blogs/1/translations/pl", nil)
+	assert.Equal(t, &translation{id: "1", lang: "pl"}, translationFromPath(req))
+
+	_, req = getHttpPair("GET", "/api/v1/blogs/2/translations", nil)
+	assert.Equal(t, &translation{id: "2"}, translationFromPath(req))
+}
+
+func TestListTranslations(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	// Case 1:
+	// All the translations are listed
+	rw, req := getHttpPair("GET", "/blogs/1/translations", nil)
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			assert.Nil(t, arg.LanguageFilters)
+			arg.Value = []*Blog{{ID: 1, Lang: "en"}, {ID: 1, Lang: "pl"}}
+		})
+	h.ListTranslations(model, model.List).ServeHTTP(rw, req)
+	assert.Equal(t, 200, rw.Result().StatusCode)
+
+	// Case 2:
+	// No translations
+	rw, req = getHttpPair("GET", "/blogs/1/translations", nil)
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value = []*Blog{}
+		})
+	h.ListTranslations(model, model.List).ServeHTTP(rw, req)
+	assert.Equal(t, 404, rw.Result().StatusCode)
+}
+
+func TestCreateTranslation(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+	model.ClientIDPolicy = ClientIDForbidden
+
+	// Case 1:
+	// The translation shares the resource's id
+	rw, req := getHttpPair("POST", "/blogs/1/translations", strings.NewReader(`{"data":{"type":"blogs","attributes":{"language":"pl"}}}`))
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value = []*Blog{{ID: 1, Lang: "en"}}
+		})
+	mockRepo.On("Create", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			blog := args.Get(0).(*jsonapi.Scope).Value.(*Blog)
+			assert.Equal(t, 1, blog.ID)
+			assert.Equal(t, "pl", blog.Lang)
+		})
+	h.CreateTranslation(model, model.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 201, rw.Result().StatusCode)
+
+	// Case 2:
+	// The resource does not exist
+	rw, req = getHttpPair("POST", "/blogs/2/translations", strings.NewReader(`{"data":{"type":"blogs","attributes":{"language":"pl"}}}`))
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value = []*Blog{}
+		})
+	h.CreateTranslation(model, model.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 404, rw.Result().StatusCode)
+
+	// Case 3:
+	// The resource is read with the List endpoint's hooks
+	if model.List == nil {
+		model.List = &Endpoint{Type: List}
+	}
+	var hooked bool
+	assert.NoError(t, model.List.AddHookFunc(BeforeRead, 0, func(req *http.Request, scope *jsonapi.Scope) error {
+		hooked = true
+		return nil
+	}))
+	rw, req = getHttpPair("POST", "/blogs/3/translations", strings.NewReader(`{"data":{"type":"blogs","attributes":{"language":"pl"}}}`))
+	mockRepo.On("List", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			args.Get(0).(*jsonapi.Scope).Value = []*Blog{}
+		})
+	h.CreateTranslation(model, model.Create).ServeHTTP(rw, req)
+	assert.Equal(t, 404, rw.Result().StatusCode)
+	assert.True(t, hooked)
+}
+
+func TestDeleteTranslation(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+	mockRepo := &MockRepository{}
+	h.SetDefaultRepo(mockRepo)
+	model := h.ModelHandlers[reflect.TypeOf(Blog{})]
+
+	// Case 1:
+	// The translation in the path language is deleted
+	rw, req := getHttpPair("DELETE", "/blogs/1/translations/pl", nil)
+	mockRepo.On("Delete", mock.AnythingOfType("*jsonapi.Scope")).Once().Return(nil).
+		Run(func(args mock.Arguments) {
+			arg := args.Get(0).(*jsonapi.Scope)
+			if assert.NotNil(t, arg.LanguageFilters) {
+				assert.Equal(t, "pl", arg.LanguageFilters.Values[0].Values[0])
+			}
+		})
+	h.DeleteTranslation(model, model.Delete).ServeHTTP(rw, req)
+	assert.Equal(t, 204, rw.Result().StatusCode)
+
+	// Case 2:
+	// Unsupported language
+	rw, req = getHttpPair("DELETE", "/blogs/1/translations/ja", nil)
+	h.DeleteTranslation(model, model.Delete).ServeHTTP(rw, req)
+	assert.Equal(t, 406, rw.Result().StatusCode)
+}
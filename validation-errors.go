@@ -0,0 +1,229 @@
+package jsonapisdk
+
+import (
+	"github.com/go-playground/locales"
+	"github.com/go-playground/universal-translator"
+	"github.com/kucjac/jsonapi"
+	"golang.org/x/text/language"
+	"gopkg.in/go-playground/validator.v9"
+	"net/http"
+	"strings"
+)
+
+const (
+	validationCodePrefix = "VALIDATION_"
+	validationMetaTag    = "tag"
+	validationMetaParam  = "param"
+	pointerData          = "/data"
+	pointerID            = "/data/id"
+	pointerAttributes    = "/data/attributes/"
+	pointerRelationships = "/data/relationships/"
+)
+
+// ValidationErrorCode returns the stable jsonapi error code for the validator tag.
+// i.e. for the tag 'required' it returns 'VALIDATION_REQUIRED'.
+func ValidationErrorCode(tag string) string {
+	return validationCodePrefix + strings.ToUpper(tag)
+}
+
+// RegisterValidationTranslations adds the locale translator to the handler's
+// ValidationTranslator and registers the translations for the Create and Patch validators.
+// The translations are also registered for the models' validators before they are used.
+// The 'register' function is i.e. the 'RegisterDefaultTranslations' from the
+// 'gopkg.in/go-playground/validator.v9/translations/<locale>' packages.
+func (h *JSONAPIHandler) RegisterValidationTranslations(
+	translator locales.Translator,
+	register func(v *validator.Validate, trans ut.Translator) error,
+) error {
+	if h.ValidationTranslator == nil {
+		h.ValidationTranslator = ut.New(translator, translator)
+	} else if err := h.ValidationTranslator.AddTranslator(translator, true); err != nil {
+		return err
+	}
+
+	trans, _ := h.ValidationTranslator.GetTranslator(translator.Locale())
+
+	h.validatorsLock.Lock()
+	h.validationTranslations = append(h.validationTranslations, &validationTranslation{trans: trans, register: register})
+	h.validatorsLock.Unlock()
+
+	if err := h.translateValidator(h.CreateValidator); err != nil {
+		return err
+	}
+	return h.translateValidator(h.PatchValidator)
+}
+
+type validationTranslation struct {
+	trans    ut.Translator
+	register func(v *validator.Validate, trans ut.Translator) error
+}
+
+// translateValidator registers the handler's validation translations that were not yet
+// registered for the validator.
+func (h *JSONAPIHandler) translateValidator(v *validator.Validate) error {
+	if v == nil {
+		return nil
+	}
+
+	h.validatorsLock.Lock()
+	defer h.validatorsLock.Unlock()
+
+	if h.translatedValidators == nil {
+		h.translatedValidators = make(map[*validator.Validate]int)
+	}
+
+	registered := h.translatedValidators[v]
+	for _, translation := range h.validationTranslations[registered:] {
+		if err := translation.register(v, translation.trans); err != nil {
+			return err
+		}
+		registered++
+		h.translatedValidators[v] = registered
+	}
+	return nil
+}
+
+// handleValidateError maps the validator errors into the jsonapi errors and writes them into the
+// response. Each error object contains the 'source.pointer' of the invalid field and the message
+// translated according to the request's language.
+func (h *JSONAPIHandler) handleValidateError(
+	model *ModelHandler,
+	err error,
+	rw http.ResponseWriter,
+	req *http.Request,
+) {
+	if _, ok := err.(*validator.InvalidValidationError); ok {
+		h.logger(rw, SubsystemValidation).Errorf("Invalid validation error for model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	vErrors, ok := err.(validator.ValidationErrors)
+	if !ok || len(vErrors) == 0 {
+		h.logger(rw, SubsystemValidation).Errorf("Unknown error type while validating model: '%v'. %v", model.ModelType, err)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	mStruct := h.Controller.Models.Get(model.ModelType)
+	if mStruct == nil {
+		h.logger(rw, SubsystemValidation).Errorf("No model found for: '%v' within the controller.", model.ModelType)
+		h.MarshalInternalError(rw)
+		return
+	}
+
+	trans := h.validationTranslator(req)
+
+	errs := make([]*jsonapi.ErrorObject, 0, len(vErrors))
+	for _, fieldErr := range vErrors {
+		// The fields that are not visible for the client have empty jsonapi name.
+		// The client is not allowed to change their values.
+		if fieldErr.Field() == "" {
+			h.MarshalErrors(rw, jsonapi.ErrInsufficientAccPerm.Copy())
+			return
+		}
+		errs = append(errs, validationErrorObject(mStruct, fieldErr, trans))
+	}
+	h.writeErrors(rw, translatorLanguage(trans), errs...)
+}
+
+// translatorLanguage gets the language of the validation translator. If the translator is nil
+// the messages are in english.
+func translatorLanguage(trans ut.Translator) language.Tag {
+	if trans == nil {
+		return language.English
+	}
+
+	tag, err := language.Parse(strings.Replace(trans.Locale(), "_", "-", -1))
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
+
+// validationTranslator gets the translator matching the request's 'Accept-Language' header.
+// If no request is provided the fallback translator is returned.
+func (h *JSONAPIHandler) validationTranslator(req *http.Request) ut.Translator {
+	if h.ValidationTranslator == nil {
+		return nil
+	}
+
+	if req == nil {
+		return h.ValidationTranslator.GetFallback()
+	}
+
+	tags, errObj := h.requestLanguages(req)
+	if errObj != nil || len(tags) == 0 {
+		return h.ValidationTranslator.GetFallback()
+	}
+
+	if h.LanguageMatcher != nil {
+		matched, _, _ := h.LanguageMatcher.Match(tags...)
+		tags = append([]language.Tag{matched}, tags...)
+	}
+
+	localeNames := make([]string, 0, len(tags)*2)
+	for _, tag := range tags {
+		localeNames = append(localeNames, strings.Replace(tag.String(), "-", "_", -1))
+		if base, conf := tag.Base(); conf != language.No {
+			localeNames = append(localeNames, base.String())
+		}
+	}
+
+	trans, _ := h.ValidationTranslator.FindTranslator(localeNames...)
+	return trans
+}
+
+// validationErrorObject creates the jsonapi error object for the validator's field error.
+func validationErrorObject(
+	mStruct *jsonapi.ModelStruct,
+	fieldErr validator.FieldError,
+	trans ut.Translator,
+) *jsonapi.ErrorObject {
+	var errObj *jsonapi.ErrorObject
+	if fieldErr.Tag() == "required" {
+		errObj = jsonapi.ErrMissingRequiredJSONField.Copy()
+	} else {
+		errObj = jsonapi.ErrInvalidJSONFieldValue.Copy()
+	}
+
+	errObj.Code = ValidationErrorCode(fieldErr.Tag())
+	if trans != nil {
+		errObj.Detail = fieldErr.Translate(trans)
+	} else {
+		errObj.Detail = fieldErr.Error()
+	}
+
+	meta := map[string]interface{}{validationMetaTag: fieldErr.Tag()}
+	if fieldErr.Param() != "" {
+		meta[validationMetaParam] = fieldErr.Param()
+	}
+	errObj.Meta = &meta
+
+	SetErrorSource(errObj, &ErrorSource{Pointer: validationPointer(mStruct, fieldErr)})
+	return errObj
+}
+
+// validationPointer gets the JSON Pointer to the invalid field within the request document.
+// The first part of the field error's namespace is the model's name.
+func validationPointer(mStruct *jsonapi.ModelStruct, fieldErr validator.FieldError) string {
+	var path []string
+	for _, part := range strings.Split(fieldErr.Namespace(), ".")[1:] {
+		if part != "" {
+			path = append(path, part)
+		}
+	}
+
+	if len(path) == 0 {
+		return pointerData
+	}
+
+	switch {
+	case path[0] == "id" && len(path) == 1:
+		return pointerID
+	case mStruct.GetRelationshipField(path[0]) != nil:
+		return pointerRelationships + strings.Join(path, "/")
+	default:
+		return pointerAttributes + strings.Join(path, "/")
+	}
+}
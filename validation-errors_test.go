@@ -0,0 +1,87 @@
+package jsonapisdk
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"reflect"
+	"testing"
+)
+
+type ValidatedModel struct {
+	ID     int      `jsonapi:"primary,validated"`
+	Name   string   `jsonapi:"attr,name" create:"required"`
+	Code   string   `jsonapi:"attr,code" create:"min=3"`
+	Secret string   `jsonapi:"attr,secret,hidden" create:"isdefault"`
+	Author *Author  `jsonapi:"relation,author" create:"required"`
+	Other  []*Model `jsonapi:"relation,others"`
+}
+
+func TestHandleValidateError(t *testing.T) {
+	h := prepareHandler(defaultLanguages, &ValidatedModel{}, &Author{}, &Model{}, &Blog{}, &Post{}, &Comment{})
+	model := h.ModelHandlers[reflect.TypeOf(ValidatedModel{})]
+
+	// Case 1:
+	// The errors contains pointer, code and meta
+	err := h.CreateValidator.Struct(&ValidatedModel{Code: "ab"})
+	assert.Error(t, err)
+
+	rw, req := getHttpPair("POST", "/validated", nil)
+	h.handleValidateError(model, err, rw, req)
+	assert.Equal(t, 400, rw.Result().StatusCode)
+
+	var doc struct {
+		Errors []struct {
+			Code   string                 `json:"code"`
+			Detail string                 `json:"detail"`
+			Source map[string]string      `json:"source"`
+			Meta   map[string]interface{} `json:"meta"`
+		} `json:"errors"`
+	}
+	assert.NoError(t, json.NewDecoder(rw.Body).Decode(&doc))
+	assert.Len(t, doc.Errors, 3)
+
+	pointers := map[string]string{}
+	for _, errObj := range doc.Errors {
+		pointers[errObj.Source["pointer"]] = errObj.Code
+		assert.NotEmpty(t, errObj.Detail)
+		assert.NotContains(t, errObj.Meta, errorMetaSource)
+	}
+	assert.Equal(t, "VALIDATION_REQUIRED", pointers["/data/attributes/name"])
+	assert.Equal(t, "VALIDATION_MIN", pointers["/data/attributes/code"])
+	assert.Equal(t, "VALIDATION_REQUIRED", pointers["/data/relationships/author"])
+
+	for _, errObj := range doc.Errors {
+		if errObj.Code == "VALIDATION_MIN" {
+			assert.Equal(t, "3", errObj.Meta[validationMetaParam])
+			assert.Equal(t, "min", errObj.Meta[validationMetaTag])
+		}
+	}
+
+	// Case 2:
+	// The hidden field results with insufficient access permissions
+	err = h.CreateValidator.Struct(&ValidatedModel{Name: "name", Code: "abc", Secret: "set", Author: &Author{}})
+	assert.Error(t, err)
+
+	rw, req = getHttpPair("POST", "/validated", nil)
+	h.handleValidateError(model, err, rw, req)
+	assert.Equal(t, 403, rw.Result().StatusCode)
+
+	// Case 3:
+	// Unknown error type
+	rw, req = getHttpPair("POST", "/validated", nil)
+	h.handleValidateError(model, assert.AnError, rw, req)
+	assert.Equal(t, 500, rw.Result().StatusCode)
+}
+
+func TestTranslatorLanguage(t *testing.T) {
+	h := prepareHandler(defaultLanguages, blogModels...)
+
+	// Case 1:
+	// No translator
+	assert.Equal(t, language.English, translatorLanguage(nil))
+
+	// Case 2:
+	// The fallback translator's locale
+	assert.Equal(t, language.English, translatorLanguage(h.ValidationTranslator.GetFallback()))
+}
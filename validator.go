@@ -1,10 +1,89 @@
 package jsonapisdk
 
 import (
+	"github.com/go-playground/universal-translator"
+	"gopkg.in/go-playground/validator.v9"
+	entranslations "gopkg.in/go-playground/validator.v9/translations/en"
 	"reflect"
 	"strings"
 )
 
+const (
+	createValidatorTag = "create"
+	patchValidatorTag  = "patch"
+)
+
+const (
+	// validationTagImmutable is the patch validator tag for the fields that could not be changed
+	// after the resource is created.
+	validationTagImmutable = "immutable"
+
+	// validationTagWriteOnce is the patch validator tag for the fields that could be set only if
+	// their current value is zero.
+	validationTagWriteOnce = "writeonce"
+)
+
+// newValidator creates the validator for provided struct tag name, which uses the jsonapi names
+// for the fields. The patch validator contains also the 'immutable' and 'writeonce' validations.
+func newValidator(tagName string) *validator.Validate {
+	v := validator.New()
+	v.SetTagName(tagName)
+	v.RegisterTagNameFunc(JSONAPITagFunc)
+
+	if tagName == patchValidatorTag {
+		// The immutable and write once fields are checked with their stored values after
+		// the patch prechecks.
+		v.RegisterValidation(validationTagImmutable, func(validator.FieldLevel) bool {
+			return true
+		})
+		v.RegisterValidation(validationTagWriteOnce, func(validator.FieldLevel) bool {
+			return true
+		})
+	}
+	return v
+}
+
+// registerEnglishTranslations registers the default english validation translations along
+// with the ones for the patch validator tags.
+func registerEnglishTranslations(v *validator.Validate, trans ut.Translator) error {
+	if err := entranslations.RegisterDefaultTranslations(v, trans); err != nil {
+		return err
+	}
+
+	messages := map[string]string{
+		validationTagImmutable: "{0} cannot be changed",
+		validationTagWriteOnce: "{0} is already set and cannot be changed",
+	}
+	for tag, message := range messages {
+		tag, message := tag, message
+		err := v.RegisterTranslation(tag, trans,
+			func(trans ut.Translator) error {
+				return trans.Add(tag, message, true)
+			},
+			func(trans ut.Translator, fe validator.FieldError) string {
+				t, _ := trans.T(fe.Tag(), fe.Field())
+				return t
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasValidationTag checks if the struct field's 'tagName' tag contains the validation 'tag'.
+func hasValidationTag(field reflect.StructField, tagName, tag string) bool {
+	for _, option := range strings.Split(field.Tag.Get(tagName), ",") {
+		for _, alternative := range strings.Split(option, "|") {
+			if alternative == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func JSONAPITagFunc(field reflect.StructField) string {
 	tagValue, ok := field.Tag.Lookup("jsonapi")
 	if !ok || tagValue == "" {